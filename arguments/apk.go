@@ -0,0 +1,29 @@
+package arguments
+
+import (
+	"github.com/grafana/grafana-build/fpm"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	APKPublicKeyFlag = &cli.StringFlag{
+		Name:    "apk-public-key-base64",
+		Usage:   "Provides a PEM encoded RSA public key encoded in base64 for signing apks",
+		EnvVars: []string{"APK_PUBLIC_KEY"},
+	}
+	APKPrivateKeyFlag = &cli.StringFlag{
+		Name:    "apk-private-key-base64",
+		Usage:   "Provides an unencrypted PEM encoded RSA private key encoded in base64 for signing apks",
+		EnvVars: []string{"APK_PRIVATE_KEY"},
+	}
+	APKKeyNameFlag = &cli.StringFlag{
+		Name:  "apk-key-name",
+		Usage: "The name of the apk signing key. Users install the public key as '/etc/apk/keys/<name>.rsa.pub'",
+		Value: fpm.DefaultAPKKeyName,
+	}
+
+	APKPublicKey  = pipeline.NewStringFlagArgument(APKPublicKeyFlag)
+	APKPrivateKey = pipeline.NewStringFlagArgument(APKPrivateKeyFlag)
+	APKKeyName    = pipeline.NewStringFlagArgument(APKKeyNameFlag)
+)
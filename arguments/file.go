@@ -0,0 +1,77 @@
+package arguments
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+// fileContentsArgument returns an argument whose value is the contents of the file on the host at the path given by 'flag'.
+// If the flag is not set, the value is an empty string.
+func fileContentsArgument(flag *cli.StringFlag) pipeline.Argument {
+	return pipeline.Argument{
+		Name:        flag.Name,
+		Description: flag.Usage,
+		Flags: []cli.Flag{
+			flag,
+		},
+		ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+			p := opts.CLIContext.String(flag.Name)
+			if p == "" {
+				return "", nil
+			}
+
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return nil, fmt.Errorf("error reading --%s: %w", flag.Name, err)
+			}
+
+			return string(b), nil
+		},
+	}
+}
+
+// hostFileArgument returns an optional argument whose value is the file on the host at the path given by 'flag'.
+// If the flag is not set, retrieving it from the state returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+func hostFileArgument(flag *cli.StringFlag) pipeline.Argument {
+	return pipeline.Argument{
+		Name:         flag.Name,
+		Description:  flag.Usage,
+		ArgumentType: pipeline.ArgumentTypeFile,
+		Flags: []cli.Flag{
+			flag,
+		},
+		ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+			p := opts.CLIContext.String(flag.Name)
+			if p == "" {
+				return nil, fmt.Errorf("%w: --%s", pipeline.ErrorFlagNotProvided, flag.Name)
+			}
+
+			return opts.Client.Host().File(p), nil
+		},
+	}
+}
+
+// hostDirectoryArgument returns an optional argument whose value is the directory on the host at the path given by 'flag'.
+// If the flag is not set, retrieving it from the state returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+func hostDirectoryArgument(flag *cli.StringFlag) pipeline.Argument {
+	return pipeline.Argument{
+		Name:         flag.Name,
+		Description:  flag.Usage,
+		ArgumentType: pipeline.ArgumentTypeDirectory,
+		Flags: []cli.Flag{
+			flag,
+		},
+		ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+			p := opts.CLIContext.String(flag.Name)
+			if p == "" {
+				return nil, fmt.Errorf("%w: --%s", pipeline.ErrorFlagNotProvided, flag.Name)
+			}
+
+			return opts.Client.Host().Directory(p), nil
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package arguments
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	FrontendBuildScriptFlag = &cli.StringFlag{
+		Name:  "frontend-build-script",
+		Usage: "The script in Grafana's package.json that builds the frontend, like 'build' or 'dev'. The 'frontend-dev' artifact flag takes precedence",
+		Value: frontend.DefaultBuildScript,
+	}
+	FrontendEnvFlag = &cli.StringSliceFlag{
+		Name:  "frontend-env",
+		Usage: "Environment variables set when building the frontend, in the format 'KEY=VALUE'. Can be set more than once. Setting NODE_OPTIONS replaces the default",
+	}
+	FrontendSourcemapsFlag = &cli.StringFlag{
+		Name:  "frontend-sourcemaps",
+		Usage: "What to do with sourcemaps produced by the frontend build: 'keep', 'remove', or 'hidden' (keep the files but remove the references to them). The 'no-sourcemaps' and 'hidden-sourcemaps' artifact flags take precedence",
+		Value: string(frontend.SourcemapsKeep),
+	}
+
+	FrontendBuildScript = pipeline.NewStringFlagArgument(FrontendBuildScriptFlag)
+	FrontendSourcemaps  = pipeline.NewStringFlagArgument(FrontendSourcemapsFlag)
+)
+
+// FrontendEnv is the list of environment variables from '--frontend-env', separated by newlines.
+var FrontendEnv = pipeline.Argument{
+	Name:        "frontend-env",
+	Description: FrontendEnvFlag.Usage,
+	Flags: []cli.Flag{
+		FrontendEnvFlag,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		env, err := frontend.ParseEnv(opts.CLIContext.StringSlice(FrontendEnvFlag.Name))
+		if err != nil {
+			return nil, err
+		}
+
+		return strings.Join(env, "\n"), nil
+	},
+}
+
+var (
+	FrontendReportPreviousFlag = &cli.StringFlag{
+		Name:  "frontend-report-previous",
+		Usage: "Path to a 'report.json' from a previous 'frontend-report' artifact. If set, the report includes the differences between the two",
+	}
+	FrontendReportBudgetFlag = &cli.StringFlag{
+		Name:  "frontend-report-budget",
+		Usage: "Path to a JSON file with maximum frontend bundle sizes. If set, verifying the 'frontend-report' artifact fails when a chunk or the total is over budget",
+	}
+
+	// FrontendReportPrevious is the contents of the previous report, or an empty string if it was not provided.
+	FrontendReportPrevious = fileContentsArgument(FrontendReportPreviousFlag)
+	// FrontendReportBudget is the contents of the budget file, or an empty string if it was not provided.
+	FrontendReportBudget = fileContentsArgument(FrontendReportBudgetFlag)
+)
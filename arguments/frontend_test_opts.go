@@ -0,0 +1,33 @@
+package arguments
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+func defaultFrontendTestSuites() string {
+	s := make([]string, len(frontend.DefaultTestSuites))
+	for i, v := range frontend.DefaultTestSuites {
+		s[i] = string(v)
+	}
+
+	return strings.Join(s, ",")
+}
+
+var (
+	FrontendTestSuitesFlag = &cli.StringFlag{
+		Name:  "frontend-test-suites",
+		Usage: "Comma-separated list of suites to run in the 'frontend-test' artifact; any of 'test', 'typecheck', and 'lint'",
+		Value: defaultFrontendTestSuites(),
+	}
+	FrontendTestShardFlag = &cli.StringFlag{
+		Name:  "frontend-test-shard",
+		Usage: "Split the jest tests into shards and only run one of them, in the format '{index}/{total}'. Typecheck and lint only run in the first shard. Example: '--frontend-test-shard=2/4'",
+	}
+
+	FrontendTestSuites = pipeline.NewStringFlagArgument(FrontendTestSuitesFlag)
+	FrontendTestShard  = pipeline.NewStringFlagArgument(FrontendTestShardFlag)
+)
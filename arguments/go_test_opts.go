@@ -0,0 +1,35 @@
+package arguments
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	GoTestPackagesFlag = &cli.StringFlag{
+		Name:  "go-test-packages",
+		Usage: "Comma-separated list of package patterns to test with the 'backend-test' artifact",
+		Value: strings.Join(backend.DefaultTestPackages, ","),
+	}
+	GoTestRunFlag = &cli.StringFlag{
+		Name:  "go-test-run",
+		Usage: "Only run the tests matching this regular expression (sets 'go test -run') in the 'backend-test' artifact",
+	}
+	GoTestShardFlag = &cli.StringFlag{
+		Name:  "go-test-shard",
+		Usage: "Split the tested packages into shards and only test one of them, in the format '{index}/{total}'. Example: '--go-test-shard=2/4'",
+	}
+	GoTestTimeoutFlag = &cli.StringFlag{
+		Name:  "go-test-timeout",
+		Usage: "Sets 'go test -timeout' in the 'backend-test' artifact",
+		Value: "30m",
+	}
+
+	GoTestPackages = pipeline.NewStringFlagArgument(GoTestPackagesFlag)
+	GoTestRun      = pipeline.NewStringFlagArgument(GoTestRunFlag)
+	GoTestShard    = pipeline.NewStringFlagArgument(GoTestShardFlag)
+	GoTestTimeout  = pipeline.NewStringFlagArgument(GoTestTimeoutFlag)
+)
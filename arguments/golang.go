@@ -1,6 +1,12 @@
 package arguments
 
 import (
+	"context"
+	"fmt"
+	"slices"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/golang"
 	"github.com/grafana/grafana-build/pipeline"
 	"github.com/urfave/cli/v2"
 )
@@ -11,12 +17,98 @@ const (
 )
 
 var GoVersionFlag = &cli.StringFlag{
-	Name:  "go-version",
-	Usage: "The Go version to use when compiling Grafana",
-	Value: DefaultGoVersion,
+	Name:        "go-version",
+	Usage:       "The Go version to use when compiling Grafana. If not set, the version is read from the 'toolchain' or 'go' directive in the Grafana go.mod, or from '.go-version'",
+	DefaultText: fmt.Sprintf("derived from the Grafana source, or %s", DefaultGoVersion),
+}
+
+// GoVersion is the version of Go used to compile Grafana.
+// The '--go-version' flag always takes precedence, and the Grafana source is not cloned when it is set. Otherwise the version is
+// derived from the Grafana source tree so that older release branches are built with the compiler they declare. If the source doesn't
+// declare a version or can't be read, DefaultGoVersion is used.
+var GoVersion = pipeline.Argument{
+	Name:        "go-version",
+	Description: GoVersionFlag.Usage,
+	Flags: []cli.Flag{
+		GoVersionFlag,
+	},
+	// GrafanaDirectory is only required if '--go-version' is not set.
+	Requires: []pipeline.Argument{
+		GrafanaDirectory,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		if v := opts.CLIContext.String(GoVersionFlag.Name); v != "" {
+			// The flag is only compared with the source if the source is a local directory, so that setting it never clones the repository.
+			if opts.CLIContext.String("grafana-dir") != "" {
+				warnGoVersionMismatch(ctx, opts, v)
+			}
+			opts.Log.Info("Using Go version", "version", v, "from", "--go-version")
+			return v, nil
+		}
+
+		src, err := opts.State.Directory(ctx, GrafanaDirectory)
+		if err != nil {
+			opts.Log.Warn("unable to read the Grafana source to derive the Go version; using the default", "version", DefaultGoVersion, "error", err)
+			return DefaultGoVersion, nil
+		}
+
+		version, source, err := goVersionFromSource(ctx, src)
+		if err != nil {
+			opts.Log.Warn("unable to derive the Go version from the Grafana source; using the default", "version", DefaultGoVersion, "error", err)
+			return DefaultGoVersion, nil
+		}
+
+		opts.Log.Info("Using Go version", "version", version, "from", source)
+		return version, nil
+	},
+}
+
+// warnGoVersionMismatch logs a warning if 'version' (from '--go-version') is not the version declared in the Grafana source.
+func warnGoVersionMismatch(ctx context.Context, opts *pipeline.ArgumentOpts, version string) {
+	src, err := opts.State.Directory(ctx, GrafanaDirectory)
+	if err != nil {
+		return
+	}
+
+	srcVersion, source, err := goVersionFromSource(ctx, src)
+	if err != nil || srcVersion == version {
+		return
+	}
+
+	opts.Log.Warn("--go-version does not match the version declared in the Grafana source", "flag", version, "source", source, "source_version", srcVersion)
 }
 
-var GoVersion = pipeline.NewStringFlagArgument(GoVersionFlag)
+// goVersionFromSource returns the Go version declared in the Grafana source tree, along with a short description of where it was found.
+func goVersionFromSource(ctx context.Context, src *dagger.Directory) (string, string, error) {
+	entries, err := src.Entries(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	if slices.Contains(entries, "go.mod") {
+		contents, err := src.File("go.mod").Contents(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		if v, err := golang.VersionFromGoMod([]byte(contents)); err == nil {
+			return v, "go.mod", nil
+		}
+	}
+
+	if slices.Contains(entries, ".go-version") {
+		contents, err := src.File(".go-version").Contents(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		v, err := golang.VersionFromGoVersionFile([]byte(contents))
+		if err != nil {
+			return "", "", err
+		}
+		return v, ".go-version", nil
+	}
+
+	return "", "", golang.ErrorNoVersion
+}
 
 var ViceroyVersionFlag = &cli.StringFlag{
 	Name:  "viceroy-version",
@@ -0,0 +1,66 @@
+package arguments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana-build/govulncheck"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	GovulncheckDBFlag = &cli.StringFlag{
+		Name:  "govulncheck-db",
+		Usage: "Path to a local copy of the Go vulnerability database (a directory, or a zip file like https://vuln.go.dev/vulndb.zip). Required for the 'govulncheck' artifact. If set, the backend binaries are also scanned when using '--verify'",
+	}
+	GovulncheckVersionFlag = &cli.StringFlag{
+		Name:  "govulncheck-version",
+		Usage: "The version of govulncheck that scans the backend binaries",
+		Value: govulncheck.DefaultVersion,
+	}
+	GovulncheckBinaryFlag = &cli.StringFlag{
+		Name:  "govulncheck-binary",
+		Usage: "Path to a prebuilt govulncheck binary for linux on the host's architecture. If it is not set, govulncheck is built with 'go install', which needs network access. Set '--govulncheck-version' to its version, because the version is part of the report's name",
+	}
+	GovulncheckPolicyFlag = &cli.StringFlag{
+		Name:  "govulncheck-policy",
+		Usage: "What to do when a vulnerable function is called by a backend binary. 'fail' fails verification; 'report' only reports the finding",
+		Value: string(govulncheck.PolicyFail),
+	}
+	GovulncheckAllowlistFlag = &cli.StringFlag{
+		Name:  "govulncheck-allowlist",
+		Usage: "Path to a file with vulnerability IDs (one per line) that are ignored by the 'fail' policy",
+	}
+)
+
+// GovulncheckDB is the Go vulnerability database used by govulncheck. It is optional; if the flag is not set then retrieving it from the state
+// returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+var GovulncheckDB = pipeline.Argument{
+	Name:         "govulncheck-db",
+	Description:  GovulncheckDBFlag.Usage,
+	ArgumentType: pipeline.ArgumentTypeDirectory,
+	Flags: []cli.Flag{
+		GovulncheckDBFlag,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		p := opts.CLIContext.String(GovulncheckDBFlag.Name)
+		if p == "" {
+			return nil, fmt.Errorf("%w: --%s", pipeline.ErrorFlagNotProvided, GovulncheckDBFlag.Name)
+		}
+
+		return govulncheck.DB(opts.Client, p)
+	},
+}
+
+// GovulncheckBinary is the prebuilt govulncheck binary from '--govulncheck-binary'. It is optional; if the flag is not set then retrieving it
+// from the state returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+var GovulncheckBinary = hostFileArgument(GovulncheckBinaryFlag)
+
+var (
+	GovulncheckVersion = pipeline.NewStringFlagArgument(GovulncheckVersionFlag)
+	GovulncheckPolicy  = pipeline.NewStringFlagArgument(GovulncheckPolicyFlag)
+)
+
+// GovulncheckAllowlist is the contents of the allowlist file, or an empty string if no allowlist was provided.
+var GovulncheckAllowlist = fileContentsArgument(GovulncheckAllowlistFlag)
@@ -181,9 +181,9 @@ func grafanaDirectory(ctx context.Context, opts *pipeline.ArgumentOpts) (any, er
 		}
 	}
 
-	nodeVersion, err := frontend.NodeVersion(opts.Client, src).Stdout(ctx)
+	node, err := nodeFromFlags(ctx, opts, src)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node version from source code: %w", err)
+		return nil, err
 	}
 
 	yarnCache, err := opts.State.CacheVolume(ctx, YarnCacheDirectory)
@@ -191,7 +191,7 @@ func grafanaDirectory(ctx context.Context, opts *pipeline.ArgumentOpts) (any, er
 		return nil, err
 	}
 
-	container := frontend.YarnInstall(opts.Client, src, nodeVersion, yarnCache, opts.Platform)
+	container := frontend.YarnInstall(opts.Client, src, node, yarnCache, opts.Platform)
 
 	if _, err := containers.ExitError(ctx, container); err != nil {
 		return nil, err
@@ -0,0 +1,35 @@
+package arguments
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-build/lint"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	PackageLintFlag = &cli.BoolFlag{
+		Name:  "package-lint",
+		Usage: "If set, deb and rpm packages are also linted with lintian and rpmlint when using '--verify'",
+	}
+	PackageLintPolicyFlag = &cli.StringFlag{
+		Name:  "package-lint-policy",
+		Usage: "What to do when lintian or rpmlint report an error. 'fail' fails verification; 'report' only reports the finding",
+		Value: string(lint.PolicyFail),
+	}
+)
+
+var PackageLint = pipeline.Argument{
+	Name:         "package-lint",
+	Description:  PackageLintFlag.Usage,
+	ArgumentType: pipeline.ArgumentTypeBool,
+	Flags: []cli.Flag{
+		PackageLintFlag,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		return opts.CLIContext.Bool(PackageLintFlag.Name), nil
+	},
+}
+
+var PackageLintPolicy = pipeline.NewStringFlagArgument(PackageLintPolicyFlag)
@@ -0,0 +1,65 @@
+package arguments
+
+import (
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	PackageMetadataFlag = &cli.StringFlag{
+		Name:  "package-metadata",
+		Usage: "Path to a JSON file with the metadata of the deb, rpm, apk, and msi packages and docker images. The other '--package-*' flags override the values in the file",
+	}
+	PackageVendorFlag = &cli.StringFlag{
+		Name:  "package-vendor",
+		Usage: "The vendor of the packages and docker images",
+	}
+	PackageMaintainerFlag = &cli.StringFlag{
+		Name:  "package-maintainer",
+		Usage: "The maintainer of the packages and docker images",
+	}
+	PackageHomepageFlag = &cli.StringFlag{
+		Name:  "package-homepage",
+		Usage: "The homepage of the packages and docker images",
+	}
+	PackageDescriptionFlag = &cli.StringFlag{
+		Name:  "package-description",
+		Usage: "The description of the packages and docker images",
+	}
+	PackageLicenseFlag = &cli.StringFlag{
+		Name:  "package-license",
+		Usage: "The license of the packages and docker images",
+	}
+	PackageDependsFlag = &cli.StringFlag{
+		Name:  "package-depends",
+		Usage: "Comma-separated list of extra dependencies of the deb, rpm, and apk packages, like 'ca-certificates,tzdata >= 2024a'",
+	}
+	MSIUpgradeCodeFlag = &cli.StringFlag{
+		Name:  "msi-upgrade-code",
+		Usage: "The upgrade code of the Grafana OSS Windows installer",
+	}
+	MSIEnterpriseUpgradeCodeFlag = &cli.StringFlag{
+		Name:  "msi-enterprise-upgrade-code",
+		Usage: "The upgrade code of the Grafana Enterprise Windows installer",
+	}
+	MSIProductNameFlag = &cli.StringFlag{
+		Name:  "msi-product-name",
+		Usage: "The product name of the Windows installer, which is shown in the list of installed programs",
+	}
+	MSITitleFlag = &cli.StringFlag{
+		Name:  "msi-title",
+		Usage: "The title of the product feature in the Windows installer",
+	}
+
+	PackageMetadata          = fileContentsArgument(PackageMetadataFlag)
+	PackageVendor            = pipeline.NewStringFlagArgument(PackageVendorFlag)
+	PackageMaintainer        = pipeline.NewStringFlagArgument(PackageMaintainerFlag)
+	PackageHomepage          = pipeline.NewStringFlagArgument(PackageHomepageFlag)
+	PackageDescription       = pipeline.NewStringFlagArgument(PackageDescriptionFlag)
+	PackageLicense           = pipeline.NewStringFlagArgument(PackageLicenseFlag)
+	PackageDepends           = pipeline.NewStringFlagArgument(PackageDependsFlag)
+	MSIUpgradeCode           = pipeline.NewStringFlagArgument(MSIUpgradeCodeFlag)
+	MSIEnterpriseUpgradeCode = pipeline.NewStringFlagArgument(MSIEnterpriseUpgradeCodeFlag)
+	MSIProductName           = pipeline.NewStringFlagArgument(MSIProductNameFlag)
+	MSITitle                 = pipeline.NewStringFlagArgument(MSITitleFlag)
+)
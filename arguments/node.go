@@ -0,0 +1,143 @@
+package arguments
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	NodeVersionFlag = &cli.StringFlag{
+		Name:        "node-version",
+		Usage:       "The node version used to build and test the frontend. If not set, the version is read from the '--node-tarball' file name, or from the '.nvmrc' file in the Grafana source",
+		DefaultText: "derived from the Grafana source",
+	}
+	NodeImageFlag = &cli.StringFlag{
+		Name:  "node-image",
+		Usage: "The image used for node containers, like a mirror of the official node image. Every '%s' is replaced with the node version",
+		Value: frontend.DefaultNodeImage,
+	}
+	NodeTarballFlag = &cli.StringFlag{
+		Name:  "node-tarball",
+		Usage: "Path to an official node release tarball (like 'node-v20.11.1-linux-x64.tar.xz'). Node is installed from it into '--node-tarball-image' instead of using '--node-image'",
+	}
+	NodeTarballImageFlag = &cli.StringFlag{
+		Name:  "node-tarball-image",
+		Usage: "The image that node is installed into when '--node-tarball' is set",
+		Value: frontend.DefaultNodeTarballImage,
+	}
+)
+
+// NodeVersion is the version of node used to build and test the frontend.
+// The '--node-version' flag always takes precedence; then the version in the '--node-tarball' file name, and then the '.nvmrc' in the
+// Grafana source.
+var NodeVersion = pipeline.Argument{
+	Name:        "node-version",
+	Description: NodeVersionFlag.Usage,
+	Flags: []cli.Flag{
+		NodeVersionFlag,
+		NodeTarballFlag,
+	},
+	Requires: []pipeline.Argument{
+		GrafanaDirectory,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		src, err := opts.State.Directory(ctx, GrafanaDirectory)
+		if err != nil {
+			return nil, err
+		}
+
+		version, from, err := nodeVersion(opts, func() (string, error) {
+			return frontend.NodeVersionFromSource(ctx, src)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Log.Info("Using node version", "version", version, "from", from)
+		return version, nil
+	},
+}
+
+// nodeVersion returns the node version from the flags, or from 'source' if it's not set by the flags, along with a short description of
+// where it was found.
+func nodeVersion(opts *pipeline.ArgumentOpts, source func() (string, error)) (string, string, error) {
+	if v := opts.CLIContext.String(NodeVersionFlag.Name); v != "" {
+		return frontend.TrimNodeVersion(v), "--" + NodeVersionFlag.Name, nil
+	}
+
+	if p := opts.CLIContext.String(NodeTarballFlag.Name); p != "" {
+		if v, ok := frontend.NodeTarballVersion(filepath.Base(p)); ok {
+			return v, "--" + NodeTarballFlag.Name, nil
+		}
+	}
+
+	v, err := source()
+	if err != nil {
+		return "", "", err
+	}
+
+	return v, ".nvmrc", nil
+}
+
+var NodeImage = pipeline.NewStringFlagArgument(NodeImageFlag)
+
+// NodeTarball is the node release tarball from '--node-tarball'. It is optional; if the flag is not set then retrieving it from the state
+// returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+var NodeTarball = pipeline.Argument{
+	Name:         "node-tarball",
+	Description:  NodeTarballFlag.Usage,
+	ArgumentType: pipeline.ArgumentTypeFile,
+	Flags: []cli.Flag{
+		NodeTarballFlag,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		p := opts.CLIContext.String(NodeTarballFlag.Name)
+		if p == "" {
+			return nil, fmt.Errorf("%w: --%s", pipeline.ErrorFlagNotProvided, NodeTarballFlag.Name)
+		}
+
+		return opts.Client.Host().File(p), nil
+	},
+}
+
+var NodeTarballImage = pipeline.NewStringFlagArgument(NodeTarballImageFlag)
+
+// NodeArguments are every argument that is used to create node containers (see 'frontend.Node').
+var NodeArguments = []pipeline.Argument{
+	NodeVersion,
+	NodeImage,
+	NodeTarball,
+	NodeTarballImage,
+	YarnOffline,
+}
+
+// nodeFromFlags returns the node setup for the source 'src' without using the state. It's used when preparing the Grafana directory, which
+// the NodeVersion argument requires.
+func nodeFromFlags(ctx context.Context, opts *pipeline.ArgumentOpts, src *dagger.Directory) (*frontend.Node, error) {
+	sourceVersion, sourceErr := frontend.NodeVersionFromSource(ctx, src)
+	version, _, err := nodeVersion(opts, func() (string, error) {
+		return sourceVersion, sourceErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	node := &frontend.Node{
+		Version:       version,
+		SourceVersion: sourceVersion,
+		Image:         opts.CLIContext.String(NodeImageFlag.Name),
+		TarballImage:  opts.CLIContext.String(NodeTarballImageFlag.Name),
+		YarnOffline:   opts.CLIContext.String(YarnCacheArchiveFlag.Name) != "",
+	}
+	if p := opts.CLIContext.String(NodeTarballFlag.Name); p != "" {
+		node.Tarball = opts.Client.Host().File(p)
+	}
+
+	return node, nil
+}
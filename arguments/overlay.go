@@ -0,0 +1,50 @@
+package arguments
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var OverlayFlag = &cli.StringFlag{
+	Name:  "overlay",
+	Usage: "Path to a directory that is copied over the root of the tarball, and so every package made from it, replacing files with the same path, like 'conf/defaults.ini'. In the deb, rpm, and apk packages, only the files in 'conf/provisioning' and the files that the packages already install in '/etc' are config files; every other file is replaced on upgrades",
+}
+
+// Overlay is the directory from '--overlay'. It is optional; if the flag is not set then retrieving it from the state returns an error that
+// wraps 'pipeline.ErrorFlagNotProvided'.
+var Overlay = hostDirectoryArgument(OverlayFlag)
+
+// OverlayDigest is the first 8 characters of the sha256 digest of the contents of the '--overlay' directory, or an empty string if it is not
+// set. It is added to the build ID of packages so that packages with different overlays have different names.
+var OverlayDigest = pipeline.Argument{
+	Name:        "overlay-digest",
+	Description: "The digest of the contents of the '--overlay' directory",
+	Requires: []pipeline.Argument{
+		Overlay,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		dir, err := opts.State.Directory(ctx, Overlay)
+		if err != nil {
+			if errors.Is(err, pipeline.ErrorFlagNotProvided) {
+				return "", nil
+			}
+			return nil, err
+		}
+
+		digest, err := dir.Digest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		digest = strings.TrimPrefix(digest, "sha256:")
+		if len(digest) > 8 {
+			digest = digest[:8]
+		}
+
+		return digest, nil
+	},
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana-build/containers"
+	"github.com/grafana/grafana-build/fpm"
 	"github.com/grafana/grafana-build/pipeline"
 	"github.com/grafana/grafana-build/stringutil"
 	"github.com/urfave/cli/v2"
@@ -67,3 +68,42 @@ var Version = pipeline.Argument{
 		return strings.ReplaceAll(version, "pre", buildID), nil
 	},
 }
+
+var PackageBuilderFlag = &cli.StringFlag{
+	Name:  "package-builder",
+	Usage: "What builds deb and rpm packages: 'fpm', or 'native' to write them with Go instead of with fpm in a ruby container",
+	Value: string(fpm.PackageBuilderFPM),
+}
+
+var PackageBuilder = pipeline.NewStringFlagArgument(PackageBuilderFlag)
+
+var (
+	DebTestImagesFlag = &cli.StringFlag{
+		Name:  "deb-test-images",
+		Usage: "Comma-separated list of images that debs are installed, upgraded, and removed on when using '--verify'. 'matrix' adds the supported Ubuntu LTS releases and Debian stable and oldstable. The e2e tests run on the first image",
+		Value: fpm.DefaultDebImage,
+	}
+	RPMTestImagesFlag = &cli.StringFlag{
+		Name:  "rpm-test-images",
+		Usage: "Comma-separated list of images that rpms are installed, upgraded, and removed on when using '--verify'. 'matrix' adds Rocky, Alma, Fedora, and openSUSE",
+		Value: fpm.DefaultRPMImage,
+	}
+	DebUpgradeFromFlag = &cli.StringFlag{
+		Name:  "deb-upgrade-from",
+		Usage: "Path to a previous deb. If set, '--verify' installs it first and checks that the config, data, and service state survive the upgrade",
+	}
+	RPMUpgradeFromFlag = &cli.StringFlag{
+		Name:  "rpm-upgrade-from",
+		Usage: "Path to a previous rpm. If set, '--verify' installs it first and checks that the config, data, and service state survive the upgrade",
+	}
+)
+
+var (
+	DebTestImages = pipeline.NewStringFlagArgument(DebTestImagesFlag)
+	RPMTestImages = pipeline.NewStringFlagArgument(RPMTestImagesFlag)
+
+	// DebUpgradeFrom and RPMUpgradeFrom are the previous packages that the upgrade tests start from. They are optional; if the flag is not
+	// set then retrieving them from the state returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+	DebUpgradeFrom = hostFileArgument(DebUpgradeFromFlag)
+	RPMUpgradeFrom = hostFileArgument(RPMUpgradeFromFlag)
+)
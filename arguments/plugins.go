@@ -0,0 +1,96 @@
+package arguments
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/grafana/grafana-build/plugins"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	BundlePluginFlag = &cli.StringSliceFlag{
+		Name:  "bundle-plugin",
+		Usage: "Path to a plugin zip file that is unpacked into 'plugins-bundled' in every package. Can be set more than once",
+	}
+	BundlePluginsDirFlag = &cli.StringFlag{
+		Name:  "bundle-plugins-dir",
+		Usage: "Path to a directory of plugin zip files that are unpacked into 'plugins-bundled' in every package",
+	}
+	BundlePluginsChecksumsFlag = &cli.StringFlag{
+		Name:  "bundle-plugins-checksums",
+		Usage: "Path to a checksum file in the 'sha256sum' format. If set, every bundled plugin zip file must be in it with a matching sha256",
+	}
+	BundlePluginsPublicKeyFlag = &cli.StringFlag{
+		Name:  "bundle-plugins-public-key",
+		Usage: "Path to an armored public key that the 'MANIFEST.txt' of every bundled plugin must be signed with",
+	}
+)
+
+// BundlePlugins is a directory with every plugin from '--bundle-plugin' and '--bundle-plugins-dir', unpacked into a folder named after the
+// plugin ID. Every plugin is validated before it is unpacked. It is optional; if neither flag is set then retrieving it from the state
+// returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+var BundlePlugins = pipeline.Argument{
+	Name:         "bundle-plugins",
+	Description:  "Additional plugins that are unpacked into 'plugins-bundled' in every package",
+	ArgumentType: pipeline.ArgumentTypeDirectory,
+	Flags: []cli.Flag{
+		BundlePluginFlag,
+		BundlePluginsDirFlag,
+		BundlePluginsChecksumsFlag,
+		BundlePluginsPublicKeyFlag,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		zips := opts.CLIContext.StringSlice(BundlePluginFlag.Name)
+		if dir := opts.CLIContext.String(BundlePluginsDirFlag.Name); dir != "" {
+			matches, err := filepath.Glob(filepath.Join(dir, "*.zip"))
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(matches)
+			zips = append(zips, matches...)
+		}
+		if len(zips) == 0 {
+			return nil, fmt.Errorf("%w: --%s or --%s", pipeline.ErrorFlagNotProvided, BundlePluginFlag.Name, BundlePluginsDirFlag.Name)
+		}
+
+		var sums map[string]string
+		if p := opts.CLIContext.String(BundlePluginsChecksumsFlag.Name); p != "" {
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return nil, fmt.Errorf("error reading --%s: %w", BundlePluginsChecksumsFlag.Name, err)
+			}
+			if sums, err = plugins.ParseChecksums(string(b)); err != nil {
+				return nil, err
+			}
+		}
+
+		dir := opts.Client.Directory()
+		ids := map[string]string{}
+		for _, v := range zips {
+			p, err := plugins.Open(v, sums)
+			if err != nil {
+				return nil, err
+			}
+			if other, ok := ids[p.JSON.ID]; ok {
+				return nil, fmt.Errorf("%w: '%s' and '%s' are both plugin '%s'", plugins.ErrorInvalidPlugin, other, v, p.JSON.ID)
+			}
+			ids[p.JSON.ID] = v
+
+			opts.Log.Info("Bundling plugin", "id", p.JSON.ID, "version", p.JSON.Info.Version, "signature", p.Manifest.SignatureType, "signed-by", p.Manifest.SignedByOrg, "file", v)
+			dir = dir.WithDirectory(p.JSON.ID, plugins.Unpack(opts.Client, opts.Client.Host().File(v), p))
+		}
+
+		if key := opts.CLIContext.String(BundlePluginsPublicKeyFlag.Name); key != "" {
+			if err := plugins.VerifySignatures(ctx, opts.Client, dir, opts.Client.Host().File(key)); err != nil {
+				return nil, err
+			}
+		}
+
+		return dir, nil
+	},
+}
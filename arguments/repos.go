@@ -0,0 +1,46 @@
+package arguments
+
+import (
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/grafana/grafana-build/repos"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	AptRepoSuiteFlag = &cli.StringFlag{
+		Name:  "apt-repo-suite",
+		Usage: "The suite (and codename) of the 'apt-repo' artifact, like 'stable' or 'beta'",
+		Value: repos.DefaultAptSuite,
+	}
+	AptRepoComponentFlag = &cli.StringFlag{
+		Name:  "apt-repo-component",
+		Usage: "The component of the 'apt-repo' artifact that the debs are added to",
+		Value: repos.DefaultAptComponent,
+	}
+	AptRepoDirFlag = &cli.StringFlag{
+		Name:  "apt-repo-dir",
+		Usage: "Path to an existing apt repository that the debs are added to. The debs that are already in its pool are kept in the indexes",
+	}
+	YumRepoDirFlag = &cli.StringFlag{
+		Name:  "yum-repo-dir",
+		Usage: "Path to an existing yum repository that the rpms are added to",
+	}
+	RepoOriginFlag = &cli.StringFlag{
+		Name:  "repo-origin",
+		Usage: "The origin and label of the package repositories",
+		Value: repos.DefaultOrigin,
+	}
+)
+
+var (
+	AptRepoSuite     = pipeline.NewStringFlagArgument(AptRepoSuiteFlag)
+	AptRepoComponent = pipeline.NewStringFlagArgument(AptRepoComponentFlag)
+	RepoOrigin       = pipeline.NewStringFlagArgument(RepoOriginFlag)
+)
+
+// AptRepoDir is an existing apt repository from '--apt-repo-dir'. It is optional; if the flag is not set then retrieving it from the state
+// returns an error that wraps 'pipeline.ErrorFlagNotProvided'.
+var AptRepoDir = hostDirectoryArgument(AptRepoDirFlag)
+
+// YumRepoDir is an existing yum repository from '--yum-repo-dir'. It is optional like AptRepoDir.
+var YumRepoDir = hostDirectoryArgument(YumRepoDirFlag)
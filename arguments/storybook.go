@@ -0,0 +1,26 @@
+package arguments
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+var StorybookMinStoriesFlag = &cli.Int64Flag{
+	Name:  "storybook-min-stories",
+	Usage: "The minimum number of stories that the storybook index must list when the 'storybook' artifact is verified",
+	Value: 50,
+}
+
+var StorybookMinStories = pipeline.Argument{
+	Name:         "storybook-min-stories",
+	Description:  StorybookMinStoriesFlag.Usage,
+	ArgumentType: pipeline.ArgumentTypeInt64,
+	Flags: []cli.Flag{
+		StorybookMinStoriesFlag,
+	},
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		return opts.CLIContext.Int64(StorybookMinStoriesFlag.Name), nil
+	},
+}
@@ -0,0 +1,53 @@
+package arguments
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-build/e2e"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/urfave/cli/v2"
+)
+
+// verifyValue is the value of '--verify'. It is a boolean flag, so '--verify' alone is the same as '--verify=e2e', but it also accepts the
+// verification mode, like '--verify=smoke'.
+type verifyValue struct {
+	mode e2e.Mode
+}
+
+func (v *verifyValue) Set(s string) error {
+	mode, err := e2e.ParseMode(s)
+	if err != nil {
+		return err
+	}
+
+	v.mode = mode
+	return nil
+}
+
+func (v *verifyValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return string(v.mode)
+}
+
+// IsBoolFlag allows '--verify' to be used without a value.
+func (v *verifyValue) IsBoolFlag() bool {
+	return true
+}
+
+var VerifyFlag = &cli.GenericFlag{
+	Name:  "verify",
+	Usage: "If set, then the artifacts that are built will be verified after being exported, depending on the artifact. '--verify' or '--verify=e2e' runs the cypress e2e tests from the Grafana source tree in the cypress/included image; '--verify=e2e-node' runs them in a node container with the node setup of the frontend build instead; '--verify=smoke' runs a quicker smoke test that checks the health, login, version, edition, and a built-in plugin",
+	Value: &verifyValue{},
+}
+
+// Verify is the verification mode from '--verify' as an 'e2e.Mode'. The flag itself is added by the 'artifacts' command, so this argument
+// has no flags.
+var Verify = pipeline.Argument{
+	Name:        "verify",
+	Description: VerifyFlag.Usage,
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		return opts.CLIContext.String(VerifyFlag.Name), nil
+	},
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 
+	"github.com/grafana/grafana-build/frontend"
 	"github.com/grafana/grafana-build/pipeline"
 	"github.com/urfave/cli/v2"
 )
@@ -16,12 +17,18 @@ var YarnCacheDirFlag = &cli.StringFlag{
 	Value:   "",
 }
 
+var YarnCacheArchiveFlag = &cli.StringFlag{
+	Name:  "yarn-cache-archive",
+	Usage: "Path to a yarn cache archive created by the 'yarn-cache' artifact. It's extracted into the yarn cache and 'yarn install' runs without the network, so packages that are not in the archive fail the install",
+}
+
 var YarnCacheDirectory = pipeline.Argument{
 	Name:         "yarn-cache-dir",
 	Description:  YarnCacheDirFlag.Usage,
 	ArgumentType: pipeline.ArgumentTypeCacheVolume,
 	Flags: []cli.Flag{
 		YarnCacheDirFlag,
+		YarnCacheArchiveFlag,
 	},
 	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
 		vol := opts.CLIContext.String(YarnCacheDirFlag.Name)
@@ -33,6 +40,12 @@ var YarnCacheDirectory = pipeline.Argument{
 		}
 
 		cache := opts.Client.CacheVolume("yarn-cache-dir")
+		if archive := opts.CLIContext.String(YarnCacheArchiveFlag.Name); archive != "" {
+			if _, err := frontend.SeedYarnCache(opts.Client, cache, opts.Client.Host().File(archive)).Sync(ctx); err != nil {
+				return nil, err
+			}
+		}
+
 		if vol == "" {
 			return cache, nil
 		}
@@ -52,3 +65,14 @@ var YarnCacheDirectory = pipeline.Argument{
 		return cache, nil
 	},
 }
+
+// YarnOffline is true if the yarn cache is seeded from '--yarn-cache-archive'. The flag itself is added by the YarnCacheDirectory argument,
+// so this argument has no flags.
+var YarnOffline = pipeline.Argument{
+	Name:         "yarn-offline",
+	Description:  "Whether 'yarn install' is ran without the network because the yarn cache is seeded from '--yarn-cache-archive'",
+	ArgumentType: pipeline.ArgumentTypeBool,
+	ValueFunc: func(ctx context.Context, opts *pipeline.ArgumentOpts) (any, error) {
+		return opts.CLIContext.String(YarnCacheArchiveFlag.Name) != "", nil
+	},
+}
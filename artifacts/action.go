@@ -8,6 +8,8 @@ import (
 	"os"
 
 	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/e2e"
 	"github.com/grafana/grafana-build/pipeline"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/sync/errgroup"
@@ -32,10 +34,14 @@ func Action(r Registerer, c *cli.Context) error {
 		parallel    = c.Int64("parallel")
 		destination = c.String("destination")
 		platform    = dagger.Platform(c.String("platform"))
-		verify      = c.Bool("verify")
 		checksum    = c.Bool("checksum")
 	)
 
+	verify, err := e2e.ParseMode(c.String(arguments.VerifyFlag.Name))
+	if err != nil {
+		return err
+	}
+
 	if len(artifactStrings) == 0 {
 		return errors.New("no artifacts specified. At least 1 artifact is required using the '--artifact' or '-a' flag")
 	}
@@ -107,7 +113,7 @@ func Action(r Registerer, c *cli.Context) error {
 		log := log.With("artifact", v.ArtifactString, "action", "export")
 		wg.Go(ExportArtifactFunc(ctx, client, sm, log, v, store, destination, checksum))
 	}
-	if verify {
+	if verify != e2e.ModeNone {
 		// Export the files from the dag, causing the containers to trigger.
 		for _, v := range artifacts {
 			log := log.With("artifact", v.ArtifactString, "action", "validate")
@@ -243,6 +249,9 @@ func verifyArtifact(ctx context.Context, client *dagger.Client, v *pipeline.Arti
 			return err
 		}
 
+		if h, ok := v.Handler.(pipeline.DependencyFileVerifier); ok {
+			return h.VerifyFileWithStore(ctx, client, store, file)
+		}
 		if err := v.Handler.VerifyFile(ctx, client, file); err != nil {
 			return err
 		}
@@ -0,0 +1,208 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/gpg"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/grafana/grafana-build/repos"
+)
+
+var (
+	AptRepoArguments = arguments.Join(
+		DebInitializer.Arguments,
+		[]pipeline.Argument{
+			arguments.AptRepoSuite,
+			arguments.AptRepoComponent,
+			arguments.AptRepoDir,
+			arguments.RepoOrigin,
+		},
+	)
+	AptRepoFlags = DebFlags
+)
+
+var AptRepoInitializer = Initializer{
+	InitializerFunc: NewAptRepoFromString,
+	Arguments:       AptRepoArguments,
+}
+
+// AptRepo is an apt repository with the debs for every package name and distribution in the artifact string.
+type AptRepo struct {
+	Opts repos.AptOpts
+	// Existing is the repository that the debs are added to. If it's nil, a new repository is created.
+	Existing *dagger.Directory
+
+	Debs []*pipeline.Artifact
+}
+
+func (a *AptRepo) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return a.Debs, nil
+}
+
+func (a *AptRepo) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	// The repository is created in its own container in repos.Apt.
+	return opts.Client.Container(), nil
+}
+
+func (a *AptRepo) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	panic("This artifact does not produce files")
+}
+
+func (a *AptRepo) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	debs := make([]*dagger.File, len(a.Debs))
+	for i, v := range a.Debs {
+		f, err := opts.Store.File(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		debs[i] = f
+	}
+
+	return repos.Apt(opts.Client, debs, a.Existing, a.Opts), nil
+}
+
+func (a *AptRepo) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (a *AptRepo) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("This artifact does not produce files")
+}
+
+func (a *AptRepo) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (a *AptRepo) Filename(ctx context.Context) (string, error) {
+	id, err := repoID(ctx, a.Debs, a.Existing, a.Opts.Origin, a.Opts.GPG.GPGPublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	signed := ""
+	if a.Opts.Sign {
+		signed = "signed"
+	}
+
+	return path.Join("apt-repo", joinIDs(a.Opts.Suite+"-"+a.Opts.Component, signed, id)), nil
+}
+
+// repoID returns the first 12 characters of a digest of the sorted names of the packages in a repository, the existing repository that they
+// are added to, and the options of the repository, so that repositories with different packages or options don't have the same names.
+func repoID(ctx context.Context, pkgs []*pipeline.Artifact, existing *dagger.Directory, opts ...string) (string, error) {
+	names := make([]string, len(pkgs))
+	for i, v := range pkgs {
+		name, err := v.Handler.Filename(ctx)
+		if err != nil {
+			return "", err
+		}
+		names[i] = name
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, v := range append(names, opts...) {
+		fmt.Fprintln(h, v)
+	}
+	if existing != nil {
+		digest, err := existing.Digest(ctx)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(h, digest)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))[:12], nil
+}
+
+func (a *AptRepo) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	panic("This artifact does not produce files")
+}
+
+// VerifyDirectory adds the repository as an apt source and checks that every deb in the pool can be installed from it.
+func (a *AptRepo) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	return repos.VerifyApt(ctx, client, dir, a.Opts)
+}
+
+func NewAptRepoFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	// The artifact string has more than one package name and distribution, so only the sign flag is parsed here.
+	options, err := pipeline.ParseFlags(artifact, []pipeline.Flag{flags.SignFlag})
+	if err != nil {
+		return nil, err
+	}
+	// The 'sign' flag signs the repository, not the debs.
+	debStrings, err := PackageArtifactStrings(artifact, "deb", flags.SignFlag.Name)
+	if err != nil {
+		return nil, err
+	}
+	debs := make([]*pipeline.Artifact, len(debStrings))
+	for i, v := range debStrings {
+		debs[i], err = NewDebFromString(ctx, log, v, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	suite, err := state.String(ctx, arguments.AptRepoSuite)
+	if err != nil {
+		return nil, err
+	}
+	component, err := state.String(ctx, arguments.AptRepoComponent)
+	if err != nil {
+		return nil, err
+	}
+	origin, err := state.String(ctx, arguments.RepoOrigin)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := state.Directory(ctx, arguments.AptRepoDir)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, err
+		}
+		existing = nil
+	}
+
+	sign, err := options.Bool(flags.Sign)
+	if err != nil {
+		return nil, err
+	}
+	var gpgOpts gpg.GPGOpts
+	if sign {
+		gpgOpts, err = GPGOpts(ctx, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeDirectory,
+		Flags:          AptRepoFlags,
+		Handler: &AptRepo{
+			Opts: repos.AptOpts{
+				Suite:     suite,
+				Component: component,
+				Origin:    origin,
+				Sign:      sign,
+				GPG:       gpgOpts,
+			},
+			Existing: existing,
+			Debs:     debs,
+		},
+	})
+}
@@ -2,6 +2,7 @@ package artifacts
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"path/filepath"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/backend"
 	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/govulncheck"
 	"github.com/grafana/grafana-build/packages"
 	"github.com/grafana/grafana-build/pipeline"
 )
@@ -19,6 +21,11 @@ var (
 		arguments.EnterpriseDirectory,
 		arguments.GoVersion,
 		arguments.ViceroyVersion,
+		arguments.GovulncheckDB,
+		arguments.GovulncheckVersion,
+		arguments.GovulncheckBinary,
+		arguments.GovulncheckPolicy,
+		arguments.GovulncheckAllowlist,
 	}
 
 	BackendFlags = flags.JoinFlags(
@@ -47,6 +54,9 @@ type Backend struct {
 	GoModCache   *dagger.CacheVolume
 	// Version is embedded in the binary at build-time
 	Version string
+
+	// Vulncheck is optional. If set, the binaries are scanned with govulncheck when they are verified.
+	Vulncheck *govulncheck.Opts
 }
 
 func (b *Backend) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
@@ -115,8 +125,22 @@ func (b *Backend) VerifyFile(ctx context.Context, client *dagger.Client, file *d
 }
 
 func (b *Backend) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
-	// Nothing to do (yet)
-	return nil
+	if err := backend.Verify(ctx, client, dir, b.Src, b.Distribution, b.BuildOpts); err != nil {
+		return err
+	}
+
+	// With the 'report' policy, findings are only available in the 'govulncheck' artifact.
+	if b.Vulncheck == nil || b.Vulncheck.Policy == govulncheck.PolicyReport {
+		return nil
+	}
+
+	report, err := govulncheck.Scan(ctx, govulncheck.Container(client, "", b.GoVersion, b.Vulncheck), dir)
+	if err != nil {
+		return err
+	}
+	report.Allow(b.Vulncheck.Allowlist)
+
+	return govulncheck.Check(report, b.Vulncheck.Policy)
 }
 
 type NewBackendOpts struct {
@@ -191,6 +215,11 @@ func NewBackendFromString(ctx context.Context, log *slog.Logger, artifact string
 		return nil, err
 	}
 
+	vulncheck, err := GovulncheckOpts(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
 	bopts := &backend.BuildOpts{
 		Version:           p.Version,
 		Enterprise:        p.Enterprise,
@@ -213,10 +242,57 @@ func NewBackendFromString(ctx context.Context, log *slog.Logger, artifact string
 			Src:            src,
 			GoModCache:     goModCache,
 			GoBuildCache:   goBuildCache,
+			Vulncheck:      vulncheck,
 		},
 	})
 }
 
+// GovulncheckOpts returns the govulncheck options from the state, or nil if no vulnerability database was provided.
+func GovulncheckOpts(ctx context.Context, state pipeline.StateHandler) (*govulncheck.Opts, error) {
+	db, err := state.Directory(ctx, arguments.GovulncheckDB)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	version, err := state.String(ctx, arguments.GovulncheckVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	bin, err := state.File(ctx, arguments.GovulncheckBinary)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, err
+		}
+		bin = nil
+	}
+
+	policyStr, err := state.String(ctx, arguments.GovulncheckPolicy)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := govulncheck.ParsePolicy(policyStr)
+	if err != nil {
+		return nil, err
+	}
+
+	allowlist, err := state.String(ctx, arguments.GovulncheckAllowlist)
+	if err != nil {
+		return nil, err
+	}
+
+	return &govulncheck.Opts{
+		Version:   version,
+		Binary:    bin,
+		DB:        db,
+		Policy:    policy,
+		Allowlist: govulncheck.ParseAllowlist(allowlist),
+	}, nil
+}
+
 func NewBackend(ctx context.Context, log *slog.Logger, artifact string, opts *NewBackendOpts) (*pipeline.Artifact, error) {
 	bopts := &backend.BuildOpts{
 		Version:           opts.Version,
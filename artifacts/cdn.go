@@ -0,0 +1,201 @@
+package artifacts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	CDNArguments = arguments.Join(
+		FrontendArguments,
+		[]pipeline.Argument{
+			arguments.Version,
+		},
+	)
+	CDNFlags = FrontendFlags
+)
+
+var CDNInitializer = Initializer{
+	InitializerFunc: NewCDNFromString,
+	Arguments:       CDNArguments,
+}
+
+// CDN produces the static assets that are uploaded to the CDN. The directory has:
+// * 'public', which is the built frontend with '.gz' and '.br' siblings for compressible files,
+// * 'manifest.json', which lists every asset with its hashes, content type, and cache control,
+// * 'cache-control.json', which separates the immutable (content-hashed) assets from the mutable ones.
+type CDN struct {
+	Enterprise bool
+	Version    string
+	// BuildID is the frontend build variant ID; it's empty for release builds.
+	BuildID string
+
+	Frontend *pipeline.Artifact
+}
+
+func (c *CDN) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return []*pipeline.Artifact{
+		c.Frontend,
+	}, nil
+}
+
+func (c *CDN) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	public, err := opts.Store.Directory(ctx, c.Frontend)
+	if err != nil {
+		return nil, err
+	}
+
+	return frontend.CDN(opts.Client, public), nil
+}
+
+func (c *CDN) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	panic("This artifact does not produce files")
+}
+
+func (c *CDN) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	out, err := builder.Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error compressing static assets: %w", err)
+	}
+
+	manifest, err := frontend.ParseCDNListing(c.Version, out)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheControl := manifest.CacheControl()
+	opts.Log.Info("prepared static assets", "assets", len(manifest.Assets), "immutable", len(cacheControl.Immutable.Files), "mutable", len(cacheControl.Mutable.Files))
+
+	m, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	cc, err := json.MarshalIndent(cacheControl, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Client.Directory().
+		WithDirectory("public", builder.Directory("/src/public")).
+		WithNewFile("manifest.json", string(m)).
+		WithNewFile("cache-control.json", string(cc)), nil
+}
+
+func (c *CDN) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (c *CDN) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (c *CDN) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (c *CDN) Filename(ctx context.Context) (string, error) {
+	n := "grafana"
+	if c.Enterprise {
+		n = "grafana-enterprise"
+	}
+
+	// The 'public' folder in this directory is at '${dist}/${version}/${name}/public', which is the layout that 'scripts/move_packages.go'
+	// expects for static assets.
+	dist := "cdn"
+	if c.BuildID != "" {
+		dist = "cdn-" + c.BuildID
+	}
+
+	return filepath.Join(dist, c.Version, n), nil
+}
+
+func (c *CDN) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	// Not a file
+	return nil
+}
+
+// VerifyDirectory checks that every file in the manifest exists in 'public'.
+func (c *CDN) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	contents, err := dir.File("manifest.json").Contents(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifest := &frontend.Manifest{}
+	if err := json.Unmarshal([]byte(contents), manifest); err != nil {
+		return fmt.Errorf("error parsing CDN manifest: %w", err)
+	}
+	if len(manifest.Assets) == 0 {
+		return fmt.Errorf("CDN manifest has no assets")
+	}
+
+	entries, err := dir.Directory("public").Glob(ctx, "**/*")
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(entries))
+	for _, v := range entries {
+		existing[v] = true
+	}
+
+	for _, v := range manifest.Files() {
+		if !existing[v] {
+			return fmt.Errorf("file '%s' is in the CDN manifest but not in 'public'", v)
+		}
+	}
+
+	return nil
+}
+
+func NewCDNFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	options, err := pipeline.ParseFlags(artifact, CDNFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	enterprise, err := options.Bool(flags.Enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := state.String(ctx, arguments.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	buildOpts, err := FrontendBuildOpts(ctx, options, state)
+	if err != nil {
+		return nil, err
+	}
+
+	frontendArtifact, err := NewFrontendFromString(ctx, log, artifact, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeDirectory,
+		Flags:          CDNFlags,
+		Handler: &CDN{
+			Enterprise: enterprise,
+			Version:    version,
+			BuildID:    buildOpts.ID(),
+			Frontend:   frontendArtifact,
+		},
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"log/slog"
 
+	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/cmd/flags"
 	"github.com/urfave/cli/v2"
 )
@@ -25,18 +26,12 @@ func ArtifactFlags(r Registerer) []cli.Flag {
 		Value: true,
 	}
 
-	verifyFlag := &cli.BoolFlag{
-		Name:  "verify",
-		Usage: "If true, then the artifacts that are built will be verified with e2e tests or similar after being exported, depending on the artifact",
-		Value: false,
-	}
-
 	flags := flags.Join(
 		[]cli.Flag{
 			artifactsFlag,
 			buildFlag,
 			publishFlag,
-			verifyFlag,
+			arguments.VerifyFlag,
 			flags.Platform,
 		},
 		flags.PublishFlags,
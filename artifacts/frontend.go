@@ -2,7 +2,7 @@ package artifacts
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
 	"path/filepath"
 
@@ -14,10 +14,19 @@ import (
 )
 
 var (
-	FrontendFlags     = flags.PackageNameFlags
-	FrontendArguments = []pipeline.Argument{
-		arguments.YarnCacheDirectory,
-	}
+	FrontendFlags = flags.JoinFlags(
+		flags.PackageNameFlags,
+		flags.FrontendVariantFlags,
+	)
+	FrontendArguments = arguments.Join(
+		[]pipeline.Argument{
+			arguments.YarnCacheDirectory,
+			arguments.FrontendBuildScript,
+			arguments.FrontendEnv,
+			arguments.FrontendSourcemaps,
+		},
+		arguments.NodeArguments,
+	)
 )
 
 var FrontendInitializer = Initializer{
@@ -30,6 +39,8 @@ type Frontend struct {
 	Version    string
 	Src        *dagger.Directory
 	YarnCache  *dagger.CacheVolume
+	BuildOpts  *frontend.BuildOpts
+	Node       *frontend.Node
 }
 
 // The frontend does not have any artifact dependencies.
@@ -39,7 +50,7 @@ func (f *Frontend) Dependencies(ctx context.Context) ([]*pipeline.Artifact, erro
 
 // Builder will return a node.js alpine container that matches the .nvmrc in the Grafana source repository
 func (f *Frontend) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
-	return FrontendBuilder(ctx, f.Src, f.YarnCache, opts)
+	return FrontendBuilder(ctx, f.Src, f.YarnCache, f.Node, opts)
 }
 
 func (f *Frontend) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
@@ -47,7 +58,7 @@ func (f *Frontend) BuildFile(ctx context.Context, builder *dagger.Container, opt
 }
 
 func (f *Frontend) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
-	return frontend.Build(builder), nil
+	return frontend.Build(builder, f.BuildOpts), nil
 }
 
 func (f *Frontend) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
@@ -73,10 +84,13 @@ func (f *Frontend) Filename(ctx context.Context) (string, error) {
 		n = "grafana-enterprise"
 	}
 
+	// Build variants (like dev builds) and builds with a different node version are stored separately from the default build.
+	public := joinIDs("public", f.BuildOpts.ID(), f.Node.ID())
+
 	// Important note: this path is only used in two ways:
 	// 1. When requesting an artifact be built and exported, this is the path where it will be exported to
 	// 2. In a map to distinguish when the same artifact is being built more than once
-	return filepath.Join(f.Version, n, "public"), nil
+	return filepath.Join(f.Version, n, public), nil
 }
 
 func (f *Frontend) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
@@ -115,10 +129,60 @@ func NewFrontendFromString(ctx context.Context, log *slog.Logger, artifact strin
 		return nil, err
 	}
 
-	return NewFrontend(ctx, log, artifact, version, enterprise, src, cache)
+	opts, err := FrontendBuildOpts(ctx, options, state)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFrontend(ctx, log, artifact, version, enterprise, src, cache, opts, node)
 }
 
-func NewFrontend(ctx context.Context, log *slog.Logger, artifact, version string, enterprise bool, src *dagger.Directory, cache *dagger.CacheVolume) (*pipeline.Artifact, error) {
+// FrontendBuildOpts returns the frontend build options from the artifact flags, falling back to the CLI arguments.
+func FrontendBuildOpts(ctx context.Context, options *pipeline.OptionsHandler, state pipeline.StateHandler) (*frontend.BuildOpts, error) {
+	script, err := options.String(flags.FrontendScript)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagOptionNotFound) {
+			return nil, err
+		}
+		script, err = state.String(ctx, arguments.FrontendBuildScript)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sourcemaps, err := options.String(flags.FrontendSourcemaps)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagOptionNotFound) {
+			return nil, err
+		}
+		sourcemaps, err = state.String(ctx, arguments.FrontendSourcemaps)
+		if err != nil {
+			return nil, err
+		}
+	}
+	policy, err := frontend.ParseSourcemapPolicy(sourcemaps)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := state.String(ctx, arguments.FrontendEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &frontend.BuildOpts{
+		Script:     script,
+		Env:        splitNonEmpty(env, "\n"),
+		Sourcemaps: policy,
+	}, nil
+}
+
+func NewFrontend(ctx context.Context, log *slog.Logger, artifact, version string, enterprise bool, src *dagger.Directory, cache *dagger.CacheVolume, opts *frontend.BuildOpts, node *frontend.Node) (*pipeline.Artifact, error) {
 	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
 		ArtifactString: artifact,
 		Type:           pipeline.ArtifactTypeDirectory,
@@ -128,6 +192,8 @@ func NewFrontend(ctx context.Context, log *slog.Logger, artifact, version string
 			Version:    version,
 			Src:        src,
 			YarnCache:  cache,
+			BuildOpts:  opts,
+			Node:       node,
 		},
 	})
 }
@@ -136,12 +202,8 @@ func FrontendBuilder(
 	ctx context.Context,
 	src *dagger.Directory,
 	cache *dagger.CacheVolume,
+	node *frontend.Node,
 	opts *pipeline.ArtifactContainerOpts,
 ) (*dagger.Container, error) {
-	nodeVersion, err := frontend.NodeVersion(opts.Client, src).Stdout(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get node version from source code: %w", err)
-	}
-
-	return frontend.Builder(opts.Client, opts.Platform, src, nodeVersion, cache), nil
+	return frontend.Builder(opts.Client, opts.Platform, src, node, cache), nil
 }
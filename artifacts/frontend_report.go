@@ -0,0 +1,196 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	FrontendReportArguments = arguments.Join(
+		FrontendArguments,
+		[]pipeline.Argument{
+			arguments.Version,
+			arguments.FrontendReportPrevious,
+			arguments.FrontendReportBudget,
+		},
+	)
+	FrontendReportFlags = FrontendFlags
+)
+
+var FrontendReportInitializer = Initializer{
+	InitializerFunc: NewFrontendReportFromString,
+	Arguments:       FrontendReportArguments,
+}
+
+// FrontendReport produces a directory with the sizes of every chunk in the frontend build as 'report.json' and 'report.md'.
+type FrontendReport struct {
+	Version  string
+	Frontend *pipeline.Artifact
+
+	// Previous is optional, and is used to add a diff to the report.
+	Previous *frontend.Report
+	// Budget is optional, and is used to fail verification.
+	Budget *frontend.Budget
+}
+
+func (f *FrontendReport) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return []*pipeline.Artifact{
+		f.Frontend,
+	}, nil
+}
+
+func (f *FrontendReport) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	public, err := opts.Store.Directory(ctx, f.Frontend)
+	if err != nil {
+		return nil, err
+	}
+
+	return frontend.Sizes(opts.Client, public), nil
+}
+
+func (f *FrontendReport) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	panic("This artifact does not produce files")
+}
+
+func (f *FrontendReport) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	out, err := builder.Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting frontend chunk sizes: %w", err)
+	}
+
+	report, err := frontend.ParseSizes(f.Version, out)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Previous != nil {
+		report.Compare(f.Previous)
+		opts.Log.Info("frontend size compared to previous report", "previous", f.Previous.Version, "gzip", frontend.FormatBytes(report.Diff.Total.Gzip), "changed", len(report.Diff.Chunks))
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Client.Directory().
+		WithNewFile("report.json", string(b)).
+		WithNewFile("report.md", report.Markdown()), nil
+}
+
+func (f *FrontendReport) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (f *FrontendReport) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (f *FrontendReport) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (f *FrontendReport) Filename(ctx context.Context) (string, error) {
+	// The frontend filename is unique for each build variant, so use it as a prefix.
+	public, err := f.Frontend.Handler.Filename(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Reports that are compared to different previous reports have different diffs.
+	previous := ""
+	if f.Previous != nil {
+		b, err := json.Marshal(f.Previous)
+		if err != nil {
+			return "", err
+		}
+		previous = fmt.Sprintf("vs-%x", sha256.Sum256(b))[:15]
+	}
+
+	return joinIDs(public+"-report", previous), nil
+}
+
+func (f *FrontendReport) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	// Not a file
+	return nil
+}
+
+// VerifyDirectory fails if there is a budget and the report is over it.
+func (f *FrontendReport) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	if f.Budget == nil {
+		return nil
+	}
+
+	contents, err := dir.File("report.json").Contents(ctx)
+	if err != nil {
+		return err
+	}
+
+	report := &frontend.Report{}
+	if err := json.Unmarshal([]byte(contents), report); err != nil {
+		return fmt.Errorf("error parsing frontend report: %w", err)
+	}
+
+	return f.Budget.Check(report)
+}
+
+func NewFrontendReportFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	version, err := state.String(ctx, arguments.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	previousJSON, err := state.String(ctx, arguments.FrontendReportPrevious)
+	if err != nil {
+		return nil, err
+	}
+	var previous *frontend.Report
+	if previousJSON != "" {
+		previous = &frontend.Report{}
+		if err := json.Unmarshal([]byte(previousJSON), previous); err != nil {
+			return nil, fmt.Errorf("error parsing previous frontend report: %w", err)
+		}
+	}
+
+	budgetJSON, err := state.String(ctx, arguments.FrontendReportBudget)
+	if err != nil {
+		return nil, err
+	}
+	var budget *frontend.Budget
+	if budgetJSON != "" {
+		budget, err = frontend.ParseBudget([]byte(budgetJSON))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	frontendArtifact, err := NewFrontendFromString(ctx, log, artifact, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeDirectory,
+		Flags:          FrontendReportFlags,
+		Handler: &FrontendReport{
+			Version:  version,
+			Frontend: frontendArtifact,
+			Previous: previous,
+			Budget:   budget,
+		},
+	})
+}
@@ -0,0 +1,161 @@
+package artifacts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/govulncheck"
+	"github.com/grafana/grafana-build/packages"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	GovulncheckArguments = BackendArguments
+	GovulncheckFlags     = BackendFlags
+)
+
+var GovulncheckInitializer = Initializer{
+	InitializerFunc: NewGovulncheckFromString,
+	Arguments:       GovulncheckArguments,
+}
+
+// Govulncheck scans the backend binaries with govulncheck and produces a JSON report of the findings.
+type Govulncheck struct {
+	Name         packages.Name
+	Distribution backend.Distribution
+	GoVersion    string
+	Opts         *govulncheck.Opts
+
+	Backend *pipeline.Artifact
+}
+
+func (g *Govulncheck) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return []*pipeline.Artifact{
+		g.Backend,
+	}, nil
+}
+
+func (g *Govulncheck) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	return govulncheck.Container(opts.Client, opts.Platform, g.GoVersion, g.Opts), nil
+}
+
+func (g *Govulncheck) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	bin, err := opts.Store.Directory(ctx, g.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := govulncheck.Scan(ctx, builder, bin)
+	if err != nil {
+		return nil, err
+	}
+	report.Allow(g.Opts.Allowlist)
+
+	for _, v := range report.Findings {
+		opts.Log.Warn("vulnerability found", "id", v.ID, "module", v.Module, "version", v.Version, "fixed", v.FixedVersion, "called", v.Called, "allowed", v.Allowed, "binaries", v.Binaries)
+	}
+
+	b, err := report.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Client.Directory().WithNewFile("govulncheck.json", string(b)).File("govulncheck.json"), nil
+}
+
+func (g *Govulncheck) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	panic("This artifact does not produce directories")
+}
+
+func (g *Govulncheck) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (g *Govulncheck) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (g *Govulncheck) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("This artifact does not produce directories")
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (g *Govulncheck) Filename(ctx context.Context) (string, error) {
+	return filepath.Join("govulncheck", g.Opts.Version, string(g.Name), string(g.Distribution)) + ".json", nil
+}
+
+// VerifyFile applies the policy to the report, so that '--verify' fails with the 'fail' policy and findings that are not allowed.
+func (g *Govulncheck) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	contents, err := file.Contents(ctx)
+	if err != nil {
+		return err
+	}
+
+	report := &govulncheck.Report{}
+	if err := json.Unmarshal([]byte(contents), report); err != nil {
+		return fmt.Errorf("error parsing govulncheck report: %w", err)
+	}
+
+	return govulncheck.Check(report, g.Opts.Policy)
+}
+
+func (g *Govulncheck) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	panic("This artifact does not produce directories")
+}
+
+func NewGovulncheckFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	vulncheck, err := GovulncheckOpts(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if vulncheck == nil {
+		return nil, errors.New("the 'govulncheck' artifact requires a vulnerability database; use the '--govulncheck-db' flag")
+	}
+
+	options, err := pipeline.ParseFlags(artifact, GovulncheckFlags)
+	if err != nil {
+		return nil, err
+	}
+	distro, err := options.String(flags.Distribution)
+	if err != nil {
+		return nil, err
+	}
+	name, err := options.String(flags.PackageName)
+	if err != nil {
+		return nil, err
+	}
+	goVersion, err := state.String(ctx, arguments.GoVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	backendArtifact, err := NewBackendFromString(ctx, log, artifact, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeFile,
+		Flags:          GovulncheckFlags,
+		Handler: &Govulncheck{
+			Name:         packages.Name(name),
+			Distribution: backend.Distribution(distro),
+			GoVersion:    goVersion,
+			Opts:         vulncheck,
+			Backend:      backendArtifact,
+		},
+	})
+}
@@ -0,0 +1,65 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+// NodeOpts returns the node setup from the state. 'src' is the Grafana source; its '.nvmrc' decides whether the node version was overridden.
+func NodeOpts(ctx context.Context, state pipeline.StateHandler, src *dagger.Directory) (*frontend.Node, error) {
+	version, err := state.String(ctx, arguments.NodeVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := state.String(ctx, arguments.NodeImage)
+	if err != nil {
+		return nil, err
+	}
+
+	tarballImage, err := state.String(ctx, arguments.NodeTarballImage)
+	if err != nil {
+		return nil, err
+	}
+
+	tarball, err := state.File(ctx, arguments.NodeTarball)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, err
+		}
+		tarball = nil
+	}
+
+	offline, err := state.Bool(ctx, arguments.YarnOffline)
+	if err != nil {
+		return nil, err
+	}
+
+	// Older versions may not have an '.nvmrc' if the version is set with '--node-version'; then the version is always treated as overridden.
+	sourceVersion, _ := frontend.NodeVersionFromSource(ctx, src)
+
+	return &frontend.Node{
+		Version:       version,
+		SourceVersion: sourceVersion,
+		Image:         image,
+		Tarball:       tarball,
+		TarballImage:  tarballImage,
+		YarnOffline:   offline,
+	}, nil
+}
+
+// joinIDs appends every non-empty identifier to the file name 'name', like the frontend build options ID and the node ID.
+func joinIDs(name string, ids ...string) string {
+	for _, v := range ids {
+		if v != "" {
+			name += "-" + v
+		}
+	}
+
+	return name
+}
@@ -15,9 +15,12 @@ import (
 
 var (
 	NPMPackagesFlags     = flags.PackageNameFlags
-	NPMPackagesArguments = []pipeline.Argument{
-		arguments.YarnCacheDirectory,
-	}
+	NPMPackagesArguments = arguments.Join(
+		[]pipeline.Argument{
+			arguments.YarnCacheDirectory,
+		},
+		arguments.NodeArguments,
+	)
 )
 
 var NPMPackagesInitializer = Initializer{
@@ -29,6 +32,7 @@ type NPMPackages struct {
 	Src       *dagger.Directory
 	YarnCache *dagger.CacheVolume
 	Version   string
+	Node      *frontend.Node
 }
 
 // The frontend does not have any artifact dependencies.
@@ -38,7 +42,7 @@ func (f *NPMPackages) Dependencies(ctx context.Context) ([]*pipeline.Artifact, e
 
 // Builder will return a node.js alpine container that matches the .nvmrc in the Grafana source repository
 func (f *NPMPackages) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
-	return FrontendBuilder(ctx, f.Src, f.YarnCache, opts)
+	return FrontendBuilder(ctx, f.Src, f.YarnCache, f.Node, opts)
 }
 
 func (f *NPMPackages) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
@@ -66,9 +70,9 @@ func (f *NPMPackages) VerifyFile(ctx context.Context, client *dagger.Client, fil
 	return nil
 }
 
+// VerifyDirectory checks the package.json and the files of every tarball, and then installs and imports every package with node.
 func (f *NPMPackages) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
-	// Nothing to verify (yet?)
-	return nil
+	return frontend.VerifyNPMPackages(ctx, client, dir, f.Node, f.Version)
 }
 
 // Filename should return a deterministic file or folder name that this build will produce.
@@ -80,7 +84,7 @@ func (f *NPMPackages) Filename(ctx context.Context) (string, error) {
 	// Important note: this path is only used in two ways:
 	// 1. When requesting an artifact be built and exported, this is the path where it will be exported to
 	// 2. In a map to distinguish when the same artifact is being built more than once
-	return filepath.Join(f.Version, "npm-packages"), nil
+	return filepath.Join(f.Version, joinIDs("npm-packages", f.Node.ID())), nil
 }
 
 func NewNPMPackagesFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
@@ -97,10 +101,15 @@ func NewNPMPackagesFromString(ctx context.Context, log *slog.Logger, artifact st
 		return nil, err
 	}
 
-	return NewNPMPackages(ctx, log, artifact, grafanaDir, version, cache)
+	node, err := NodeOpts(ctx, state, grafanaDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNPMPackages(ctx, log, artifact, grafanaDir, version, cache, node)
 }
 
-func NewNPMPackages(ctx context.Context, log *slog.Logger, artifact string, src *dagger.Directory, version string, cache *dagger.CacheVolume) (*pipeline.Artifact, error) {
+func NewNPMPackages(ctx context.Context, log *slog.Logger, artifact string, src *dagger.Directory, version string, cache *dagger.CacheVolume, node *frontend.Node) (*pipeline.Artifact, error) {
 	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
 		ArtifactString: artifact,
 		Type:           pipeline.ArtifactTypeDirectory,
@@ -109,6 +118,7 @@ func NewNPMPackages(ctx context.Context, log *slog.Logger, artifact string, src
 			Src:       src,
 			YarnCache: cache,
 			Version:   version,
+			Node:      node,
 		},
 	})
 }
@@ -0,0 +1,264 @@
+package artifacts
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/e2e"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/fpm"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/packages"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	APKArguments = arguments.Join(
+		TargzArguments,
+		[]pipeline.Argument{
+			arguments.APKKeyName,
+		},
+		PackageMetadataArguments,
+	)
+	APKFlags = flags.JoinFlags(
+		TargzFlags,
+		[]pipeline.Flag{
+			flags.SignFlag,
+			flags.NightlyFlag,
+		},
+	)
+	APKSignArguments = []pipeline.Argument{
+		arguments.APKPublicKey,
+		arguments.APKPrivateKey,
+	}
+)
+
+var APKInitializer = Initializer{
+	InitializerFunc: NewAPKFromString,
+	Arguments: arguments.Join(
+		APKArguments,
+		APKSignArguments,
+	),
+}
+
+// APK uses a built tar.gz package to create an .apk package for Alpine Linux with abuild.
+type APK struct {
+	Name         packages.Name
+	Version      string
+	BuildID      string
+	Distribution backend.Distribution
+	Enterprise   bool
+	NameOverride string
+	// Sign has the RSA key that signs the apk. If Sign.Sign is false, the apk is signed with a new key that nobody trusts.
+	Sign fpm.APKSignOpts
+	// VerifyMode is how the package is verified with '--verify'.
+	VerifyMode e2e.Mode
+	// Metadata overrides the vendor, maintainer, homepage, description, and license of the package and adds dependencies.
+	Metadata packages.Metadata
+	// Overlay is the directory from '--overlay', or nil. Its provisioning files are also installed as config files.
+	Overlay *dagger.Directory
+
+	Tarball *pipeline.Artifact
+
+	// Src is the source tree of Grafana. This should only be used in the verify function.
+	Src       *dagger.Directory
+	YarnCache *dagger.CacheVolume
+	// Node is the node setup that the e2e tests run with. This should only be used in the verify function.
+	Node *frontend.Node
+}
+
+func (d *APK) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return []*pipeline.Artifact{
+		d.Tarball,
+	}, nil
+}
+
+func (d *APK) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	return fpm.APKBuilder(opts.Client, d.Distribution), nil
+}
+
+func (d *APK) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	targz, err := opts.Store.File(ctx, d.Tarball)
+	if err != nil {
+		return nil, err
+	}
+
+	overlayConfig, err := overlayConfigFiles(ctx, d.Overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	return fpm.BuildAPK(opts.Client, builder, fpm.BuildOpts{
+		Name:         d.Name,
+		Enterprise:   d.Enterprise,
+		Version:      d.Version,
+		BuildID:      d.BuildID,
+		Distribution: d.Distribution,
+		PackageType:  fpm.PackageTypeAPK,
+		NameOverride: d.NameOverride,
+		// OpenRC sources '/etc/conf.d/grafana-server' before the init script, so the deb's environment file works unchanged.
+		ConfigFiles: append([][]string{
+			{"/src/packaging/deb/default/grafana-server", "/pkg/etc/conf.d/grafana-server"},
+		}, overlayConfig...),
+		EnvFolder: "/pkg/etc/conf.d",
+		Metadata:  d.Metadata,
+	}, d.Sign, targz)
+}
+
+func (d *APK) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	panic("This artifact does not produce directories")
+}
+
+func (d *APK) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *APK) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *APK) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("This artifact does not produce directories")
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (d *APK) Filename(ctx context.Context) (string, error) {
+	name := d.Name
+	if d.NameOverride != "" {
+		name = packages.Name(d.NameOverride)
+	}
+
+	signed := ""
+	if d.Sign.Sign {
+		signed = "signed"
+	}
+
+	return packages.FileName(name, d.Version, joinIDs(d.BuildID, signed), d.Distribution, "apk")
+}
+
+func (d *APK) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	return fpm.VerifyAPK(ctx, client, file, d.Distribution, d.Enterprise, d.Sign, validateOpts(d.VerifyMode, d.Src, d.YarnCache, d.Node, d.Version, d.Enterprise))
+}
+
+func (d *APK) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	panic("This artifact does not produce directories")
+}
+
+func NewAPKFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	tarball, err := NewTarballFromString(ctx, log, artifact, state)
+	if err != nil {
+		return nil, err
+	}
+	options, err := pipeline.ParseFlags(artifact, APKFlags)
+	if err != nil {
+		return nil, err
+	}
+	p, err := GetTarballPackageDetails(ctx, options, state)
+	if err != nil {
+		return nil, err
+	}
+	src, err := state.Directory(ctx, arguments.GrafanaDirectory)
+	if err != nil {
+		return nil, err
+	}
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
+	yarnCache, err := state.CacheVolume(ctx, arguments.YarnCacheDirectory)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := verifyMode(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := GetPackageMetadata(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := overlayDirectory(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	sign, err := options.Bool(flags.Sign)
+	if err != nil {
+		return nil, err
+	}
+	signOpts, err := apkSignOpts(ctx, state, sign)
+	if err != nil {
+		return nil, err
+	}
+
+	name := string(p.Name)
+	if nightly, _ := options.Bool(flags.Nightly); nightly {
+		name += "-nightly"
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Handler: &APK{
+			Name:         p.Name,
+			Version:      p.Version,
+			BuildID:      p.BuildID,
+			Distribution: p.Distribution,
+			Enterprise:   p.Enterprise,
+			Tarball:      tarball,
+			Src:          src,
+			Node:         node,
+			YarnCache:    yarnCache,
+			NameOverride: name,
+			Sign:         signOpts,
+			VerifyMode:   mode,
+			Metadata:     metadata,
+			Overlay:      overlay,
+		},
+		Type:  pipeline.ArtifactTypeFile,
+		Flags: TargzFlags,
+	})
+}
+
+// apkSignOpts returns the RSA keys that sign apks. The keys are only read from the state if 'sign' is true.
+func apkSignOpts(ctx context.Context, state pipeline.StateHandler, sign bool) (fpm.APKSignOpts, error) {
+	name, err := state.String(ctx, arguments.APKKeyName)
+	if err != nil {
+		return fpm.APKSignOpts{}, err
+	}
+	if !sign {
+		return fpm.APKSignOpts{KeyName: name}, nil
+	}
+
+	pubb64, err := state.String(ctx, arguments.APKPublicKey)
+	if err != nil {
+		return fpm.APKSignOpts{}, err
+	}
+	pub, err := base64.StdEncoding.DecodeString(pubb64)
+	if err != nil {
+		return fpm.APKSignOpts{}, fmt.Errorf("apk-public-key-base64 cannot be decoded %w", err)
+	}
+
+	privb64, err := state.String(ctx, arguments.APKPrivateKey)
+	if err != nil {
+		return fpm.APKSignOpts{}, err
+	}
+	priv, err := base64.StdEncoding.DecodeString(privb64)
+	if err != nil {
+		return fpm.APKSignOpts{}, fmt.Errorf("apk-private-key-base64 cannot be decoded %w", err)
+	}
+
+	return fpm.APKSignOpts{
+		Sign:       true,
+		PrivateKey: string(priv),
+		PublicKey:  string(pub),
+		KeyName:    name,
+	}, nil
+}
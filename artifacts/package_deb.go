@@ -2,23 +2,40 @@ package artifacts
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"path/filepath"
 	"strings"
 
 	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/e2e"
 	"github.com/grafana/grafana-build/flags"
 	"github.com/grafana/grafana-build/fpm"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/gpg"
+	"github.com/grafana/grafana-build/lint"
 	"github.com/grafana/grafana-build/packages"
 	"github.com/grafana/grafana-build/pipeline"
 )
 
 var (
-	DebArguments = TargzArguments
-	DebFlags     = flags.JoinFlags(
+	DebArguments = arguments.Join(
+		TargzArguments,
+		[]pipeline.Argument{
+			arguments.PackageBuilder,
+			arguments.PackageLint,
+			arguments.PackageLintPolicy,
+			arguments.DebTestImages,
+			arguments.DebUpgradeFrom,
+		},
+		PackageMetadataArguments,
+	)
+	DebFlags = flags.JoinFlags(
 		TargzFlags,
 		[]pipeline.Flag{
+			flags.SignFlag,
 			flags.NightlyFlag,
 		},
 	)
@@ -26,7 +43,10 @@ var (
 
 var DebInitializer = Initializer{
 	InitializerFunc: NewDebFromString,
-	Arguments:       TargzArguments,
+	Arguments: arguments.Join(
+		DebArguments,
+		GPGArguments,
+	),
 }
 
 // PacakgeDeb uses a built tar.gz package to create a .deb installer for debian based Linux distributions.
@@ -37,12 +57,31 @@ type Deb struct {
 	Distribution backend.Distribution
 	Enterprise   bool
 	NameOverride string
+	// Sign adds an origin signature to the deb with debsigs.
+	Sign bool
+	GPG  gpg.GPGOpts
+	// PackageBuilder is either fpm or the native deb writer.
+	PackageBuilder fpm.PackageBuilder
+	// LintPolicy is set if the package is also linted when it is verified.
+	LintPolicy lint.Policy
+	// TestImages are the images that the package is installed on when it is verified.
+	TestImages []string
+	// UpgradeFrom is a previous package that the upgrade tests start from. It is nil if there are no upgrade tests.
+	UpgradeFrom *dagger.File
+	// VerifyMode is how the package is verified with '--verify'.
+	VerifyMode e2e.Mode
+	// Metadata overrides the vendor, maintainer, homepage, description, and license of the package and adds dependencies.
+	Metadata packages.Metadata
+	// Overlay is the directory from '--overlay', or nil. Its provisioning files are also installed as config files.
+	Overlay *dagger.Directory
 
 	Tarball *pipeline.Artifact
 
 	// Src is the source tree of Grafana. This should only be used in the verify function.
 	Src       *dagger.Directory
 	YarnCache *dagger.CacheVolume
+	// Node is the node setup that the e2e tests run with. This should only be used in the verify function.
+	Node *frontend.Node
 }
 
 func (d *Deb) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
@@ -52,6 +91,9 @@ func (d *Deb) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
 }
 
 func (d *Deb) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	if d.PackageBuilder == fpm.PackageBuilderNative {
+		return opts.Client.Container(), nil
+	}
 	return fpm.Builder(opts.Client), nil
 }
 
@@ -66,7 +108,7 @@ func (d *Deb) BuildFile(ctx context.Context, builder *dagger.Container, opts *pi
 		return nil, err
 	}
 
-	return fpm.Build(builder, fpm.BuildOpts{
+	buildOpts := fpm.BuildOpts{
 		Name:         d.Name,
 		Enterprise:   d.Enterprise,
 		Version:      debVersion(d.Version),
@@ -89,7 +131,29 @@ func (d *Deb) BuildFile(ctx context.Context, builder *dagger.Container, opts *pi
 		ExtraArgs: []string{
 			"--deb-no-default-config-files",
 		},
-	}, targz), nil
+		Metadata: d.Metadata,
+	}
+
+	overlayConfig, err := overlayConfigFiles(ctx, d.Overlay)
+	if err != nil {
+		return nil, err
+	}
+	buildOpts.ConfigFiles = append(buildOpts.ConfigFiles, overlayConfig...)
+
+	var deb *dagger.File
+	if d.PackageBuilder == fpm.PackageBuilderNative {
+		deb, err = fpm.BuildNative(ctx, opts.Client, buildOpts, targz)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		deb = fpm.Build(builder, buildOpts, targz)
+	}
+
+	if !d.Sign {
+		return deb, nil
+	}
+	return gpg.SignDeb(opts.Client, deb, d.GPG), nil
 }
 
 func (d *Deb) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
@@ -119,11 +183,43 @@ func (d *Deb) Filename(ctx context.Context) (string, error) {
 		name = packages.Name(d.NameOverride)
 	}
 
-	return packages.FileName(name, d.Version, d.BuildID, d.Distribution, "deb")
+	signed := ""
+	if d.Sign {
+		signed = "signed"
+	}
+
+	return packages.FileName(name, d.Version, joinIDs(d.BuildID, d.PackageBuilder.ID(), signed), d.Distribution, "deb")
 }
 
 func (d *Deb) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
-	return fpm.VerifyDeb(ctx, client, file, d.Src, d.YarnCache, d.Distribution, d.Enterprise)
+	if d.LintPolicy != "" {
+		name, err := d.Filename(ctx)
+		if err != nil {
+			return err
+		}
+		if err := lintPackageFile(ctx, client, "deb", file, filepath.Base(name), d.LintPolicy); err != nil {
+			return err
+		}
+	}
+	if d.Sign {
+		if err := gpg.VerifyDebSignature(ctx, client, file, d.GPG.GPGPublicKey); err != nil {
+			return err
+		}
+	}
+	if len(d.TestImages) == 0 {
+		return errors.New("no images to install the deb on; check '--deb-test-images'")
+	}
+
+	if err := fpm.VerifyInstall(ctx, client, file, fpm.InstallOpts{
+		PackageType:  fpm.PackageTypeDeb,
+		Distribution: d.Distribution,
+		Images:       d.TestImages,
+		UpgradeFrom:  d.UpgradeFrom,
+	}); err != nil {
+		return err
+	}
+
+	return fpm.VerifyDeb(ctx, client, file, d.Distribution, d.Enterprise, d.TestImages[0], validateOpts(d.VerifyMode, d.Src, d.YarnCache, d.Node, d.Version, d.Enterprise))
 }
 
 func (d *Deb) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
@@ -139,7 +235,7 @@ func NewDebFromString(ctx context.Context, log *slog.Logger, artifact string, st
 	if err != nil {
 		return nil, err
 	}
-	p, err := GetPackageDetails(ctx, options, state)
+	p, err := GetTarballPackageDetails(ctx, options, state)
 	if err != nil {
 		return nil, err
 	}
@@ -147,10 +243,53 @@ func NewDebFromString(ctx context.Context, log *slog.Logger, artifact string, st
 	if err != nil {
 		return nil, err
 	}
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
 	yarnCache, err := state.CacheVolume(ctx, arguments.YarnCacheDirectory)
 	if err != nil {
 		return nil, err
 	}
+	packageBuilder, err := packageBuilder(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	lintPolicy, err := packageLintPolicy(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	testImages, err := state.String(ctx, arguments.DebTestImages)
+	if err != nil {
+		return nil, err
+	}
+	upgradeFrom, err := optionalFile(ctx, state, arguments.DebUpgradeFrom)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := verifyMode(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := GetPackageMetadata(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := overlayDirectory(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	sign, err := options.Bool(flags.Sign)
+	if err != nil {
+		return nil, err
+	}
+	var gpgOpts gpg.GPGOpts
+	if sign {
+		gpgOpts, err = GPGOpts(ctx, state)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	debname := string(p.Name)
 	if nightly, _ := options.Bool(flags.Nightly); nightly {
@@ -163,17 +302,49 @@ func NewDebFromString(ctx context.Context, log *slog.Logger, artifact string, st
 	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
 		ArtifactString: artifact,
 		Handler: &Deb{
-			Name:         p.Name,
-			Version:      p.Version,
-			BuildID:      p.BuildID,
-			Distribution: p.Distribution,
-			Enterprise:   p.Enterprise,
-			Tarball:      tarball,
-			Src:          src,
-			YarnCache:    yarnCache,
-			NameOverride: debname,
+			Name:           p.Name,
+			Version:        p.Version,
+			BuildID:        p.BuildID,
+			Distribution:   p.Distribution,
+			Enterprise:     p.Enterprise,
+			Tarball:        tarball,
+			Src:            src,
+			Node:           node,
+			YarnCache:      yarnCache,
+			NameOverride:   debname,
+			PackageBuilder: packageBuilder,
+			LintPolicy:     lintPolicy,
+			TestImages:     fpm.ParseImages(testImages, fpm.DebImageMatrix),
+			UpgradeFrom:    upgradeFrom,
+			VerifyMode:     mode,
+			Metadata:       metadata,
+			Overlay:        overlay,
+			Sign:           sign,
+			GPG:            gpgOpts,
 		},
 		Type:  pipeline.ArtifactTypeFile,
 		Flags: TargzFlags,
 	})
 }
+
+// optionalFile returns the file argument 'arg' from the state, or nil if its flag was not set.
+func optionalFile(ctx context.Context, state pipeline.StateHandler, arg pipeline.Argument) (*dagger.File, error) {
+	f, err := state.File(ctx, arg)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func packageBuilder(ctx context.Context, state pipeline.StateHandler) (fpm.PackageBuilder, error) {
+	v, err := state.String(ctx, arguments.PackageBuilder)
+	if err != nil {
+		return "", err
+	}
+
+	return fpm.ParsePackageBuilder(v)
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/backend"
 	"github.com/grafana/grafana-build/docker"
+	"github.com/grafana/grafana-build/e2e"
 	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/frontend"
 	"github.com/grafana/grafana-build/packages"
 	"github.com/grafana/grafana-build/pipeline"
 )
@@ -27,6 +29,7 @@ var (
 			arguments.UbuntuTagFormat,
 			arguments.BoringTagFormat,
 		},
+		PackageMetadataArguments,
 	)
 	DockerFlags = flags.JoinFlags(
 		TargzFlags,
@@ -61,6 +64,12 @@ type Docker struct {
 	// from the tar.gz file.
 	Src       *dagger.Directory
 	YarnCache *dagger.CacheVolume
+	// Node is the node setup that the e2e tests run with. This should only be used in the verify function.
+	Node *frontend.Node
+	// VerifyMode is how the image is verified with '--verify'.
+	VerifyMode e2e.Mode
+	// Metadata is added to the image as OCI labels.
+	Metadata packages.Metadata
 }
 
 func (d *Docker) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
@@ -98,6 +107,7 @@ func (d *Docker) BuildFile(ctx context.Context, builder *dagger.Container, opts
 		// You might want to also include a 'latest' version of the tag.
 		Tags:     tags,
 		Platform: backend.Platform(d.Distro),
+		Labels:   docker.Labels(d.Metadata, d.Version, d.Enterprise),
 		BuildArgs: []string{
 			"GRAFANA_TGZ=grafana.tar.gz",
 			"GO_SRC=tgz-builder",
@@ -152,7 +162,7 @@ func (d *Docker) VerifyFile(ctx context.Context, client *dagger.Client, file *da
 		return nil
 	}
 
-	return docker.Verify(ctx, client, file, d.Src, d.YarnCache, d.Distro)
+	return docker.Verify(ctx, client, file, d.Distro, validateOpts(d.VerifyMode, d.Src, d.YarnCache, d.Node, d.Version, d.Enterprise))
 }
 
 func (d *Docker) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
@@ -165,7 +175,7 @@ func NewDockerFromString(ctx context.Context, log *slog.Logger, artifact string,
 		return nil, err
 	}
 
-	p, err := GetPackageDetails(ctx, options, state)
+	p, err := GetTarballPackageDetails(ctx, options, state)
 	if err != nil {
 		return nil, err
 	}
@@ -235,12 +245,25 @@ func NewDockerFromString(ctx context.Context, log *slog.Logger, artifact string,
 	if err != nil {
 		return nil, err
 	}
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
 
 	yarnCache, err := state.CacheVolume(ctx, arguments.YarnCacheDirectory)
 	if err != nil {
 		return nil, err
 	}
 
+	mode, err := verifyMode(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := GetPackageMetadata(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Info("initializing Docker artifact", "Org", org, "registry", registry, "repos", repos, "tag", format)
 
 	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
@@ -260,8 +283,11 @@ func NewDockerFromString(ctx context.Context, log *slog.Logger, artifact string,
 			Repositories: repos,
 			TagFormat:    format,
 
-			Src:       src,
-			YarnCache: yarnCache,
+			Src:        src,
+			Node:       node,
+			YarnCache:  yarnCache,
+			VerifyMode: mode,
+			Metadata:   metadata,
 		},
 		Type:  pipeline.ArtifactTypeFile,
 		Flags: DockerFlags,
@@ -147,7 +147,7 @@ func NewEntDockerFromString(ctx context.Context, log *slog.Logger, artifact stri
 		return nil, err
 	}
 
-	p, err := GetPackageDetails(ctx, options, state)
+	p, err := GetTarballPackageDetails(ctx, options, state)
 	if err != nil {
 		return nil, err
 	}
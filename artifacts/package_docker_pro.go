@@ -148,7 +148,7 @@ func NewProDockerFromString(ctx context.Context, log *slog.Logger, artifact stri
 		return nil, err
 	}
 
-	p, err := GetPackageDetails(ctx, options, state)
+	p, err := GetTarballPackageDetails(ctx, options, state)
 	if err != nil {
 		return nil, err
 	}
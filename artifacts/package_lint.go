@@ -0,0 +1,207 @@
+package artifacts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/lint"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	PackageLintArguments = RPMInitializer.Arguments
+	PackageLintFlags     = flags.JoinFlags(
+		RPMFlags,
+		flags.PackageLintFlags,
+	)
+)
+
+var PackageLintInitializer = Initializer{
+	InitializerFunc: NewPackageLintFromString,
+	Arguments:       PackageLintArguments,
+}
+
+// PackageLint lints a built deb or rpm package with lintian or rpmlint and produces a JSON report of the findings.
+type PackageLint struct {
+	// Type is 'deb' or 'rpm'.
+	Type   string
+	Policy lint.Policy
+
+	Package *pipeline.Artifact
+}
+
+func (p *PackageLint) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return []*pipeline.Artifact{
+		p.Package,
+	}, nil
+}
+
+func lintContainer(d *dagger.Client, typ string) *dagger.Container {
+	if typ == "rpm" {
+		return lint.RPMLintContainer(d)
+	}
+	return lint.LintianContainer(d)
+}
+
+func lintPackage(ctx context.Context, d *dagger.Client, c *dagger.Container, typ string, file *dagger.File, name string) (*lint.Report, error) {
+	if typ == "rpm" {
+		return lint.RPM(ctx, d, c, file, name)
+	}
+	return lint.Deb(ctx, d, c, file, name)
+}
+
+func (p *PackageLint) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	return lintContainer(opts.Client, p.Type), nil
+}
+
+func (p *PackageLint) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	file, err := opts.Store.File(ctx, p.Package)
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.Package.Handler.Filename(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := lintPackage(ctx, opts.Client, builder, p.Type, file, filepath.Base(name))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range report.Findings {
+		opts.Log.Warn("package lint finding", "linter", report.Linter, "level", f.Level, "tag", f.Tag, "package", f.Package, "info", f.Info)
+	}
+
+	b, err := report.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Client.Directory().WithNewFile("lint.json", string(b)).File("lint.json"), nil
+}
+
+func (p *PackageLint) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	panic("This artifact does not produce directories")
+}
+
+func (p *PackageLint) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (p *PackageLint) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (p *PackageLint) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("This artifact does not produce directories")
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (p *PackageLint) Filename(ctx context.Context) (string, error) {
+	name, err := p.Package.Handler.Filename(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return name + ".lint.json", nil
+}
+
+// VerifyFile applies the policy to the report, so that '--verify' fails with the 'fail' policy if the linter reported errors.
+func (p *PackageLint) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	contents, err := file.Contents(ctx)
+	if err != nil {
+		return err
+	}
+
+	report := &lint.Report{}
+	if err := json.Unmarshal([]byte(contents), report); err != nil {
+		return fmt.Errorf("error parsing package lint report: %w", err)
+	}
+
+	return lint.Check(report, p.Policy)
+}
+
+func (p *PackageLint) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	panic("This artifact does not produce directories")
+}
+
+// lintPackageFile lints the built deb or rpm 'file' and applies 'policy' to the findings. It is used by the deb and rpm artifacts when
+// '--package-lint' and '--verify' are set.
+func lintPackageFile(ctx context.Context, d *dagger.Client, typ string, file *dagger.File, name string, policy lint.Policy) error {
+	report, err := lintPackage(ctx, d, lintContainer(d, typ), typ, file, name)
+	if err != nil {
+		return err
+	}
+
+	return lint.Check(report, policy)
+}
+
+// packageLintPolicy returns the lint policy if '--package-lint' is set, or an empty policy if the package should not be linted when it is
+// verified.
+func packageLintPolicy(ctx context.Context, state pipeline.StateHandler) (lint.Policy, error) {
+	enabled, err := state.Bool(ctx, arguments.PackageLint)
+	if err != nil {
+		return "", err
+	}
+	if !enabled {
+		return "", nil
+	}
+
+	v, err := state.String(ctx, arguments.PackageLintPolicy)
+	if err != nil {
+		return "", err
+	}
+
+	return lint.ParsePolicy(v)
+}
+
+func NewPackageLintFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	options, err := pipeline.ParseFlags(artifact, PackageLintFlags)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := options.String(flags.PackageLintType)
+	if err != nil {
+		return nil, fmt.Errorf("the 'package-lint' artifact requires a package type, like 'package-lint:deb' or 'package-lint:rpm': %w", err)
+	}
+	v, err := state.String(ctx, arguments.PackageLintPolicy)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := lint.ParsePolicy(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg *pipeline.Artifact
+	if typ == "rpm" {
+		pkg, err = NewRPMFromString(ctx, log, artifact, state)
+	} else {
+		pkg, err = NewDebFromString(ctx, log, artifact, state)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeFile,
+		Flags:          PackageLintFlags,
+		Handler: &PackageLint{
+			Type:    typ,
+			Policy:  policy,
+			Package: pkg,
+		},
+	})
+}
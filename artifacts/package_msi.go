@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/backend"
 	"github.com/grafana/grafana-build/msi"
 	"github.com/grafana/grafana-build/packages"
@@ -13,13 +14,16 @@ import (
 )
 
 var (
-	MSIArguments = TargzArguments
-	MSIFlags     = TargzFlags
+	MSIArguments = arguments.Join(
+		TargzArguments,
+		PackageMetadataArguments,
+	)
+	MSIFlags = TargzFlags
 )
 
 var MSIInitializer = Initializer{
 	InitializerFunc: NewMSIFromString,
-	Arguments:       TargzArguments,
+	Arguments:       MSIArguments,
 }
 
 // PacakgeMSI uses a built tar.gz package to create a .exe installer for exeian based Linux distributions.
@@ -29,6 +33,8 @@ type MSI struct {
 	BuildID      string
 	Distribution backend.Distribution
 	Enterprise   bool
+	// Metadata overrides the manufacturer, links, and upgrade codes of the installer.
+	Metadata packages.Metadata
 
 	Tarball *pipeline.Artifact
 }
@@ -49,7 +55,7 @@ func (d *MSI) BuildFile(ctx context.Context, builder *dagger.Container, opts *pi
 		return nil, err
 	}
 
-	return msi.Build(opts.Client, builder, targz, d.Version, d.Enterprise)
+	return msi.Build(opts.Client, builder, targz, d.Version, d.Enterprise, d.Metadata)
 }
 
 func (d *MSI) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
@@ -96,7 +102,12 @@ func NewMSIFromString(ctx context.Context, log *slog.Logger, artifact string, st
 	if err != nil {
 		return nil, err
 	}
-	p, err := GetPackageDetails(ctx, options, state)
+	p, err := GetTarballPackageDetails(ctx, options, state)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := GetPackageMetadata(ctx, state)
 	if err != nil {
 		return nil, err
 	}
@@ -113,6 +124,7 @@ func NewMSIFromString(ctx context.Context, log *slog.Logger, artifact string, st
 			BuildID:      p.BuildID,
 			Distribution: p.Distribution,
 			Enterprise:   p.Enterprise,
+			Metadata:     metadata,
 			Tarball:      targz,
 		},
 		Type:  pipeline.ArtifactTypeFile,
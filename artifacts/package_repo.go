@@ -0,0 +1,59 @@
+package artifacts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+func flagNames(f []pipeline.Flag) map[string]bool {
+	names := make(map[string]bool, len(f))
+	for _, v := range f {
+		names[v.Name] = true
+	}
+	return names
+}
+
+// PackageArtifactStrings returns an artifact string of type 'typ' (like 'deb') for every package name and distribution in a repository
+// artifact string like 'apt-repo:grafana:enterprise:linux/amd64:linux/arm64'. Every other flag except for 'exclude' is added to each
+// artifact string.
+func PackageArtifactStrings(artifact, typ string, exclude ...string) ([]string, error) {
+	var (
+		distros      = flagNames(flags.DistroFlags())
+		names        = flagNames(flags.PackageNameFlags)
+		excluded     = map[string]bool{}
+		nameFlags    = []string{}
+		distroFlags  = []string{}
+		otherFlags   = []string{}
+		components   = strings.Split(artifact, ":")
+		repoArtifact = components[0]
+	)
+	for _, v := range exclude {
+		excluded[v] = true
+	}
+
+	for _, v := range components[1:] {
+		switch {
+		case names[v]:
+			nameFlags = append(nameFlags, v)
+		case distros[v]:
+			distroFlags = append(distroFlags, v)
+		case !excluded[v]:
+			otherFlags = append(otherFlags, v)
+		}
+	}
+	if len(nameFlags) == 0 || len(distroFlags) == 0 {
+		return nil, fmt.Errorf("the '%s' artifact requires at least one package name and distribution, like '%s:grafana:linux/amd64:linux/arm64'", repoArtifact, repoArtifact)
+	}
+
+	s := []string{}
+	for _, name := range nameFlags {
+		for _, distro := range distroFlags {
+			s = append(s, strings.Join(append([]string{typ, name, distro}, otherFlags...), ":"))
+		}
+	}
+
+	return s, nil
+}
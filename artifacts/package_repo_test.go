@@ -0,0 +1,43 @@
+package artifacts_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grafana/grafana-build/artifacts"
+)
+
+func TestPackageArtifactStrings(t *testing.T) {
+	t.Run("It should return an artifact string for every name and distribution", func(t *testing.T) {
+		s, err := artifacts.PackageArtifactStrings("apt-repo:grafana:enterprise:linux/amd64:linux/arm64:nightly:sign", "deb", "sign")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{
+			"deb:grafana:linux/amd64:nightly",
+			"deb:grafana:linux/arm64:nightly",
+			"deb:enterprise:linux/amd64:nightly",
+			"deb:enterprise:linux/arm64:nightly",
+		}
+		if !reflect.DeepEqual(s, expected) {
+			t.Fatalf("expected %v, got %v", expected, s)
+		}
+	})
+	t.Run("It should keep flags that are not excluded", func(t *testing.T) {
+		s, err := artifacts.PackageArtifactStrings("yum-repo:grafana:linux/amd64:sign", "rpm")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"rpm:grafana:linux/amd64:sign"}
+		if !reflect.DeepEqual(s, expected) {
+			t.Fatalf("expected %v, got %v", expected, s)
+		}
+	})
+	t.Run("It should require a distribution", func(t *testing.T) {
+		if _, err := artifacts.PackageArtifactStrings("apt-repo:grafana", "deb"); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -2,24 +2,36 @@ package artifacts
 
 import (
 	"context"
-	"encoding/base64"
-	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 
 	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/e2e"
 	"github.com/grafana/grafana-build/flags"
 	"github.com/grafana/grafana-build/fpm"
+	"github.com/grafana/grafana-build/frontend"
 	"github.com/grafana/grafana-build/gpg"
+	"github.com/grafana/grafana-build/lint"
 	"github.com/grafana/grafana-build/packages"
 	"github.com/grafana/grafana-build/pipeline"
 )
 
 var (
-	RPMArguments = TargzArguments
-	RPMFlags     = flags.JoinFlags(
+	RPMArguments = arguments.Join(
+		TargzArguments,
+		[]pipeline.Argument{
+			arguments.PackageBuilder,
+			arguments.PackageLint,
+			arguments.PackageLintPolicy,
+			arguments.RPMTestImages,
+			arguments.RPMUpgradeFrom,
+		},
+		PackageMetadataArguments,
+	)
+	RPMFlags = flags.JoinFlags(
 		TargzFlags,
 		[]pipeline.Flag{
 			flags.SignFlag,
@@ -31,12 +43,8 @@ var (
 var RPMInitializer = Initializer{
 	InitializerFunc: NewRPMFromString,
 	Arguments: arguments.Join(
-		TargzArguments,
-		[]pipeline.Argument{
-			arguments.GPGPublicKey,
-			arguments.GPGPrivateKey,
-			arguments.GPGPassphrase,
-		},
+		RPMArguments,
+		GPGArguments,
 	),
 }
 
@@ -49,6 +57,20 @@ type RPM struct {
 	Enterprise   bool
 	Sign         bool
 	NameOverride string
+	// PackageBuilder is either fpm or the native rpm writer.
+	PackageBuilder fpm.PackageBuilder
+	// LintPolicy is set if the package is also linted when it is verified.
+	LintPolicy lint.Policy
+	// TestImages are the images that the package is installed on when it is verified.
+	TestImages []string
+	// UpgradeFrom is a previous package that the upgrade tests start from. It is nil if there are no upgrade tests.
+	UpgradeFrom *dagger.File
+	// VerifyMode is how the package is verified with '--verify'.
+	VerifyMode e2e.Mode
+	// Metadata overrides the vendor, maintainer, homepage, description, and license of the package and adds dependencies.
+	Metadata packages.Metadata
+	// Overlay is the directory from '--overlay', or nil. Its provisioning files are also installed as config files.
+	Overlay *dagger.Directory
 
 	GPGPublicKey  string
 	GPGPrivateKey string
@@ -56,6 +78,8 @@ type RPM struct {
 
 	Src       *dagger.Directory
 	YarnCache *dagger.CacheVolume
+	// Node is the node setup that the e2e tests run with. This should only be used in the verify function.
+	Node *frontend.Node
 
 	Tarball *pipeline.Artifact
 }
@@ -67,6 +91,9 @@ func (d *RPM) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
 }
 
 func (d *RPM) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	if d.PackageBuilder == fpm.PackageBuilderNative {
+		return opts.Client.Container(), nil
+	}
 	return fpm.Builder(opts.Client), nil
 }
 
@@ -82,7 +109,7 @@ func (d *RPM) BuildFile(ctx context.Context, builder *dagger.Container, opts *pi
 		return nil, err
 	}
 
-	rpm := fpm.Build(builder, fpm.BuildOpts{
+	buildOpts := fpm.BuildOpts{
 		Name:         d.Name,
 		Enterprise:   d.Enterprise,
 		Version:      rpmVersion(d.Version),
@@ -103,7 +130,24 @@ func (d *RPM) BuildFile(ctx context.Context, builder *dagger.Container, opts *pi
 			"--rpm-digest=sha256",
 		},
 		EnvFolder: "/pkg/etc/sysconfig",
-	}, targz)
+		Metadata:  d.Metadata,
+	}
+
+	overlayConfig, err := overlayConfigFiles(ctx, d.Overlay)
+	if err != nil {
+		return nil, err
+	}
+	buildOpts.ConfigFiles = append(buildOpts.ConfigFiles, overlayConfig...)
+
+	var rpm *dagger.File
+	if d.PackageBuilder == fpm.PackageBuilderNative {
+		rpm, err = fpm.BuildNative(ctx, opts.Client, buildOpts, targz)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rpm = fpm.Build(builder, buildOpts, targz)
+	}
 
 	if !d.Sign {
 		return rpm, nil
@@ -142,12 +186,35 @@ func (d *RPM) Filename(ctx context.Context) (string, error) {
 		name = packages.Name(d.NameOverride)
 	}
 
-	return packages.FileName(name, d.Version, d.BuildID, d.Distribution, "rpm")
+	return packages.FileName(name, d.Version, joinIDs(d.BuildID, d.PackageBuilder.ID()), d.Distribution, "rpm")
 }
 
 func (d *RPM) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
-	return nil
-	// return fpm.VerifyRpm(ctx, client, file, d.Src, d.YarnCache, d.Distribution, d.Enterprise, d.Sign, d.GPGPublicKey, d.GPGPrivateKey, d.GPGPassphrase)
+	if d.LintPolicy != "" {
+		name, err := d.Filename(ctx)
+		if err != nil {
+			return err
+		}
+		if err := lintPackageFile(ctx, client, "rpm", file, filepath.Base(name), d.LintPolicy); err != nil {
+			return err
+		}
+	}
+
+	if err := fpm.VerifyInstall(ctx, client, file, fpm.InstallOpts{
+		PackageType:  fpm.PackageTypeRPM,
+		Distribution: d.Distribution,
+		Images:       d.TestImages,
+		UpgradeFrom:  d.UpgradeFrom,
+	}); err != nil {
+		return err
+	}
+
+	// The e2e tests don't run against rpms yet, but the smoke test does.
+	if d.VerifyMode != e2e.ModeSmoke || len(d.TestImages) == 0 {
+		return nil
+	}
+
+	return fpm.VerifyRpm(ctx, client, file, d.Distribution, d.Enterprise, d.Sign, d.GPGPublicKey, d.GPGPrivateKey, d.GPGPassphrase, d.TestImages[0], validateOpts(d.VerifyMode, d.Src, d.YarnCache, d.Node, d.Version, d.Enterprise))
 }
 
 func (d *RPM) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
@@ -163,7 +230,7 @@ func NewRPMFromString(ctx context.Context, log *slog.Logger, artifact string, st
 	if err != nil {
 		return nil, err
 	}
-	p, err := GetPackageDetails(ctx, options, state)
+	p, err := GetTarballPackageDetails(ctx, options, state)
 	if err != nil {
 		return nil, err
 	}
@@ -175,40 +242,49 @@ func NewRPMFromString(ctx context.Context, log *slog.Logger, artifact string, st
 	if err != nil {
 		return nil, err
 	}
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
 	yarnCache, err := state.CacheVolume(ctx, arguments.YarnCacheDirectory)
 	if err != nil {
 		return nil, err
 	}
+	packageBuilder, err := packageBuilder(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	lintPolicy, err := packageLintPolicy(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	testImages, err := state.String(ctx, arguments.RPMTestImages)
+	if err != nil {
+		return nil, err
+	}
+	upgradeFrom, err := optionalFile(ctx, state, arguments.RPMUpgradeFrom)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := verifyMode(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := GetPackageMetadata(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := overlayDirectory(ctx, state)
+	if err != nil {
+		return nil, err
+	}
 
-	var gpgPublicKey, gpgPrivateKey, gpgPassphrase string
-
+	var gpgOpts gpg.GPGOpts
 	if sign {
-		pubb64, err := state.String(ctx, arguments.GPGPublicKey)
-		if err != nil {
-			return nil, err
-		}
-		pub, err := base64.StdEncoding.DecodeString(pubb64)
-		if err != nil {
-			return nil, fmt.Errorf("gpg-private-key-base64 cannot be decoded %w", err)
-		}
-
-		privb64, err := state.String(ctx, arguments.GPGPrivateKey)
+		gpgOpts, err = GPGOpts(ctx, state)
 		if err != nil {
 			return nil, err
 		}
-		priv, err := base64.StdEncoding.DecodeString(privb64)
-		if err != nil {
-			return nil, fmt.Errorf("gpg-private-key-base64 cannot be decoded %w", err)
-		}
-
-		pass, err := state.String(ctx, arguments.GPGPassphrase)
-		if err != nil {
-			return nil, err
-		}
-
-		gpgPublicKey = string(pub)
-		gpgPrivateKey = string(priv)
-		gpgPassphrase = pass
 	}
 
 	rpmname := string(p.Name)
@@ -230,11 +306,20 @@ func NewRPMFromString(ctx context.Context, log *slog.Logger, artifact string, st
 			Tarball:       tarball,
 			Sign:          sign,
 			Src:           src,
+			Node:          node,
 			YarnCache:     yarnCache,
-			GPGPublicKey:  gpgPublicKey,
-			GPGPrivateKey: gpgPrivateKey,
-			GPGPassphrase: gpgPassphrase,
+			GPGPublicKey:  gpgOpts.GPGPublicKey,
+			GPGPrivateKey: gpgOpts.GPGPrivateKey,
+			GPGPassphrase: gpgOpts.GPGPassphrase,
 			NameOverride:  rpmname,
+
+			PackageBuilder: packageBuilder,
+			LintPolicy:     lintPolicy,
+			TestImages:     fpm.ParseImages(testImages, fpm.RPMImageMatrix),
+			UpgradeFrom:    upgradeFrom,
+			VerifyMode:     mode,
+			Metadata:       metadata,
+			Overlay:        overlay,
 		},
 		Type:  pipeline.ArtifactTypeFile,
 		Flags: TargzFlags,
@@ -2,6 +2,7 @@ package artifacts
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -18,21 +19,39 @@ import (
 )
 
 var (
-	TargzArguments = []pipeline.Argument{
-		// Tarballs need the Build ID and version for naming the package properly.
-		arguments.BuildID,
-		arguments.Version,
-
-		// The grafanadirectory has contents like the LICENSE.txt and such that need to be included in the package
-		arguments.GrafanaDirectory,
-
-		// The go version used to build the backend
-		arguments.GoVersion,
-		arguments.ViceroyVersion,
-		arguments.YarnCacheDirectory,
-	}
+	TargzArguments = arguments.Join(
+		[]pipeline.Argument{
+			// Tarballs need the Build ID and version for naming the package properly.
+			arguments.BuildID,
+			arguments.Version,
+
+			// The grafanadirectory has contents like the LICENSE.txt and such that need to be included in the package
+			arguments.GrafanaDirectory,
+
+			// The go version used to build the backend
+			arguments.GoVersion,
+			arguments.ViceroyVersion,
+			arguments.YarnCacheDirectory,
+
+			// Options for the frontend build variant that is embedded in the package
+			arguments.FrontendBuildScript,
+			arguments.FrontendEnv,
+			arguments.FrontendSourcemaps,
+		},
+		// The node version and image that the frontend, the bundled plugins, the npm packages, and the storybook are built with
+		arguments.NodeArguments,
+		[]pipeline.Argument{
+			// Additional plugins that are added to the plugins in 'plugins-bundled'
+			arguments.BundlePlugins,
+
+			// A directory that is copied over the tarball, and the digest of it that is added to the build ID
+			arguments.Overlay,
+			arguments.OverlayDigest,
+		},
+	)
 	TargzFlags = flags.JoinFlags(
 		flags.StdPackageFlags(),
+		flags.FrontendVariantFlags,
 	)
 )
 
@@ -51,6 +70,12 @@ type Tarball struct {
 
 	Grafana   *dagger.Directory
 	YarnCache *dagger.CacheVolume
+	// Node is the node setup that the frontend is built and the e2e tests run with.
+	Node *frontend.Node
+	// VerifyMode is how the tarball is verified with '--verify'.
+	VerifyMode e2e.Mode
+	// Overlay is optional, and is copied over the root of the tarball when it is set.
+	Overlay *dagger.Directory
 
 	// Dependent artifacts
 	Backend        *pipeline.Artifact
@@ -58,6 +83,8 @@ type Tarball struct {
 	NPMPackages    *pipeline.Artifact
 	BundledPlugins *pipeline.Artifact
 	Storybook      *pipeline.Artifact
+	// SBOM is optional, and is only set when the 'with-sbom' flag is used.
+	SBOM *pipeline.Artifact
 }
 
 func NewTarballFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
@@ -96,6 +123,11 @@ func NewTarballFromString(ctx context.Context, log *slog.Logger, artifact string
 		return nil, err
 	}
 
+	withSBOM, err := options.Bool(flags.WithSBOM)
+	if err != nil {
+		return nil, err
+	}
+
 	yarnCache, err := state.CacheVolume(ctx, arguments.YarnCacheDirectory)
 	if err != nil {
 		return nil, err
@@ -111,93 +143,175 @@ func NewTarballFromString(ctx context.Context, log *slog.Logger, artifact string
 		return nil, err
 	}
 
-	p, err := GetPackageDetails(ctx, options, state)
+	p, err := GetTarballPackageDetails(ctx, options, state)
 	if err != nil {
 		return nil, err
 	}
-	log.Info("Initializing tar.gz artifact with options", "name", p.Name, "build ID", p.BuildID, "version", p.Version, "distro", p.Distribution, "static", static, "enterprise", p.Enterprise)
+	log.Info("Initializing tar.gz artifact with options", "name", p.Name, "build ID", p.BuildID, "version", p.Version, "distro", p.Distribution, "static", static, "enterprise", p.Enterprise, "sbom", withSBOM)
 
 	src, err := GrafanaDir(ctx, state, p.Enterprise)
 	if err != nil {
 		return nil, err
 	}
-	return NewTarball(ctx, log, artifact, p.Distribution, p.Enterprise, p.Name, p.Version, p.BuildID, src, yarnCache, goModCache, goBuildCache, static, wireTag, tags, goVersion, viceroyVersion, experiments)
-}
 
-// NewTarball returns a properly initialized Tarball artifact.
-// There are a lot of options that can affect how a tarball is built; most of which define different ways for the backend to be built.
-func NewTarball(
-	ctx context.Context,
-	log *slog.Logger,
-	artifact string,
-	distro backend.Distribution,
-	enterprise bool,
-	name packages.Name,
-	version string,
-	buildID string,
-	src *dagger.Directory,
-	cache *dagger.CacheVolume,
-	goModCache *dagger.CacheVolume,
-	goBuildCache *dagger.CacheVolume,
-	static bool,
-	wireTag string,
-	tags []string,
-	goVersion string,
-	viceroyVersion string,
-	experiments []string,
-) (*pipeline.Artifact, error) {
-	backendArtifact, err := NewBackend(ctx, log, artifact, &NewBackendOpts{
-		Name:           name,
-		Version:        version,
-		Distribution:   distro,
+	frontendOpts, err := FrontendBuildOpts(ctx, options, state)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
+
+	bundlePlugins, err := state.Directory(ctx, arguments.BundlePlugins)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, err
+		}
+		bundlePlugins = nil
+	}
+
+	overlay, err := overlayDirectory(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := verifyMode(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTarball(ctx, log, artifact, &NewTarballOpts{
+		Name:           p.Name,
+		Version:        p.Version,
+		BuildID:        p.BuildID,
+		Distribution:   p.Distribution,
+		Enterprise:     p.Enterprise,
 		Src:            src,
+		YarnCache:      yarnCache,
+		GoModCache:     goModCache,
+		GoBuildCache:   goBuildCache,
 		Static:         static,
 		WireTag:        wireTag,
 		Tags:           tags,
 		GoVersion:      goVersion,
 		ViceroyVersion: viceroyVersion,
 		Experiments:    experiments,
-		Enterprise:     enterprise,
-		GoBuildCache:   goBuildCache,
-		GoModCache:     goModCache,
+		WithSBOM:       withSBOM,
+		FrontendOpts:   frontendOpts,
+		Node:           node,
+		BundlePlugins:  bundlePlugins,
+		Overlay:        overlay,
+		VerifyMode:     mode,
+	})
+}
+
+type NewTarballOpts struct {
+	Name         packages.Name
+	Version      string
+	BuildID      string
+	Distribution backend.Distribution
+	Enterprise   bool
+	Src          *dagger.Directory
+
+	YarnCache    *dagger.CacheVolume
+	GoModCache   *dagger.CacheVolume
+	GoBuildCache *dagger.CacheVolume
+
+	// Static, WireTag, Tags, GoVersion, ViceroyVersion, and Experiments change how the backend is built (see NewBackendOpts).
+	Static         bool
+	WireTag        string
+	Tags           []string
+	GoVersion      string
+	ViceroyVersion string
+	Experiments    []string
+
+	WithSBOM      bool
+	FrontendOpts  *frontend.BuildOpts
+	Node          *frontend.Node
+	BundlePlugins *dagger.Directory
+	Overlay       *dagger.Directory
+	VerifyMode    e2e.Mode
+}
+
+// NewTarball returns a properly initialized Tarball artifact.
+// There are a lot of options that can affect how a tarball is built; most of which define different ways for the backend to be built.
+func NewTarball(ctx context.Context, log *slog.Logger, artifact string, opts *NewTarballOpts) (*pipeline.Artifact, error) {
+	backendArtifact, err := NewBackend(ctx, log, artifact, &NewBackendOpts{
+		Name:           opts.Name,
+		Version:        opts.Version,
+		Distribution:   opts.Distribution,
+		Src:            opts.Src,
+		Static:         opts.Static,
+		WireTag:        opts.WireTag,
+		Tags:           opts.Tags,
+		GoVersion:      opts.GoVersion,
+		ViceroyVersion: opts.ViceroyVersion,
+		Experiments:    opts.Experiments,
+		Enterprise:     opts.Enterprise,
+		GoBuildCache:   opts.GoBuildCache,
+		GoModCache:     opts.GoModCache,
 	})
 	if err != nil {
 		return nil, err
 	}
-	frontendArtifact, err := NewFrontend(ctx, log, version, artifact, enterprise, src, cache)
+	frontendArtifact, err := NewFrontend(ctx, log, artifact, opts.Version, opts.Enterprise, opts.Src, opts.YarnCache, opts.FrontendOpts, opts.Node)
 	if err != nil {
 		return nil, err
 	}
 
-	bundledPluginsArtifact, err := NewBundledPlugins(ctx, log, artifact, src, version, cache)
+	bundledPluginsArtifact, err := NewBundledPlugins(ctx, log, artifact, opts.Src, opts.Version, opts.YarnCache, opts.Node, opts.BundlePlugins)
 	if err != nil {
 		return nil, err
 	}
 
-	npmArtifact, err := NewNPMPackages(ctx, log, artifact, src, version, cache)
+	npmArtifact, err := NewNPMPackages(ctx, log, artifact, opts.Src, opts.Version, opts.YarnCache, opts.Node)
 	if err != nil {
 		return nil, err
 	}
 
-	storybookArtifact, err := NewStorybook(ctx, log, artifact, src, version, cache)
+	// Only the requested artifacts are verified, so the storybook in the tarball doesn't need a minimum number of stories.
+	storybookArtifact, err := NewStorybook(ctx, log, artifact, opts.Src, opts.Version, opts.YarnCache, opts.Node, 0)
 	if err != nil {
 		return nil, err
 	}
+
+	var sbomArtifact *pipeline.Artifact
+	if opts.WithSBOM {
+		sbomArtifact, err = NewSBOM(ctx, log, artifact, &NewSBOMOpts{
+			Name:         opts.Name,
+			Version:      opts.Version,
+			BuildID:      opts.BuildID,
+			Distribution: opts.Distribution,
+			GoVersion:    opts.GoVersion,
+			Src:          opts.Src,
+			Backend:      backendArtifact,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tarball := &Tarball{
-		Name:         name,
-		Distribution: distro,
-		Version:      version,
-		GoVersion:    goVersion,
-		BuildID:      buildID,
-		Grafana:      src,
-		Enterprise:   enterprise,
-		YarnCache:    cache,
+		Name:         opts.Name,
+		Distribution: opts.Distribution,
+		Version:      opts.Version,
+		GoVersion:    opts.GoVersion,
+		BuildID:      opts.BuildID,
+		Grafana:      opts.Src,
+		Enterprise:   opts.Enterprise,
+		YarnCache:    opts.YarnCache,
+		Node:         opts.Node,
+		VerifyMode:   opts.VerifyMode,
+		Overlay:      opts.Overlay,
 
 		Backend:        backendArtifact,
 		Frontend:       frontendArtifact,
 		NPMPackages:    npmArtifact,
 		BundledPlugins: bundledPluginsArtifact,
 		Storybook:      storybookArtifact,
+		SBOM:           sbomArtifact,
 	}
 
 	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
@@ -282,6 +396,14 @@ func (t *Tarball) BuildFile(ctx context.Context, b *dagger.Container, opts *pipe
 		targz.NewMappedDir("plugins-bundled", pluginsDir),
 	}
 
+	if t.SBOM != nil {
+		sbomDir, err := opts.Store.Directory(ctx, t.SBOM)
+		if err != nil {
+			return nil, err
+		}
+		directories = append(directories, targz.NewMappedDir("sbom", sbomDir))
+	}
+
 	root := fmt.Sprintf("grafana-%s", version)
 
 	return targz.Build(
@@ -290,6 +412,7 @@ func (t *Tarball) BuildFile(ctx context.Context, b *dagger.Container, opts *pipe
 			Root:        root,
 			Files:       files,
 			Directories: directories,
+			Overlay:     t.Overlay,
 		},
 	), nil
 }
@@ -318,7 +441,7 @@ func (t *Tarball) VerifyFile(ctx context.Context, client *dagger.Client, file *d
 		return nil
 	}
 
-	return verifyTarball(ctx, client, file, t.Grafana, t.YarnCache, t.Distribution, t.Enterprise)
+	return verifyTarball(ctx, client, file, t.Distribution, t.Enterprise, validateOpts(t.VerifyMode, t.Grafana, t.YarnCache, t.Node, t.Version, t.Enterprise))
 }
 
 func (t *Tarball) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
@@ -326,13 +449,18 @@ func (t *Tarball) VerifyDirectory(ctx context.Context, client *dagger.Client, di
 }
 
 func (t *Tarball) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
-	return []*pipeline.Artifact{
+	deps := []*pipeline.Artifact{
 		t.Backend,
 		t.Frontend,
 		t.NPMPackages,
 		t.BundledPlugins,
 		t.Storybook,
-	}, nil
+	}
+	if t.SBOM != nil {
+		deps = append(deps, t.SBOM)
+	}
+
+	return deps, nil
 }
 
 func (t *Tarball) Filename(ctx context.Context) (string, error) {
@@ -343,16 +471,10 @@ func verifyTarball(
 	ctx context.Context,
 	d *dagger.Client,
 	pkg *dagger.File,
-	src *dagger.Directory,
-	yarnCache *dagger.CacheVolume,
 	distro backend.Distribution,
 	enterprise bool,
+	opts e2e.ValidateOpts,
 ) error {
-	nodeVersion, err := frontend.NodeVersion(d, src).Stdout(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get node version from source code: %w", err)
-	}
-
 	var (
 		platform = backend.Platform(distro)
 		archive  = containers.ExtractedArchive(d, pkg)
@@ -375,8 +497,5 @@ func verifyTarball(
 		WithExec([]string{"./bin/grafana", "server"}).
 		WithExposedPort(3000)
 
-	if _, err := containers.ExitError(ctx, e2e.ValidatePackage(d, service.AsService(), src, yarnCache, nodeVersion)); err != nil {
-		return err
-	}
-	return nil
+	return e2e.Validate(ctx, d, service.AsService(), opts)
 }
@@ -2,10 +2,15 @@ package artifacts
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/backend"
 	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/fpm"
 	"github.com/grafana/grafana-build/packages"
 	"github.com/grafana/grafana-build/pipeline"
 )
@@ -31,6 +36,14 @@ func GetPackageDetails(ctx context.Context, options *pipeline.OptionsHandler, st
 	if err != nil {
 		return PackageDetails{}, err
 	}
+	// Packages with an overlay have different contents than packages from the same build without one, so they get a different name.
+	overlay, err := state.String(ctx, arguments.OverlayDigest)
+	if err != nil {
+		return PackageDetails{}, err
+	}
+	if overlay != "" {
+		buildID = fmt.Sprintf("%s-%s", buildID, overlay)
+	}
 
 	name, err := options.String(flags.PackageName)
 	if err != nil {
@@ -50,3 +63,161 @@ func GetPackageDetails(ctx context.Context, options *pipeline.OptionsHandler, st
 		Enterprise:   enterprise,
 	}, nil
 }
+
+// GetTarballPackageDetails returns the package details of a tarball or of a package that is made from a tarball. The build ID also has the
+// IDs of the options that change the contents of the tarball, like the frontend variant, the node version, the additional bundled plugins,
+// and the SBOM, so that these packages don't have the same names as the packages of the default build.
+func GetTarballPackageDetails(ctx context.Context, options *pipeline.OptionsHandler, state pipeline.StateHandler) (PackageDetails, error) {
+	p, err := GetPackageDetails(ctx, options, state)
+	if err != nil {
+		return PackageDetails{}, err
+	}
+
+	frontendOpts, err := FrontendBuildOpts(ctx, options, state)
+	if err != nil {
+		return PackageDetails{}, err
+	}
+
+	src, err := GrafanaDir(ctx, state, p.Enterprise)
+	if err != nil {
+		return PackageDetails{}, err
+	}
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return PackageDetails{}, err
+	}
+
+	bundlePlugins, err := state.Directory(ctx, arguments.BundlePlugins)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return PackageDetails{}, err
+		}
+		bundlePlugins = nil
+	}
+	plugins, err := extraPluginsID(ctx, bundlePlugins)
+	if err != nil {
+		return PackageDetails{}, err
+	}
+
+	withSBOM, err := options.Bool(flags.WithSBOM)
+	if err != nil {
+		return PackageDetails{}, err
+	}
+	sbom := ""
+	if withSBOM {
+		sbom = "sbom"
+	}
+
+	p.BuildID = joinIDs(p.BuildID, frontendOpts.ID(), node.ID(), plugins, sbom)
+
+	return p, nil
+}
+
+var PackageMetadataArguments = []pipeline.Argument{
+	arguments.PackageMetadata,
+	arguments.PackageVendor,
+	arguments.PackageMaintainer,
+	arguments.PackageHomepage,
+	arguments.PackageDescription,
+	arguments.PackageLicense,
+	arguments.PackageDepends,
+	arguments.MSIUpgradeCode,
+	arguments.MSIEnterpriseUpgradeCode,
+	arguments.MSIProductName,
+	arguments.MSITitle,
+}
+
+// GetPackageMetadata returns the package metadata from the '--package-metadata' file, overridden by the other '--package-*' flags. Fields
+// that are not set in either are set to the defaults for the edition when the package is built.
+func GetPackageMetadata(ctx context.Context, state pipeline.StateHandler) (packages.Metadata, error) {
+	m := packages.Metadata{}
+	file, err := state.String(ctx, arguments.PackageMetadata)
+	if err != nil {
+		return m, err
+	}
+	if file != "" {
+		m, err = packages.ParseMetadata([]byte(file))
+		if err != nil {
+			return m, err
+		}
+	}
+
+	overrides := packages.Metadata{}
+	for _, v := range []struct {
+		arg   pipeline.Argument
+		value *string
+	}{
+		{arguments.PackageVendor, &overrides.Vendor},
+		{arguments.PackageMaintainer, &overrides.Maintainer},
+		{arguments.PackageHomepage, &overrides.Homepage},
+		{arguments.PackageDescription, &overrides.Description},
+		{arguments.PackageLicense, &overrides.License},
+		{arguments.MSIUpgradeCode, &overrides.UpgradeCode},
+		{arguments.MSIEnterpriseUpgradeCode, &overrides.EnterpriseUpgradeCode},
+		{arguments.MSIProductName, &overrides.ProductName},
+		{arguments.MSITitle, &overrides.Title},
+	} {
+		s, err := state.String(ctx, v.arg)
+		if err != nil {
+			return m, err
+		}
+		*v.value = s
+	}
+
+	depends, err := state.String(ctx, arguments.PackageDepends)
+	if err != nil {
+		return m, err
+	}
+	for _, v := range strings.Split(depends, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			overrides.Depends = append(overrides.Depends, v)
+		}
+	}
+
+	m = m.Merge(overrides)
+	for _, v := range m.Depends {
+		if _, err := fpm.ParseDependency(v); err != nil {
+			return m, err
+		}
+	}
+
+	return m, nil
+}
+
+// overlayDirectory returns the directory from '--overlay', or nil if it is not set.
+func overlayDirectory(ctx context.Context, state pipeline.StateHandler) (*dagger.Directory, error) {
+	dir, err := state.Directory(ctx, arguments.Overlay)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return dir, nil
+}
+
+// overlayConfigFiles returns the config files that the overlay adds to the deb, rpm, and apk packages. Like every other file, the files in
+// the overlay are in '/usr/share/grafana' and are replaced on upgrades. If the overlay has provisioning files in 'conf/provisioning', the
+// 'conf/provisioning' folder of the tarball is also installed into '/etc/grafana/provisioning' and marked as config files, so that changes
+// to them are kept on upgrades.
+// Only 'conf/provisioning' is handled. The other files that the packages install in '/etc', like the default environment files in
+// 'packaging', are already config files, so replacing them in the overlay works without this. Every other file in the overlay, like
+// 'conf/defaults.ini', is not a config file (see '--overlay').
+func overlayConfigFiles(ctx context.Context, overlay *dagger.Directory) ([][]string, error) {
+	if overlay == nil {
+		return nil, nil
+	}
+
+	provisioning, err := overlay.Glob(ctx, "conf/provisioning/**")
+	if err != nil {
+		return nil, err
+	}
+	if len(provisioning) == 0 {
+		return nil, nil
+	}
+
+	return [][]string{
+		{"/src/conf/provisioning", "/pkg/etc/grafana/provisioning"},
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"path"
+	"strings"
 
 	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/arguments"
@@ -15,9 +16,12 @@ import (
 
 var (
 	BundledPluginsFlags     = flags.PackageNameFlags
-	BundledPluginsArguments = []pipeline.Argument{
-		arguments.YarnCacheDirectory,
-	}
+	BundledPluginsArguments = arguments.Join(
+		[]pipeline.Argument{
+			arguments.YarnCacheDirectory,
+		},
+		arguments.NodeArguments,
+	)
 )
 
 type BundledPlugins struct {
@@ -25,6 +29,11 @@ type BundledPlugins struct {
 	Src       *dagger.Directory
 	YarnCache *dagger.CacheVolume
 	Version   string
+	Node      *frontend.Node
+
+	// Extra has additional plugins that are unpacked into a folder named after the plugin ID (see 'arguments.BundlePlugins'). They replace
+	// the plugins in the source with the same ID. It is nil if there are no additional plugins.
+	Extra *dagger.Directory
 }
 
 // The frontend does not have any artifact dependencies.
@@ -34,7 +43,7 @@ func (f *BundledPlugins) Dependencies(ctx context.Context) ([]*pipeline.Artifact
 
 // Builder will return a node.js alpine container that matches the .nvmrc in the Grafana source repository
 func (f *BundledPlugins) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
-	return FrontendBuilder(ctx, f.Src, f.YarnCache, opts)
+	return FrontendBuilder(ctx, f.Src, f.YarnCache, f.Node, opts)
 }
 
 func (f *BundledPlugins) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
@@ -42,7 +51,21 @@ func (f *BundledPlugins) BuildFile(ctx context.Context, builder *dagger.Containe
 }
 
 func (f *BundledPlugins) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
-	return frontend.BuildPlugins(builder), nil
+	dir := frontend.BuildPlugins(builder)
+	if f.Extra == nil {
+		return dir, nil
+	}
+
+	ids, err := f.Extra.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		opts.Log.Info("adding bundled plugin", "id", id)
+		dir = dir.WithoutDirectory(id).WithDirectory(id, f.Extra.Directory(id))
+	}
+
+	return dir, nil
 }
 
 func (f *BundledPlugins) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
@@ -74,10 +97,31 @@ func (f *BundledPlugins) Filename(ctx context.Context) (string, error) {
 	// Important note: this path is only used in two ways:
 	// 1. When requesting an artifact be built and exported, this is the path where it will be exported to
 	// 2. In a map to distinguish when the same artifact is being built more than once
-	return path.Join("bin", "bundled-plugins"), nil
+	extra, err := extraPluginsID(ctx, f.Extra)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join("bin", joinIDs("bundled-plugins", f.Node.ID(), extra)), nil
+}
+
+// extraPluginsID returns an identifier for the additional plugins from '--bundle-plugin' and '--bundle-plugins-dir' that can be used in file
+// names. It is empty if there are no additional plugins.
+func extraPluginsID(ctx context.Context, extra *dagger.Directory) (string, error) {
+	if extra == nil {
+		return "", nil
+	}
+
+	digest, err := extra.Digest(ctx)
+	if err != nil {
+		return "", err
+	}
+	_, sum, _ := strings.Cut(digest, ":")
+
+	return "extra-" + sum[:12], nil
 }
 
-func NewBundledPlugins(ctx context.Context, log *slog.Logger, artifact string, src *dagger.Directory, version string, cacheVolume *dagger.CacheVolume) (*pipeline.Artifact, error) {
+func NewBundledPlugins(ctx context.Context, log *slog.Logger, artifact string, src *dagger.Directory, version string, cacheVolume *dagger.CacheVolume, node *frontend.Node, extra *dagger.Directory) (*pipeline.Artifact, error) {
 	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
 		ArtifactString: artifact,
 		Type:           pipeline.ArtifactTypeDirectory,
@@ -86,6 +130,8 @@ func NewBundledPlugins(ctx context.Context, log *slog.Logger, artifact string, s
 			Src:       src,
 			YarnCache: cacheVolume,
 			Version:   version,
+			Node:      node,
+			Extra:     extra,
 		},
 	})
 }
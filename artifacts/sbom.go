@@ -0,0 +1,288 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/containers"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/golang"
+	"github.com/grafana/grafana-build/packages"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/grafana/grafana-build/sbom"
+)
+
+var (
+	SBOMArguments = TargzArguments
+	SBOMFlags     = flags.JoinFlags(
+		TargzFlags,
+		[]pipeline.Flag{flags.SBOMFromTarballFlag},
+	)
+)
+
+var SBOMInitializer = Initializer{
+	InitializerFunc: NewSBOMFromString,
+	Arguments:       SBOMArguments,
+}
+
+// SBOM produces a directory with a software bill of materials for a Grafana package in both the SPDX and CycloneDX JSON formats.
+// The Go modules are read from the build info embedded in the backend binaries, and the npm packages are read from the yarn lockfile.
+type SBOM struct {
+	Name         packages.Name
+	Version      string
+	BuildID      string
+	Distribution backend.Distribution
+	GoVersion    string
+
+	// Src is used for the yarn lockfile.
+	Src *dagger.Directory
+
+	// Either Tarball or Backend is set. The frontend doesn't need to be built; its packages are read from the yarn lockfile in Src.
+	Backend *pipeline.Artifact
+	Tarball *pipeline.Artifact
+}
+
+func (s *SBOM) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	if s.Tarball != nil {
+		return []*pipeline.Artifact{
+			s.Tarball,
+		}, nil
+	}
+
+	return []*pipeline.Artifact{
+		s.Backend,
+	}, nil
+}
+
+// Builder returns a Go container, which is used for 'go version -m'. It can read binaries of any OS / architecture, so the host's platform is used.
+func (s *SBOM) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	return golang.Container(opts.Client, opts.Platform, s.GoVersion), nil
+}
+
+func (s *SBOM) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	panic("This artifact does not produce files")
+}
+
+func (s *SBOM) bin(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	if s.Tarball == nil {
+		return opts.Store.Directory(ctx, s.Backend)
+	}
+
+	targz, err := opts.Store.File(ctx, s.Tarball)
+	if err != nil {
+		return nil, err
+	}
+
+	return containers.ExtractedArchive(opts.Client, targz).Directory("bin"), nil
+}
+
+func (s *SBOM) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	bin, err := s.bin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	buildInfo, err := builder.
+		WithMountedDirectory("/src/bin", bin).
+		WithExec([]string{"go", "version", "-m", "/src/bin"}).
+		Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading build info from backend binaries: %w", err)
+	}
+
+	goPkgs, err := sbom.GoPackages(buildInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	lockfile, err := s.Src.File("yarn.lock").Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading yarn.lock: %w", err)
+	}
+
+	npmPkgs, err := sbom.YarnPackages(lockfile)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := s.Filename(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSuffix(name, ".sbom")
+
+	doc := sbom.NewDocument(name, string(s.Name), s.Version, time.Now(), goPkgs, npmPkgs)
+	opts.Log.Info("generated SBOM", "go", len(goPkgs), "npm", len(npmPkgs), "packages", len(doc.Packages))
+
+	spdx, err := sbom.SPDX(doc)
+	if err != nil {
+		return nil, err
+	}
+	cdx, err := sbom.CycloneDX(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	spdxName, cdxName, err := s.documentNames()
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.Client.Directory().
+		WithNewFile(spdxName, string(spdx)).
+		WithNewFile(cdxName, string(cdx)), nil
+}
+
+func (s *SBOM) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (s *SBOM) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (s *SBOM) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (s *SBOM) Filename(ctx context.Context) (string, error) {
+	return packages.FileName(s.Name, s.Version, s.BuildID, s.Distribution, "sbom")
+}
+
+func (s *SBOM) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	// Not a file
+	return nil
+}
+
+// documentNames returns the names of the SPDX and CycloneDX documents in the SBOM directory.
+func (s *SBOM) documentNames() (string, string, error) {
+	spdxName, err := packages.FileName(s.Name, s.Version, s.BuildID, s.Distribution, "spdx.json")
+	if err != nil {
+		return "", "", err
+	}
+	cdxName, err := packages.FileName(s.Name, s.Version, s.BuildID, s.Distribution, "cdx.json")
+	if err != nil {
+		return "", "", err
+	}
+
+	return spdxName, cdxName, nil
+}
+
+// VerifyDirectory checks that both documents are in the directory, that they parse as SPDX and CycloneDX JSON, and that they list at
+// least one package.
+func (s *SBOM) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	spdxName, cdxName, err := s.documentNames()
+	if err != nil {
+		return err
+	}
+
+	for name, verify := range map[string]func([]byte) error{
+		spdxName: sbom.VerifySPDX,
+		cdxName:  sbom.VerifyCycloneDX,
+	} {
+		contents, err := dir.File(name).Contents(ctx)
+		if err != nil {
+			return fmt.Errorf("error reading '%s': %w", name, err)
+		}
+		if err := verify([]byte(contents)); err != nil {
+			return fmt.Errorf("error verifying '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+type NewSBOMOpts struct {
+	Name         packages.Name
+	Version      string
+	BuildID      string
+	Distribution backend.Distribution
+	GoVersion    string
+	Src          *dagger.Directory
+
+	Backend *pipeline.Artifact
+	Tarball *pipeline.Artifact
+}
+
+func NewSBOMFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	options, err := pipeline.ParseFlags(artifact, SBOMFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	fromTarball, err := options.Bool(flags.SBOMFromTarball)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := GetPackageDetails(ctx, options, state)
+	if err != nil {
+		return nil, err
+	}
+
+	goVersion, err := state.String(ctx, arguments.GoVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := GrafanaDir(ctx, state, p.Enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &NewSBOMOpts{
+		Name:         p.Name,
+		Version:      p.Version,
+		BuildID:      p.BuildID,
+		Distribution: p.Distribution,
+		GoVersion:    goVersion,
+		Src:          src,
+	}
+
+	if fromTarball {
+		tarball, err := NewTarballFromString(ctx, log, artifact, state)
+		if err != nil {
+			return nil, err
+		}
+		opts.Tarball = tarball
+		return NewSBOM(ctx, log, artifact, opts)
+	}
+
+	backendArtifact, err := NewBackendFromString(ctx, log, artifact, state)
+	if err != nil {
+		return nil, err
+	}
+	opts.Backend = backendArtifact
+
+	return NewSBOM(ctx, log, artifact, opts)
+}
+
+func NewSBOM(ctx context.Context, log *slog.Logger, artifact string, opts *NewSBOMOpts) (*pipeline.Artifact, error) {
+	log.Info("Initializing SBOM artifact", "name", opts.Name, "version", opts.Version, "distro", opts.Distribution, "from-targz", opts.Tarball != nil)
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeDirectory,
+		Flags:          SBOMFlags,
+		Handler: &SBOM{
+			Name:         opts.Name,
+			Version:      opts.Version,
+			BuildID:      opts.BuildID,
+			Distribution: opts.Distribution,
+			GoVersion:    opts.GoVersion,
+			Src:          opts.Src,
+			Backend:      opts.Backend,
+			Tarball:      opts.Tarball,
+		},
+	})
+}
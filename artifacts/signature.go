@@ -0,0 +1,187 @@
+package artifacts
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/gpg"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	GPGArguments = []pipeline.Argument{
+		arguments.GPGPublicKey,
+		arguments.GPGPrivateKey,
+		arguments.GPGPassphrase,
+	}
+	SignatureArguments = arguments.Join(
+		TargzArguments,
+		GPGArguments,
+	)
+	SignatureFlags = flags.JoinFlags(
+		TargzFlags,
+		flags.SignatureFlags,
+	)
+)
+
+var SignatureInitializer = Initializer{
+	InitializerFunc: NewSignatureFromString,
+	Arguments:       SignatureArguments,
+}
+
+// Signature is a detached armored GPG signature ('.asc') of a tar.gz, zip, or msi package.
+type Signature struct {
+	// Type is 'targz', 'zip', or 'msi'.
+	Type string
+	GPG  gpg.GPGOpts
+
+	Package *pipeline.Artifact
+}
+
+func (s *Signature) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return []*pipeline.Artifact{
+		s.Package,
+	}, nil
+}
+
+func (s *Signature) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	// The signature is created in the gpg.Signer container.
+	return opts.Client.Container(), nil
+}
+
+func (s *Signature) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	file, err := opts.Store.File(ctx, s.Package)
+	if err != nil {
+		return nil, err
+	}
+
+	return gpg.SignDetached(opts.Client, file, s.GPG), nil
+}
+
+func (s *Signature) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	panic("This artifact does not produce directories")
+}
+
+func (s *Signature) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (s *Signature) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (s *Signature) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("This artifact does not produce directories")
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (s *Signature) Filename(ctx context.Context) (string, error) {
+	name, err := s.Package.Handler.Filename(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return name + ".asc", nil
+}
+
+// VerifyFile can't verify the signature without the signed package; the signature is verified with VerifyFileWithStore instead.
+func (s *Signature) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	return errors.New("the signature can only be verified with the signed package")
+}
+
+// VerifyFileWithStore checks the signature against the signed package from the store with only the public key.
+func (s *Signature) VerifyFileWithStore(ctx context.Context, client *dagger.Client, store pipeline.ArtifactStore, file *dagger.File) error {
+	pkg, err := store.File(ctx, s.Package)
+	if err != nil {
+		return err
+	}
+
+	return gpg.VerifyDetachedSignature(ctx, client, pkg, file, s.GPG.GPGPublicKey)
+}
+
+func (s *Signature) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	panic("This artifact does not produce directories")
+}
+
+func NewSignatureFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	options, err := pipeline.ParseFlags(artifact, SignatureFlags)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := options.String(flags.SignatureType)
+	if err != nil {
+		return nil, fmt.Errorf("the 'asc' artifact requires a package type, like 'asc:targz', 'asc:zip', or 'asc:msi': %w", err)
+	}
+	gpgOpts, err := GPGOpts(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg *pipeline.Artifact
+	switch typ {
+	case "zip":
+		pkg, err = NewZipFromString(ctx, log, artifact, state)
+	case "msi":
+		pkg, err = NewMSIFromString(ctx, log, artifact, state)
+	case "targz":
+		pkg, err = NewTarballFromString(ctx, log, artifact, state)
+	default:
+		return nil, fmt.Errorf("the 'asc' artifact can only sign 'targz', 'zip', or 'msi' packages, not '%s'", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeFile,
+		Flags:          SignatureFlags,
+		Handler: &Signature{
+			Type:    typ,
+			GPG:     gpgOpts,
+			Package: pkg,
+		},
+	})
+}
+
+// GPGOpts returns the GPG keys and passphrase from the '--gpg-*' arguments. The keys are decoded from base64.
+func GPGOpts(ctx context.Context, state pipeline.StateHandler) (gpg.GPGOpts, error) {
+	pubb64, err := state.String(ctx, arguments.GPGPublicKey)
+	if err != nil {
+		return gpg.GPGOpts{}, err
+	}
+	pub, err := base64.StdEncoding.DecodeString(pubb64)
+	if err != nil {
+		return gpg.GPGOpts{}, fmt.Errorf("gpg-public-key-base64 cannot be decoded %w", err)
+	}
+
+	privb64, err := state.String(ctx, arguments.GPGPrivateKey)
+	if err != nil {
+		return gpg.GPGOpts{}, err
+	}
+	priv, err := base64.StdEncoding.DecodeString(privb64)
+	if err != nil {
+		return gpg.GPGOpts{}, fmt.Errorf("gpg-private-key-base64 cannot be decoded %w", err)
+	}
+
+	pass, err := state.String(ctx, arguments.GPGPassphrase)
+	if err != nil {
+		return gpg.GPGOpts{}, err
+	}
+
+	return gpg.GPGOpts{
+		GPGPublicKey:  string(pub),
+		GPGPrivateKey: string(priv),
+		GPGPassphrase: pass,
+	}, nil
+}
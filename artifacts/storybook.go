@@ -14,9 +14,13 @@ import (
 
 var (
 	StorybookFlags     = flags.PackageNameFlags
-	StorybookArguments = []pipeline.Argument{
-		arguments.YarnCacheDirectory,
-	}
+	StorybookArguments = arguments.Join(
+		[]pipeline.Argument{
+			arguments.YarnCacheDirectory,
+			arguments.StorybookMinStories,
+		},
+		arguments.NodeArguments,
+	)
 )
 
 var StorybookInitializer = Initializer{
@@ -28,6 +32,10 @@ type Storybook struct {
 	Src       *dagger.Directory
 	YarnCache *dagger.CacheVolume
 	Version   string
+	Node      *frontend.Node
+
+	// MinStories is the minimum number of stories that the storybook index must list for verification to pass.
+	MinStories int
 }
 
 // The frontend does not have any artifact dependencies.
@@ -37,7 +45,7 @@ func (f *Storybook) Dependencies(ctx context.Context) ([]*pipeline.Artifact, err
 
 // Builder will return a node.js alpine container that matches the .nvmrc in the Grafana source repository
 func (f *Storybook) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
-	return FrontendBuilder(ctx, f.Src, f.YarnCache, opts)
+	return FrontendBuilder(ctx, f.Src, f.YarnCache, f.Node, opts)
 }
 
 func (f *Storybook) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
@@ -66,8 +74,9 @@ func (f *Storybook) VerifyFile(ctx context.Context, client *dagger.Client, file
 	return nil
 }
 
+// VerifyDirectory serves the storybook and checks that its pages, stories index, and referenced assets load.
 func (f *Storybook) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
-	return nil
+	return frontend.VerifyStorybook(ctx, client, dir, f.MinStories)
 }
 
 // Filename should return a deterministic file or folder name that this build will produce.
@@ -79,7 +88,7 @@ func (f *Storybook) Filename(ctx context.Context) (string, error) {
 	// Important note: this path is only used in two ways:
 	// 1. When requesting an artifact be built and exported, this is the path where it will be exported to
 	// 2. In a map to distinguish when the same artifact is being built more than once
-	return filepath.Join(f.Version, "storybook"), nil
+	return filepath.Join(f.Version, joinIDs("storybook", f.Node.ID())), nil
 }
 
 func NewStorybookFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
@@ -95,19 +104,30 @@ func NewStorybookFromString(ctx context.Context, log *slog.Logger, artifact stri
 	if err != nil {
 		return nil, err
 	}
+	minStories, err := state.Int64(ctx, arguments.StorybookMinStories)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := NodeOpts(ctx, state, grafanaDir)
+	if err != nil {
+		return nil, err
+	}
 
-	return NewStorybook(ctx, log, artifact, grafanaDir, version, cacheDir)
+	return NewStorybook(ctx, log, artifact, grafanaDir, version, cacheDir, node, int(minStories))
 }
 
-func NewStorybook(ctx context.Context, log *slog.Logger, artifact string, src *dagger.Directory, version string, cache *dagger.CacheVolume) (*pipeline.Artifact, error) {
+func NewStorybook(ctx context.Context, log *slog.Logger, artifact string, src *dagger.Directory, version string, cache *dagger.CacheVolume, node *frontend.Node, minStories int) (*pipeline.Artifact, error) {
 	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
 		ArtifactString: artifact,
 		Type:           pipeline.ArtifactTypeDirectory,
 		Flags:          StorybookFlags,
 		Handler: &Storybook{
-			Src:       src,
-			YarnCache: cache,
-			Version:   version,
+			Src:        src,
+			YarnCache:  cache,
+			Version:    version,
+			Node:       node,
+			MinStories: minStories,
 		},
 	})
 }
@@ -0,0 +1,253 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/backend"
+	cmdflags "github.com/grafana/grafana-build/cmd/flags"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/packages"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	BackendTestArguments = arguments.Join(
+		BackendArguments,
+		[]pipeline.Argument{
+			arguments.Version,
+			arguments.GoTestPackages,
+			arguments.GoTestRun,
+			arguments.GoTestShard,
+			arguments.GoTestTimeout,
+		},
+	)
+
+	BackendTestFlags = flags.JoinFlags(
+		flags.PackageNameFlags,
+		flags.DistroFlags(),
+		flags.TestFlags,
+	)
+)
+
+var BackendTestInitializer = Initializer{
+	InitializerFunc: NewBackendTestFromString,
+	Arguments:       BackendTestArguments,
+}
+
+// BackendTest runs the Grafana backend's Go tests in the same container that is used to build the backend, and produces a directory
+// with the JUnit report, the coverage profile, and the raw 'go test -json' output.
+type BackendTest struct {
+	Name           packages.Name
+	Version        string
+	Src            *dagger.Directory
+	Distribution   backend.Distribution
+	BuildOpts      *backend.BuildOpts
+	TestOpts       *backend.TestOpts
+	GoVersion      string
+	ViceroyVersion string
+
+	GoBuildCache *dagger.CacheVolume
+	GoModCache   *dagger.CacheVolume
+}
+
+func (b *BackendTest) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	return backend.Builder(
+		opts.Client,
+		opts.Log,
+		b.Distribution,
+		b.BuildOpts,
+		opts.Platform,
+		b.Src,
+		b.GoVersion,
+		b.ViceroyVersion,
+		b.GoBuildCache,
+		b.GoModCache,
+	)
+}
+
+func (b *BackendTest) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return nil, nil
+}
+
+func (b *BackendTest) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	panic("not implemented") // BackendTest doesn't return a file
+}
+
+func (b *BackendTest) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	opts.Log.Info("running backend tests", "packages", b.TestOpts.Packages, "short", b.TestOpts.Short, "run", b.TestOpts.Run, "shard", b.TestOpts.Shard, "shards", b.TestOpts.Shards)
+	return backend.Test(builder, backend.GoTestSum(opts.Client, opts.Platform, b.GoVersion), b.Src, b.TestOpts), nil
+}
+
+func (b *BackendTest) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (b *BackendTest) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (b *BackendTest) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (b *BackendTest) Filename(ctx context.Context) (string, error) {
+	return filepath.Join(b.Version, "backend-test", string(b.Name), string(b.Distribution), b.TestOpts.ID()), nil
+}
+
+func (b *BackendTest) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	// Not a file
+	return nil
+}
+
+func (b *BackendTest) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	// Test failures already fail the artifact when it's built.
+	return nil
+}
+
+func NewBackendTestFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	goVersion, err := state.String(ctx, arguments.GoVersion)
+	if err != nil {
+		return nil, err
+	}
+	viceroyVersion, err := state.String(ctx, arguments.ViceroyVersion)
+	if err != nil {
+		return nil, err
+	}
+	goModCache, err := state.CacheVolume(ctx, arguments.GoModCache)
+	if err != nil {
+		return nil, err
+	}
+	goBuildCache, err := state.CacheVolume(ctx, arguments.GoBuildCache)
+	if err != nil {
+		return nil, err
+	}
+	version, err := state.String(ctx, arguments.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := pipeline.ParseFlags(artifact, BackendTestFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tests are ran in the build container, so unlike packages, the distribution is optional and defaults to the host's.
+	distro := backend.Distribution(cmdflags.DefaultDistros[0])
+	if d, err := options.String(flags.Distribution); err == nil {
+		distro = backend.Distribution(d)
+	} else if !errors.Is(err, pipeline.ErrorFlagOptionNotFound) {
+		return nil, err
+	}
+	if os, _ := backend.OSAndArch(distro); os != "linux" {
+		return nil, fmt.Errorf("distribution ('%s') for backend-test '%s' is not a Linux distribution; tests can only be ran on Linux", distro, artifact)
+	}
+
+	name, err := options.String(flags.PackageName)
+	if err != nil {
+		return nil, err
+	}
+	enterprise, err := options.Bool(flags.Enterprise)
+	if err != nil {
+		return nil, err
+	}
+	wireTag, err := options.String(flags.WireTag)
+	if err != nil {
+		return nil, err
+	}
+	experiments, err := options.StringSlice(flags.GoExperiments)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := options.StringSlice(flags.GoTags)
+	if err != nil {
+		return nil, err
+	}
+	short, err := options.Bool(flags.TestShort)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := state.String(ctx, arguments.GoTestPackages)
+	if err != nil {
+		return nil, err
+	}
+	run, err := state.String(ctx, arguments.GoTestRun)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := state.String(ctx, arguments.GoTestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	shardStr, err := state.String(ctx, arguments.GoTestShard)
+	if err != nil {
+		return nil, err
+	}
+	shard, shards, err := backend.ParseShard(shardStr)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := GrafanaDir(ctx, state, enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	testOpts := &backend.TestOpts{
+		Packages: splitNonEmpty(pkgs, ","),
+		Short:    short,
+		Run:      run,
+		Tags:     tags,
+		Timeout:  timeout,
+		Shard:    shard,
+		Shards:   shards,
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeDirectory,
+		Flags:          BackendTestFlags,
+		Handler: &BackendTest{
+			Name:         packages.Name(name),
+			Version:      version,
+			Src:          src,
+			Distribution: distro,
+			BuildOpts: &backend.BuildOpts{
+				Version:           version,
+				Enterprise:        enterprise,
+				ExperimentalFlags: experiments,
+				WireTag:           wireTag,
+				Tags:              tags,
+			},
+			TestOpts:       testOpts,
+			GoVersion:      goVersion,
+			ViceroyVersion: viceroyVersion,
+			GoBuildCache:   goBuildCache,
+			GoModCache:     goModCache,
+		},
+	})
+}
+
+// splitNonEmpty splits 's' by 'sep' and removes any empty or whitespace-only values.
+func splitNonEmpty(s, sep string) []string {
+	r := []string{}
+	for _, v := range strings.Split(s, sep) {
+		if v := strings.TrimSpace(v); v != "" {
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,202 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/packages"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	FrontendTestArguments = arguments.Join(
+		[]pipeline.Argument{
+			arguments.YarnCacheDirectory,
+			arguments.Version,
+			arguments.FrontendTestSuites,
+			arguments.FrontendTestShard,
+		},
+		arguments.NodeArguments,
+	)
+
+	FrontendTestFlags = flags.PackageNameFlags
+)
+
+var FrontendTestInitializer = Initializer{
+	InitializerFunc: NewFrontendTestFromString,
+	Arguments:       FrontendTestArguments,
+}
+
+// FrontendTest runs the jest tests, the type checks, and the linters in the same container that is used to build the frontend, and produces
+// a directory with a JUnit report for every suite, the lcov coverage report, and the output of every suite.
+type FrontendTest struct {
+	Name      packages.Name
+	Version   string
+	Src       *dagger.Directory
+	YarnCache *dagger.CacheVolume
+	TestOpts  *frontend.TestOpts
+	Node      *frontend.Node
+}
+
+// The frontend tests do not have any artifact dependencies.
+func (f *FrontendTest) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return nil, nil
+}
+
+func (f *FrontendTest) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	return FrontendBuilder(ctx, f.Src, f.YarnCache, f.Node, opts)
+}
+
+func (f *FrontendTest) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	panic("not implemented") // FrontendTest doesn't return a file
+}
+
+// BuildDir runs every suite, even if one of them fails, and logs the failures of each suite. It returns an error if any suite failed.
+func (f *FrontendTest) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	opts.Log.Info("running frontend tests", "suites", f.TestOpts.RunSuites(), "shard", f.TestOpts.Shard, "shards", f.TestOpts.Shards)
+
+	results, dir, err := frontend.TestResults(ctx, frontend.Test(builder, f.TestOpts), f.TestOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := []string{}
+	for _, r := range results {
+		log := opts.Log.With("suite", r.Suite, "tests", r.JUnit.Tests, "failures", r.JUnit.Failures)
+		if !r.Failed() {
+			log.Info("frontend test suite passed")
+			continue
+		}
+
+		failed = append(failed, string(r.Suite))
+		suites := r.JUnit.FailedSuites()
+		log.Error("frontend test suite failed", "exit", r.ExitCode, "failed_suites", len(suites))
+		for _, v := range suites {
+			log.Error("failed", "name", v.Name, "cases", v.Cases)
+		}
+		if r.Suite != frontend.TestSuiteJest || len(suites) == 0 {
+			log.Error("output", "tail", r.Tail(50))
+		}
+	}
+
+	if len(failed) != 0 {
+		return nil, fmt.Errorf("%w: %s", frontend.ErrorTestsFailed, strings.Join(failed, ", "))
+	}
+
+	return dir, nil
+}
+
+func (f *FrontendTest) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (f *FrontendTest) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (f *FrontendTest) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (f *FrontendTest) Filename(ctx context.Context) (string, error) {
+	return filepath.Join(f.Version, "frontend-test", string(f.Name), joinIDs(f.TestOpts.ID(), f.Node.ID())), nil
+}
+
+func (f *FrontendTest) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	// Not a file
+	return nil
+}
+
+func (f *FrontendTest) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	// Test failures already fail the artifact when it's built.
+	return nil
+}
+
+func NewFrontendTestFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	options, err := pipeline.ParseFlags(artifact, FrontendTestFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := options.String(flags.PackageName)
+	if err != nil {
+		return nil, err
+	}
+	enterprise, err := options.Bool(flags.Enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := state.String(ctx, arguments.Version)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := state.CacheVolume(ctx, arguments.YarnCacheDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	suitesStr, err := state.String(ctx, arguments.FrontendTestSuites)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := frontend.ParseTestSuites(splitNonEmpty(suitesStr, ","))
+	if err != nil {
+		return nil, err
+	}
+	shardStr, err := state.String(ctx, arguments.FrontendTestShard)
+	if err != nil {
+		return nil, err
+	}
+	shard, shards, err := backend.ParseShard(shardStr)
+	if err != nil {
+		return nil, err
+	}
+
+	testOpts := &frontend.TestOpts{
+		Suites: suites,
+		Shard:  shard,
+		Shards: shards,
+	}
+	if len(testOpts.RunSuites()) == 0 {
+		return nil, errors.New("no frontend test suites to run in this shard; typecheck and lint only run in the first shard")
+	}
+
+	src, err := GrafanaDir(ctx, state, enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeDirectory,
+		Flags:          FrontendTestFlags,
+		Handler: &FrontendTest{
+			Name:      packages.Name(name),
+			Version:   version,
+			Src:       src,
+			YarnCache: cache,
+			TestOpts:  testOpts,
+			Node:      node,
+		},
+	})
+}
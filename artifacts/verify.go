@@ -0,0 +1,36 @@
+package artifacts
+
+import (
+	"context"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/e2e"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+// verifyMode returns the verification mode from '--verify'.
+func verifyMode(ctx context.Context, state pipeline.StateHandler) (e2e.Mode, error) {
+	v, err := state.String(ctx, arguments.Verify)
+	if err != nil {
+		return e2e.ModeNone, err
+	}
+
+	return e2e.ParseMode(v)
+}
+
+// validateOpts returns the options for verifying a package with 'version' with the e2e tests from 'src' (which run with 'node') or with the
+// smoke test.
+func validateOpts(mode e2e.Mode, src *dagger.Directory, yarnCache *dagger.CacheVolume, node *frontend.Node, version string, enterprise bool) e2e.ValidateOpts {
+	return e2e.ValidateOpts{
+		Mode:      mode,
+		Src:       src,
+		YarnCache: yarnCache,
+		Node:      node,
+		Smoke: e2e.SmokeOpts{
+			Version:    version,
+			Enterprise: enterprise,
+		},
+	}
+}
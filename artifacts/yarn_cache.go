@@ -0,0 +1,130 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+var (
+	YarnCacheFlags     = flags.PackageNameFlags
+	YarnCacheArguments = arguments.Join(
+		[]pipeline.Argument{
+			arguments.GrafanaDirectory,
+		},
+		arguments.NodeArguments,
+	)
+)
+
+var YarnCacheInitializer = Initializer{
+	InitializerFunc: NewYarnCacheFromString,
+	Arguments:       YarnCacheArguments,
+}
+
+// YarnCache produces a '.tar.gz' archive of every package in the source's 'yarn.lock'. The archive can be provided to other builds with
+// '--yarn-cache-archive', so that 'yarn install' doesn't need to reach the npm registry.
+type YarnCache struct {
+	Src  *dagger.Directory
+	Node *frontend.Node
+}
+
+// The yarn cache does not have any artifact dependencies.
+func (y *YarnCache) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return nil, nil
+}
+
+func (y *YarnCache) node(client *dagger.Client, platform dagger.Platform) *dagger.Container {
+	return frontend.NodeContainer(client, y.Node, platform)
+}
+
+// Builder returns a node container that matches the .nvmrc in the Grafana source repository. Unlike the frontend builder, it doesn't mount the
+// yarn cache volume, so that the archive only has the packages in the lockfile.
+func (y *YarnCache) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	return y.node(opts.Client, opts.Platform), nil
+}
+
+func (y *YarnCache) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	return frontend.YarnCacheArchive(builder, y.Src), nil
+}
+
+func (y *YarnCache) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	panic("This artifact does not produce directories")
+}
+
+func (y *YarnCache) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (y *YarnCache) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (y *YarnCache) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("This artifact does not produce directories")
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (y *YarnCache) Filename(ctx context.Context) (string, error) {
+	// The archive only depends on the lockfile and the supported architectures, so use their hash in the name to make it clear which
+	// sources it can be used with.
+	lockfile, err := y.Src.File("yarn.lock").Contents(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error reading yarn.lock: %w", err)
+	}
+
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(lockfile+"\n"+frontend.YarnSupportedArchitectures)))
+	return fmt.Sprintf("yarn-cache-%s.tar.gz", sum[:12]), nil
+}
+
+// VerifyFile runs 'yarn install' with the network disabled, using only the packages in the archive.
+func (y *YarnCache) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	_, err := frontend.VerifyYarnCache(y.node(client, ""), y.Src, file).Sync(ctx)
+	return err
+}
+
+func (y *YarnCache) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	panic("This artifact does not produce directories")
+}
+
+func NewYarnCacheFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	options, err := pipeline.ParseFlags(artifact, YarnCacheFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	enterprise, err := options.Bool(flags.Enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := GrafanaDir(ctx, state, enterprise)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := NodeOpts(ctx, state, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeFile,
+		Flags:          YarnCacheFlags,
+		Handler: &YarnCache{
+			Src:  src,
+			Node: node,
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/arguments"
+	"github.com/grafana/grafana-build/flags"
+	"github.com/grafana/grafana-build/gpg"
+	"github.com/grafana/grafana-build/pipeline"
+	"github.com/grafana/grafana-build/repos"
+)
+
+var (
+	YumRepoArguments = arguments.Join(
+		RPMInitializer.Arguments,
+		[]pipeline.Argument{
+			arguments.YumRepoDir,
+		},
+	)
+	YumRepoFlags = RPMFlags
+)
+
+var YumRepoInitializer = Initializer{
+	InitializerFunc: NewYumRepoFromString,
+	Arguments:       YumRepoArguments,
+}
+
+// YumRepo is a yum repository with the rpms for every package name and distribution in the artifact string.
+type YumRepo struct {
+	Opts repos.YumOpts
+	// Existing is the repository that the rpms are added to. If it's nil, a new repository is created.
+	Existing *dagger.Directory
+
+	RPMs []*pipeline.Artifact
+}
+
+func (a *YumRepo) Dependencies(ctx context.Context) ([]*pipeline.Artifact, error) {
+	return a.RPMs, nil
+}
+
+func (a *YumRepo) Builder(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	// The repository is created in its own container in repos.Yum.
+	return opts.Client.Container(), nil
+}
+
+func (a *YumRepo) BuildFile(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.File, error) {
+	panic("This artifact does not produce files")
+}
+
+func (a *YumRepo) BuildDir(ctx context.Context, builder *dagger.Container, opts *pipeline.ArtifactContainerOpts) (*dagger.Directory, error) {
+	rpms := make([]*dagger.File, len(a.RPMs))
+	for i, v := range a.RPMs {
+		f, err := opts.Store.File(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		rpms[i] = f
+	}
+
+	return repos.Yum(opts.Client, rpms, a.Existing, a.Opts), nil
+}
+
+func (a *YumRepo) Publisher(ctx context.Context, opts *pipeline.ArtifactContainerOpts) (*dagger.Container, error) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (a *YumRepo) PublishFile(ctx context.Context, opts *pipeline.ArtifactPublishFileOpts) error {
+	panic("This artifact does not produce files")
+}
+
+func (a *YumRepo) PublishDir(ctx context.Context, opts *pipeline.ArtifactPublishDirOpts) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// Filename should return a deterministic file or folder name that this build will produce.
+// This filename is used as a map key for caching, so implementers need to ensure that arguments or flags that affect the output
+// also affect the filename to ensure that there are no collisions.
+// For example, the backend for `linux/amd64` and `linux/arm64` should not both produce a `bin` folder, they should produce a
+// `bin/linux-amd64` folder and a `bin/linux-arm64` folder. Callers can mount this as `bin` or whatever if they want.
+func (a *YumRepo) Filename(ctx context.Context) (string, error) {
+	id, err := repoID(ctx, a.RPMs, a.Existing, a.Opts.GPG.GPGPublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	signed := ""
+	if a.Opts.Sign {
+		signed = "signed"
+	}
+
+	return path.Join("yum-repo", joinIDs(id, signed)), nil
+}
+
+func (a *YumRepo) VerifyFile(ctx context.Context, client *dagger.Client, file *dagger.File) error {
+	panic("This artifact does not produce files")
+}
+
+// VerifyDirectory serves the repository over HTTP and checks that every rpm in it can be installed from it with dnf.
+func (a *YumRepo) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
+	return repos.VerifyYum(ctx, client, dir, a.Opts)
+}
+
+func NewYumRepoFromString(ctx context.Context, log *slog.Logger, artifact string, state pipeline.StateHandler) (*pipeline.Artifact, error) {
+	// The artifact string has more than one package name and distribution, so only the sign flag is parsed here.
+	options, err := pipeline.ParseFlags(artifact, []pipeline.Flag{flags.SignFlag})
+	if err != nil {
+		return nil, err
+	}
+	// The 'sign' flag signs both the repository and the rpms, so that dnf can check the packages too.
+	rpmStrings, err := PackageArtifactStrings(artifact, "rpm")
+	if err != nil {
+		return nil, err
+	}
+	rpms := make([]*pipeline.Artifact, len(rpmStrings))
+	for i, v := range rpmStrings {
+		rpms[i], err = NewRPMFromString(ctx, log, v, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := state.Directory(ctx, arguments.YumRepoDir)
+	if err != nil {
+		if !errors.Is(err, pipeline.ErrorFlagNotProvided) {
+			return nil, err
+		}
+		existing = nil
+	}
+
+	sign, err := options.Bool(flags.Sign)
+	if err != nil {
+		return nil, err
+	}
+	var gpgOpts gpg.GPGOpts
+	if sign {
+		gpgOpts, err = GPGOpts(ctx, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pipeline.ArtifactWithLogging(ctx, log, &pipeline.Artifact{
+		ArtifactString: artifact,
+		Type:           pipeline.ArtifactTypeDirectory,
+		Flags:          YumRepoFlags,
+		Handler: &YumRepo{
+			Opts: repos.YumOpts{
+				Sign: sign,
+				GPG:  gpgOpts,
+			},
+			Existing: existing,
+			RPMs:     rpms,
+		},
+	})
+}
@@ -46,6 +46,14 @@ func GoBuildCommand(output string, ldflags []LDFlag, tags []string, main string)
 	return args
 }
 
+// Binaries are the commands in 'pkg/cmd' that are built into the 'bin' folder.
+var Binaries = []string{
+	"grafana",
+	"grafana-server",
+	"grafana-cli",
+	"grafana-example-apiserver",
+}
+
 func Build(
 	d *dagger.Client,
 	builder *dagger.Container,
@@ -63,16 +71,9 @@ func Build(
 		ldflags = LDFlagsStatic(vcsinfo)
 	}
 
-	cmd := []string{
-		"grafana",
-		"grafana-server",
-		"grafana-cli",
-		"grafana-example-apiserver",
-	}
-
 	os, _ := OSAndArch(distro)
 
-	for _, v := range cmd {
+	for _, v := range Binaries {
 		// Some CLI packages such as grafana-example-apiserver don't exist in earlier Grafana Versions <10.3
 		// Below check skips building them as needed
 		pkgPath := path.Join("pkg", "cmd", v)
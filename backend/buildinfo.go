@@ -0,0 +1,275 @@
+package backend
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrorNoStringVar is returned by ReadStringVar if the binary has no symbol for the variable, for example because it was built with '-s'.
+var ErrorNoStringVar = errors.New("variable not found in the binary's symbol table")
+
+// ParseLDFlagsX returns the values that are set with '-X' in 'ldflags', like the '-ldflags' build setting of a binary, by name.
+func ParseLDFlagsX(ldflags string) map[string]string {
+	var (
+		fields = []string{}
+		field  = strings.Builder{}
+		quote  rune
+		in     bool
+	)
+	for _, r := range ldflags {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			field.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, in = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if in {
+				fields = append(fields, field.String())
+				field.Reset()
+				in = false
+			}
+		default:
+			field.WriteRune(r)
+			in = true
+		}
+	}
+	if in {
+		fields = append(fields, field.String())
+	}
+
+	values := map[string]string{}
+	for i := 0; i < len(fields); i++ {
+		v := fields[i]
+		switch {
+		case (v == "-X" || v == "--X") && i+1 < len(fields):
+			i++
+			v = fields[i]
+		case strings.HasPrefix(v, "-X="):
+			v = strings.TrimPrefix(v, "-X=")
+		default:
+			continue
+		}
+		if name, value, ok := strings.Cut(v, "="); ok {
+			values[name] = value
+		}
+	}
+
+	return values
+}
+
+// CheckBuildInfo checks that the 'main.version' and 'main.commit' values that were set with '-X' in the Go binary in 'r' match the build.
+// The values are read from the '-ldflags' build setting, or from the binary's symbol table if it was built with '-trimpath', which leaves
+// '-ldflags' out of the build info.
+func CheckBuildInfo(r io.ReaderAt, expectVersion, expectCommit string) error {
+	info, err := buildinfo.Read(r)
+	if err != nil {
+		return fmt.Errorf("%w: could not read the Go build info: %s", ErrorVersionMismatch, err)
+	}
+
+	for _, v := range info.Settings {
+		if v.Key != "-ldflags" {
+			continue
+		}
+		x := ParseLDFlagsX(v.Value)
+		version, ok := x["main.version"]
+		if !ok {
+			return fmt.Errorf("%w: 'main.version' is not set in the binary's -ldflags", ErrorVersionMismatch)
+		}
+		commit, ok := x["main.commit"]
+		if !ok {
+			return fmt.Errorf("%w: 'main.commit' is not set in the binary's -ldflags", ErrorVersionMismatch)
+		}
+
+		return CheckVersion(version, commit, expectVersion, expectCommit)
+	}
+
+	version, err := ReadStringVar(r, "main.version")
+	if err != nil {
+		return fmt.Errorf("%w: could not read 'main.version': %w", ErrorVersionMismatch, err)
+	}
+	commit, err := ReadStringVar(r, "main.commit")
+	if err != nil {
+		return fmt.Errorf("%w: could not read 'main.commit': %w", ErrorVersionMismatch, err)
+	}
+
+	return CheckVersion(version, commit, expectVersion, expectCommit)
+}
+
+// executable is the part of an ELF, Mach-O, or PE file that ReadStringVar needs.
+type executable struct {
+	order   binary.ByteOrder
+	ptrSize int
+	// symbol returns the virtual address of the symbol 'name'.
+	symbol func(name string) (uint64, bool)
+	// read returns 'n' bytes at the virtual address 'addr'.
+	read func(addr uint64, n int) ([]byte, error)
+}
+
+// readSection reads 'n' bytes at 'addr' from the section that starts at the virtual address 'start' and has 'size' bytes.
+func readSection(r io.ReaderAt, start, size, addr uint64, n int) ([]byte, bool, error) {
+	if addr < start || addr+uint64(n) > start+size {
+		return nil, false, nil
+	}
+
+	b := make([]byte, n)
+	if _, err := r.ReadAt(b, int64(addr-start)); err != nil {
+		return nil, true, err
+	}
+
+	return b, true, nil
+}
+
+func openExecutable(r io.ReaderAt) (*executable, error) {
+	if f, err := elf.NewFile(r); err == nil {
+		ptrSize := 8
+		if f.Class == elf.ELFCLASS32 {
+			ptrSize = 4
+		}
+		return &executable{
+			order:   f.ByteOrder,
+			ptrSize: ptrSize,
+			symbol: func(name string) (uint64, bool) {
+				syms, err := f.Symbols()
+				if err != nil {
+					return 0, false
+				}
+				for _, v := range syms {
+					if v.Name == name {
+						return v.Value, true
+					}
+				}
+				return 0, false
+			},
+			read: func(addr uint64, n int) ([]byte, error) {
+				for _, v := range f.Sections {
+					if v.Type == elf.SHT_NOBITS {
+						continue
+					}
+					if b, ok, err := readSection(v, v.Addr, v.Size, addr, n); ok {
+						return b, err
+					}
+				}
+				return nil, fmt.Errorf("address %#x is not in the binary", addr)
+			},
+		}, nil
+	}
+
+	if f, err := macho.NewFile(r); err == nil {
+		ptrSize := 8
+		if f.Magic == macho.Magic32 {
+			ptrSize = 4
+		}
+		return &executable{
+			order:   f.ByteOrder,
+			ptrSize: ptrSize,
+			symbol: func(name string) (uint64, bool) {
+				if f.Symtab == nil {
+					return 0, false
+				}
+				for _, v := range f.Symtab.Syms {
+					// Mach-O symbols have a leading underscore.
+					if v.Name == "_"+name || v.Name == name {
+						return v.Value, true
+					}
+				}
+				return 0, false
+			},
+			read: func(addr uint64, n int) ([]byte, error) {
+				for _, v := range f.Sections {
+					if b, ok, err := readSection(v, v.Addr, v.Size, addr, n); ok {
+						return b, err
+					}
+				}
+				return nil, fmt.Errorf("address %#x is not in the binary", addr)
+			},
+		}, nil
+	}
+
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, errors.New("not an ELF, Mach-O, or PE file")
+	}
+	var (
+		imageBase uint64
+		ptrSize   = 8
+	)
+	switch h := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		imageBase, ptrSize = uint64(h.ImageBase), 4
+	case *pe.OptionalHeader64:
+		imageBase = h.ImageBase
+	}
+
+	return &executable{
+		order:   binary.LittleEndian,
+		ptrSize: ptrSize,
+		symbol: func(name string) (uint64, bool) {
+			for _, v := range f.Symbols {
+				if v.Name != name || v.SectionNumber <= 0 || int(v.SectionNumber) > len(f.Sections) {
+					continue
+				}
+				return imageBase + uint64(f.Sections[v.SectionNumber-1].VirtualAddress) + uint64(v.Value), true
+			}
+			return 0, false
+		},
+		read: func(addr uint64, n int) ([]byte, error) {
+			for _, v := range f.Sections {
+				size := min(v.VirtualSize, v.Size)
+				if b, ok, err := readSection(v, imageBase+uint64(v.VirtualAddress), uint64(size), addr, n); ok {
+					return b, err
+				}
+			}
+			return nil, fmt.Errorf("address %#x is not in the binary", addr)
+		},
+	}, nil
+}
+
+// ReadStringVar returns the value of the string variable 'name' (like 'main.version') in the Go binary in 'r', which is read from the
+// binary's symbol table and data. It returns ErrorNoStringVar if the binary has no symbol for the variable.
+func ReadStringVar(r io.ReaderAt, name string) (string, error) {
+	exe, err := openExecutable(r)
+	if err != nil {
+		return "", err
+	}
+
+	addr, ok := exe.symbol(name)
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", ErrorNoStringVar, name)
+	}
+
+	// A string is a pointer to the data and a length.
+	header, err := exe.read(addr, exe.ptrSize*2)
+	if err != nil {
+		return "", err
+	}
+	var data, length uint64
+	if exe.ptrSize == 4 {
+		data, length = uint64(exe.order.Uint32(header)), uint64(exe.order.Uint32(header[4:]))
+	} else {
+		data, length = exe.order.Uint64(header), exe.order.Uint64(header[8:])
+	}
+	if length == 0 {
+		return "", nil
+	}
+	if length > 1<<20 {
+		return "", fmt.Errorf("'%s' is too long", name)
+	}
+
+	b, err := exe.read(data, int(length))
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/golang"
+)
+
+const (
+	// GoTestSumVersion is the version of gotestsum used to produce JUnit reports from 'go test -json'.
+	GoTestSumVersion = "v1.12.1"
+
+	// TestReportsDir is where the JUnit, coverage, and 'go test -json' reports are written in the test container.
+	TestReportsDir = "/src/test-reports"
+)
+
+var DefaultTestPackages = []string{"./pkg/..."}
+
+var ErrorInvalidShard = errors.New("invalid shard; expected the format '{index}/{total}', like '1/4'")
+
+// TestOpts are options that change which Go tests are ran and how.
+type TestOpts struct {
+	// Packages are the package patterns provided to 'go test', like './pkg/...'.
+	Packages []string
+	// Short sets the '-short' flag.
+	Short bool
+	// Run sets the '-run' flag when not empty.
+	Run string
+	// Tags are the go build tags, like 'oss' or 'enterprise'.
+	Tags    []string
+	Timeout string
+
+	// Shard and Shards split the list of packages into 'Shards' groups and only tests the group 'Shard' (1-indexed).
+	// If Shards is 0 or 1 then all packages are tested.
+	Shard  int
+	Shards int
+}
+
+// ParseShard parses a shard string like '1/4' into its index and total.
+// An empty string is treated as "no sharding".
+func ParseShard(s string) (int, int, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	p := strings.Split(s, "/")
+	if len(p) != 2 {
+		return 0, 0, fmt.Errorf("%w: '%s'", ErrorInvalidShard, s)
+	}
+
+	shard, err := strconv.Atoi(p[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: '%s'", ErrorInvalidShard, s)
+	}
+	shards, err := strconv.Atoi(p[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: '%s'", ErrorInvalidShard, s)
+	}
+
+	if shards < 1 || shard < 1 || shard > shards {
+		return 0, 0, fmt.Errorf("%w: '%s'", ErrorInvalidShard, s)
+	}
+
+	return shard, shards, nil
+}
+
+// ID returns a short string which is unique for each combination of options that changes which tests are ran.
+// It's used in the artifact filename so that different test runs are not confused with one another.
+func (o *TestOpts) ID() string {
+	parts := []string{}
+	if o.Short {
+		parts = append(parts, "short")
+	}
+	if o.Shards > 1 {
+		parts = append(parts, fmt.Sprintf("shard-%d-of-%d", o.Shard, o.Shards))
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s", strings.Join(o.Packages, ","), o.Run, strings.Join(o.Tags, ","), o.Timeout)
+	parts = append(parts, fmt.Sprintf("%x", h.Sum(nil))[:8])
+
+	return strings.Join(parts, "-")
+}
+
+// TestScript returns the shell script that runs 'go test' using gotestsum with the given options.
+// gotestsum writes the JUnit report and the raw 'go test -json' output; the coverage profile is written by 'go test'.
+// The script exits with a non-zero exit code if any test fails.
+func TestScript(opts *TestOpts) string {
+	packages := opts.Packages
+	if len(packages) == 0 {
+		packages = DefaultTestPackages
+	}
+
+	// 'go list' runs on its own so that 'set -e' stops the script if it fails. In a pipeline, only the exit code of the last command is
+	// checked, so a failing 'go list' would leave no packages to test and the script would succeed.
+	list := fmt.Sprintf("PACKAGES=$(go list -tags=%s %s)", strings.Join(opts.Tags, ","), strings.Join(packages, " "))
+	shard := ""
+	if opts.Shards > 1 {
+		shard = fmt.Sprintf(`PACKAGES=$(echo "$PACKAGES" | awk -v total=%d -v shard=%d '(NR - 1) %% total == shard - 1')`, opts.Shards, opts.Shard)
+	}
+
+	args := []string{
+		fmt.Sprintf("-tags=%s", strings.Join(opts.Tags, ",")),
+		"-covermode=atomic",
+		fmt.Sprintf("-coverprofile=%s", path.Join(TestReportsDir, "coverage.out")),
+	}
+	if opts.Short {
+		args = append(args, "-short")
+	}
+	if opts.Run != "" {
+		args = append(args, "-run="+shellQuote(opts.Run))
+	}
+	if opts.Timeout != "" {
+		args = append(args, fmt.Sprintf("-timeout=%s", opts.Timeout))
+	}
+
+	lines := []string{
+		"set -e",
+		fmt.Sprintf("mkdir -p %s", TestReportsDir),
+		list,
+	}
+	if shard != "" {
+		lines = append(lines, shard)
+	}
+
+	return strings.Join(append(lines,
+		`if [ -z "$PACKAGES" ]; then echo "no packages to test"; exit 0; fi`,
+		fmt.Sprintf("gotestsum --format=testname --junitfile=%s --jsonfile=%s -- %s $PACKAGES",
+			path.Join(TestReportsDir, "junit.xml"),
+			path.Join(TestReportsDir, "test.json"),
+			strings.Join(args, " "),
+		),
+	), "\n")
+}
+
+// shellQuote quotes 's' in single quotes so that the shell passes it to the command as one argument, even if it has quotes or spaces.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// GoTestSum returns the gotestsum binary at GoTestSumVersion, built with the Go toolchain for 'goVersion'. It is built in its own container
+// so that it is cached like an image layer instead of being downloaded and built again every time the tests run.
+func GoTestSum(d *dagger.Client, platform dagger.Platform, goVersion string) *dagger.File {
+	return golang.Container(d, platform, goVersion).
+		WithEnvVariable("CGO_ENABLED", "0").
+		WithEnvVariable("GOBIN", "/gotestsum").
+		WithExec([]string{"go", "install", "gotest.tools/gotestsum@" + GoTestSumVersion}).
+		File("/gotestsum/gotestsum")
+}
+
+// Test runs the Go tests in the provided builder (see 'Builder') and returns the directory of test reports.
+// The builder should be a container created using 'Builder'; it already has the Go dependencies downloaded and cached.
+// 'gotestsum' is the gotestsum binary (see 'GoTestSum'); it should be built for the platform of the builder and not for the target distribution.
+func Test(builder *dagger.Container, gotestsum *dagger.File, src *dagger.Directory, opts *TestOpts) *dagger.Directory {
+	return builder.
+		WithFile("/usr/local/bin/gotestsum", gotestsum, dagger.ContainerWithFileOpts{Permissions: 0o755}).
+		WithDirectory("/src/conf", src.Directory("conf")).
+		WithDirectory("/src/public", src.Directory("public"), dagger.ContainerWithDirectoryOpts{
+			// Backend tests only read the plugin.json files, templates, and other static files in public.
+			Exclude: []string{"build", "**/*.ts", "**/*.tsx", "**/*.scss"},
+		}).
+		WithExec([]string{"/bin/sh", "-c", TestScript(opts)}).
+		Directory(TestReportsDir)
+}
@@ -0,0 +1,91 @@
+package backend_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-build/backend"
+)
+
+func TestParseShard(t *testing.T) {
+	t.Run("It should parse valid shards", func(t *testing.T) {
+		shard, shards, err := backend.ParseShard("2/4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shard != 2 || shards != 4 {
+			t.Fatalf("expected 2/4, got %d/%d", shard, shards)
+		}
+	})
+	t.Run("It should treat an empty string as no sharding", func(t *testing.T) {
+		shard, shards, err := backend.ParseShard("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shard != 0 || shards != 0 {
+			t.Fatalf("expected 0/0, got %d/%d", shard, shards)
+		}
+	})
+	t.Run("It should reject invalid shards", func(t *testing.T) {
+		for _, v := range []string{"1", "0/4", "5/4", "a/b", "1/0", "1/2/3"} {
+			if _, _, err := backend.ParseShard(v); !errors.Is(err, backend.ErrorInvalidShard) {
+				t.Errorf("expected ErrorInvalidShard for '%s', got '%v'", v, err)
+			}
+		}
+	})
+}
+
+func TestTestOptsID(t *testing.T) {
+	a := &backend.TestOpts{Packages: []string{"./pkg/..."}, Short: true, Shard: 1, Shards: 2}
+	b := &backend.TestOpts{Packages: []string{"./pkg/..."}, Short: true, Shard: 2, Shards: 2}
+	c := &backend.TestOpts{Packages: []string{"./pkg/..."}, Short: true, Shard: 1, Shards: 2, Run: "Integration"}
+
+	if a.ID() == b.ID() {
+		t.Errorf("different shards should have different IDs")
+	}
+	if a.ID() == c.ID() {
+		t.Errorf("different run filters should have different IDs")
+	}
+	if !strings.HasPrefix(a.ID(), "short-shard-1-of-2-") {
+		t.Errorf("unexpected ID '%s'", a.ID())
+	}
+}
+
+func TestTestScript(t *testing.T) {
+	script := backend.TestScript(&backend.TestOpts{
+		Packages: []string{"./pkg/api/..."},
+		Short:    true,
+		Run:      "TestIntegration",
+		Tags:     []string{"oss"},
+		Shard:    1,
+		Shards:   3,
+	})
+
+	for _, v := range []string{
+		"go list -tags=oss ./pkg/api/...",
+		"(NR - 1) % total == shard - 1",
+		"-short",
+		"-run='TestIntegration'",
+		"--junitfile=/src/test-reports/junit.xml",
+		"--jsonfile=/src/test-reports/test.json",
+		"-coverprofile=/src/test-reports/coverage.out",
+	} {
+		if !strings.Contains(script, v) {
+			t.Errorf("expected script to contain '%s', script:\n%s", v, script)
+		}
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		if strings.Contains(line, "go list") && strings.Contains(line, "|") {
+			t.Errorf("expected 'go list' not to be in a pipeline so that its errors stop the script, line: %s", line)
+		}
+	}
+}
+
+func TestTestScriptQuotesRun(t *testing.T) {
+	script := backend.TestScript(&backend.TestOpts{Run: "Test'Quoted (a|b)"})
+	if !strings.Contains(script, `-run='Test'\''Quoted (a|b)'`) {
+		t.Fatalf("expected the run filter to be quoted, script:\n%s", script)
+	}
+}
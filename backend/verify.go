@@ -0,0 +1,287 @@
+package backend
+
+import (
+	"context"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+var (
+	ErrorBinaryMismatch  = errors.New("binary does not match the distribution")
+	ErrorBinaryMissing   = errors.New("expected binary is missing")
+	ErrorVersionMismatch = errors.New("binary version or commit does not match the build")
+)
+
+var elfMachines = map[string]elf.Machine{
+	"386":      elf.EM_386,
+	"amd64":    elf.EM_X86_64,
+	"arm":      elf.EM_ARM,
+	"arm64":    elf.EM_AARCH64,
+	"loong64":  elf.EM_LOONGARCH,
+	"mips":     elf.EM_MIPS,
+	"mipsle":   elf.EM_MIPS,
+	"mips64":   elf.EM_MIPS,
+	"mips64le": elf.EM_MIPS,
+	"ppc64":    elf.EM_PPC64,
+	"ppc64le":  elf.EM_PPC64,
+	"riscv64":  elf.EM_RISCV,
+	"s390x":    elf.EM_S390,
+}
+
+var peMachines = map[string]uint16{
+	"386":   pe.IMAGE_FILE_MACHINE_I386,
+	"amd64": pe.IMAGE_FILE_MACHINE_AMD64,
+	"arm":   pe.IMAGE_FILE_MACHINE_ARMNT,
+	"arm64": pe.IMAGE_FILE_MACHINE_ARM64,
+}
+
+var machoCPUs = map[string]macho.Cpu{
+	"amd64": macho.CpuAmd64,
+	"arm64": macho.CpuArm64,
+}
+
+func mismatch(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrorBinaryMismatch, fmt.Sprintf(format, args...))
+}
+
+// CheckBinary checks that the executable in 'r' targets the OS and architecture of 'distro'.
+// If 'static' is true, then Linux / BSD binaries must not request a dynamic interpreter.
+func CheckBinary(r io.ReaderAt, distro Distribution, static bool) error {
+	os, arch := OSAndArch(distro)
+
+	switch os {
+	case "windows":
+		f, err := pe.NewFile(r)
+		if err != nil {
+			return mismatch("expected a PE executable for '%s': %s", distro, err)
+		}
+		defer f.Close()
+
+		if m, ok := peMachines[arch]; ok && f.Machine != m {
+			return mismatch("expected PE machine %#x for '%s', got %#x", m, distro, f.Machine)
+		}
+	case "darwin", "ios":
+		f, err := macho.NewFile(r)
+		if err != nil {
+			return mismatch("expected a Mach-O executable for '%s': %s", distro, err)
+		}
+		defer f.Close()
+
+		if cpu, ok := machoCPUs[arch]; ok && f.Cpu != cpu {
+			return mismatch("expected Mach-O CPU '%s' for '%s', got '%s'", cpu, distro, f.Cpu)
+		}
+	default:
+		f, err := elf.NewFile(r)
+		if err != nil {
+			return mismatch("expected an ELF executable for '%s': %s", distro, err)
+		}
+		defer f.Close()
+
+		if err := checkELF(f, os, arch); err != nil {
+			return err
+		}
+
+		if !static {
+			return nil
+		}
+		for _, p := range f.Progs {
+			if p.Type == elf.PT_INTERP {
+				return mismatch("static binary for '%s' has a dynamic interpreter", distro)
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkELF(f *elf.File, os, arch string) error {
+	if m, ok := elfMachines[arch]; ok && f.Machine != m {
+		return mismatch("expected ELF machine '%s' for '%s/%s', got '%s'", m, os, arch, f.Machine)
+	}
+
+	if strings.HasSuffix(arch, "le") && f.ByteOrder != binary.LittleEndian {
+		return mismatch("expected a little-endian ELF for '%s/%s'", os, arch)
+	}
+
+	if strings.HasPrefix(arch, "mips64") || arch == "ppc64" || arch == "s390x" || arch == "mips" {
+		if !strings.HasSuffix(arch, "le") && f.ByteOrder != binary.BigEndian {
+			return mismatch("expected a big-endian ELF for '%s/%s'", os, arch)
+		}
+	}
+
+	is64 := f.Class == elf.ELFCLASS64
+	if want := !slices.Contains([]string{"386", "arm", "mips", "mipsle"}, arch); is64 != want {
+		return mismatch("unexpected ELF class '%s' for '%s/%s'", f.Class, os, arch)
+	}
+
+	// Go sets the OSABI for FreeBSD binaries; Linux binaries use the generic (SYSV) ABI.
+	if os == "freebsd" && f.OSABI != elf.ELFOSABI_FREEBSD {
+		return mismatch("expected FreeBSD ELF OS/ABI, got '%s'", f.OSABI)
+	}
+	if os == "linux" && f.OSABI != elf.ELFOSABI_NONE && f.OSABI != elf.ELFOSABI_LINUX {
+		return mismatch("expected Linux ELF OS/ABI, got '%s'", f.OSABI)
+	}
+
+	return nil
+}
+
+// ExpectedBinaries returns the file names of the binaries that should be built for 'distro' from a source tree whose 'pkg/cmd' folder
+// has the given entries.
+func ExpectedBinaries(cmds []string, distro Distribution) []string {
+	expected := []string{}
+	for _, v := range Binaries {
+		if !slices.Contains(cmds, v) {
+			continue
+		}
+		if IsWindows(distro) {
+			v += ".exe"
+		}
+		expected = append(expected, v)
+	}
+
+	return expected
+}
+
+var versionOutputRegex = regexp.MustCompile(`Version (\S+) \(commit: ([^,\s]*), branch: ([^)]*)\)`)
+
+// ParseVersionOutput parses the output of 'grafana server -v', like 'Version 11.1.0 (commit: abcdef, branch: main)'.
+func ParseVersionOutput(out string) (string, string, error) {
+	m := versionOutputRegex.FindStringSubmatch(out)
+	if m == nil {
+		return "", "", fmt.Errorf("%w: unrecognized version output '%s'", ErrorVersionMismatch, strings.TrimSpace(out))
+	}
+
+	return m[1], m[2], nil
+}
+
+// CheckVersion checks that the version and commit of the build match what was embedded in the binary.
+func CheckVersion(version, commit, expectVersion, expectCommit string) error {
+	expectVersion = strings.TrimPrefix(expectVersion, "v")
+	if version != expectVersion {
+		return fmt.Errorf("%w: expected version '%s', got '%s'", ErrorVersionMismatch, expectVersion, version)
+	}
+	if commit != expectCommit {
+		return fmt.Errorf("%w: expected commit '%s', got '%s'", ErrorVersionMismatch, expectCommit, commit)
+	}
+
+	return nil
+}
+
+// verifyImage returns the image used to run the binaries under emulation; binaries that are dynamically linked against glibc
+// can't run in alpine.
+func verifyImage(distro Distribution) string {
+	if strings.HasSuffix(string(distro), "/dynamic") {
+		return "debian:bookworm-slim"
+	}
+
+	return "alpine:3.18.4"
+}
+
+// Verify checks the binaries in 'bin' that were built from 'src' for 'distro'. It checks that:
+// * every expected binary is present,
+// * every binary targets the OS / architecture of the distribution (and has no dynamic interpreter if it's static),
+// * the version and commit embedded in the binary match the build.
+// The version and commit are read from the Go build info or the symbol table of every binary (see CheckBuildInfo). For Linux distributions,
+// they are also read by running 'grafana server -v' (emulated, if necessary), which is the only check for stripped static binaries.
+func Verify(ctx context.Context, d *dagger.Client, bin *dagger.Directory, src *dagger.Directory, distro Distribution, opts *BuildOpts) error {
+	cmds, err := src.Entries(ctx, dagger.DirectoryEntriesOpts{Path: "pkg/cmd"})
+	if err != nil {
+		return err
+	}
+
+	commit, err := src.File(".buildinfo.commit").Contents(ctx)
+	if err != nil {
+		return err
+	}
+	commit = strings.TrimSpace(commit)
+
+	dir, err := os.MkdirTemp("", "grafana-bin-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := bin.Export(ctx, dir); err != nil {
+		return err
+	}
+
+	expected := ExpectedBinaries(cmds, distro)
+	for _, v := range expected {
+		if err := verifyBinaryFile(filepath.Join(dir, v), distro, opts.Static); err != nil {
+			return fmt.Errorf("%s: %w", v, err)
+		}
+	}
+
+	// There's no way to run binaries for other OSes and riscv64, so the values that were set with '-X' are only checked in the build info
+	// for them.
+	goos, arch := OSAndArch(distro)
+	runnable := goos == "linux" && arch != "riscv64"
+	for _, v := range expected {
+		err := verifyBuildInfo(filepath.Join(dir, v), opts.Version, commit)
+		// Static builds are stripped with '-s', so the values can only be checked by running the binary.
+		if err != nil && !(runnable && errors.Is(err, ErrorNoStringVar)) {
+			return fmt.Errorf("%s: %w", v, err)
+		}
+	}
+
+	if !runnable {
+		return nil
+	}
+
+	cmd := []string{"./grafana-server", "-v"}
+	if slices.Contains(expected, "grafana") {
+		cmd = []string{"./grafana", "server", "-v"}
+	}
+
+	out, err := d.Container(dagger.ContainerOpts{Platform: Platform(distro)}).
+		From(verifyImage(distro)).
+		WithMountedDirectory("/src/bin", bin).
+		WithWorkdir("/src/bin").
+		WithExec(cmd).
+		Stdout(ctx)
+	if err != nil {
+		return err
+	}
+
+	version, binCommit, err := ParseVersionOutput(out)
+	if err != nil {
+		return err
+	}
+
+	return CheckVersion(version, binCommit, opts.Version, commit)
+}
+
+func verifyBinaryFile(path string, distro Distribution, static bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrorBinaryMissing
+		}
+		return err
+	}
+	defer f.Close()
+
+	return CheckBinary(f, distro, static)
+}
+
+func verifyBuildInfo(path, version, commit string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return CheckBuildInfo(f, version, commit)
+}
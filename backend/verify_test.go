@@ -0,0 +1,191 @@
+package backend_test
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/grafana/grafana-build/backend"
+)
+
+func TestCheckBinary(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the test binary is only an ELF file on Linux")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := backend.CheckBinary(f, backend.Distribution("linux/"+runtime.GOARCH), false); err != nil {
+		t.Fatalf("expected the test binary to match linux/%s, got '%v'", runtime.GOARCH, err)
+	}
+
+	for _, v := range []backend.Distribution{backend.DistWindowsAMD64, backend.DistDarwinARM64, backend.DistLinuxS390X, backend.DistLinuxARMv7} {
+		if string(v) == "linux/"+runtime.GOARCH {
+			continue
+		}
+		if err := backend.CheckBinary(f, v, false); !errors.Is(err, backend.ErrorBinaryMismatch) {
+			t.Fatalf("expected ErrorBinaryMismatch for '%s', got '%v'", v, err)
+		}
+	}
+
+	t.Run("static binaries should not have an interpreter", func(t *testing.T) {
+		e, err := elf.NewFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hasInterp := false
+		for _, p := range e.Progs {
+			if p.Type == elf.PT_INTERP {
+				hasInterp = true
+			}
+		}
+
+		err = backend.CheckBinary(f, backend.Distribution("linux/"+runtime.GOARCH), true)
+		if hasInterp && !errors.Is(err, backend.ErrorBinaryMismatch) {
+			t.Fatalf("expected ErrorBinaryMismatch for a dynamically linked binary, got '%v'", err)
+		}
+		if !hasInterp && err != nil {
+			t.Fatalf("expected no error for a statically linked binary, got '%v'", err)
+		}
+	})
+}
+
+func TestExpectedBinaries(t *testing.T) {
+	// grafana-example-apiserver doesn't exist in older versions of Grafana
+	cmds := []string{"grafana", "grafana-cli", "grafana-server", "grafana-cli-unrelated"}
+
+	linux := backend.ExpectedBinaries(cmds, backend.DistLinuxAMD64)
+	if len(linux) != 3 || linux[0] != "grafana" || linux[2] != "grafana-cli" {
+		t.Fatalf("unexpected binaries for linux: %v", linux)
+	}
+
+	windows := backend.ExpectedBinaries(cmds, backend.DistWindowsAMD64)
+	if len(windows) != 3 || windows[0] != "grafana.exe" {
+		t.Fatalf("unexpected binaries for windows: %v", windows)
+	}
+}
+
+func TestParseVersionOutput(t *testing.T) {
+	version, commit, err := backend.ParseVersionOutput("Version 11.1.0-pre (commit: 8f2c7e8d, branch: main)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "11.1.0-pre" || commit != "8f2c7e8d" {
+		t.Fatalf("unexpected version / commit: '%s', '%s'", version, commit)
+	}
+
+	if _, _, err := backend.ParseVersionOutput("grafana version 11.1.0"); !errors.Is(err, backend.ErrorVersionMismatch) {
+		t.Fatalf("expected ErrorVersionMismatch, got '%v'", err)
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	if err := backend.CheckVersion("11.1.0", "abc", "v11.1.0", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.CheckVersion("11.1.0", "abc", "v11.1.1", "abc"); !errors.Is(err, backend.ErrorVersionMismatch) {
+		t.Fatalf("expected ErrorVersionMismatch for a different version, got '%v'", err)
+	}
+	if err := backend.CheckVersion("11.1.0", "abc", "v11.1.0", "def"); !errors.Is(err, backend.ErrorVersionMismatch) {
+		t.Fatalf("expected ErrorVersionMismatch for a different commit, got '%v'", err)
+	}
+}
+
+func TestParseLDFlagsX(t *testing.T) {
+	x := backend.ParseLDFlagsX(`-w -s -X "main.version=11.1.0" -X 'main.commit=abc def' -X=main.buildBranch=main -extldflags "-static"`)
+	for k, v := range map[string]string{"main.version": "11.1.0", "main.commit": "abc def", "main.buildBranch": "main"} {
+		if x[k] != v {
+			t.Fatalf("expected '%s' for '%s', got '%s'", v, k, x[k])
+		}
+	}
+	if len(x) != 3 {
+		t.Fatalf("expected 3 values, got %v", x)
+	}
+}
+
+// buildMain builds a main package that has the 'version' and 'commit' variables for 'goos' and 'goarch' with 'ldflags', the same way that
+// the Grafana binaries are built with '-trimpath', and returns the path of the binary.
+func buildMain(t *testing.T, goos, goarch, ldflags string) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("this test needs the go command")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/grafana\n\ngo 1.21\n",
+		"main.go": "package main\n\nvar version, commit string\n\nfunc main() { println(version, commit) }\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(dir, "grafana")
+	cmd := exec.Command(goBin, "build", "-trimpath", "-ldflags="+ldflags, "-o="+out, ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0", "GOFLAGS=")
+	if b, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("error building the test binary: %s\n%s", err, b)
+	}
+
+	return out
+}
+
+func TestCheckBuildInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("this test builds binaries")
+	}
+
+	check := func(t *testing.T, path, version, commit string) error {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		return backend.CheckBuildInfo(f, version, commit)
+	}
+
+	for _, v := range [][2]string{{"linux", "amd64"}, {"linux", "386"}, {"windows", "amd64"}, {"darwin", "arm64"}} {
+		t.Run(fmt.Sprintf("The values set with -X should be read from %s/%s binaries", v[0], v[1]), func(t *testing.T) {
+			bin := buildMain(t, v[0], v[1], `-X "main.version=11.1.0" -X "main.commit=abc"`)
+			if err := check(t, bin, "v11.1.0", "abc"); err != nil {
+				t.Fatal(err)
+			}
+			if err := check(t, bin, "v11.1.0", "ab"); !errors.Is(err, backend.ErrorVersionMismatch) {
+				t.Fatalf("expected ErrorVersionMismatch for a commit that is only part of the binary's, got '%v'", err)
+			}
+		})
+	}
+
+	t.Run("A binary without the values should be an error", func(t *testing.T) {
+		bin := buildMain(t, "linux", "amd64", "")
+		if err := check(t, bin, "v11.1.0", "abc"); !errors.Is(err, backend.ErrorVersionMismatch) {
+			t.Fatalf("expected ErrorVersionMismatch, got '%v'", err)
+		}
+	})
+
+	t.Run("A stripped binary should be an error", func(t *testing.T) {
+		bin := buildMain(t, "linux", "amd64", `-s -w -X "main.version=11.1.0" -X "main.commit=abc"`)
+		if err := check(t, bin, "v11.1.0", "abc"); !errors.Is(err, backend.ErrorNoStringVar) {
+			t.Fatalf("expected ErrorNoStringVar, got '%v'", err)
+		}
+	})
+}
@@ -6,16 +6,28 @@ import (
 
 var Artifacts = map[string]artifacts.Initializer{
 	"backend":           artifacts.BackendInitializer,
+	"backend-test":      artifacts.BackendTestInitializer,
+	"cdn":               artifacts.CDNInitializer,
 	"frontend":          artifacts.FrontendInitializer,
+	"frontend-report":   artifacts.FrontendReportInitializer,
+	"frontend-test":     artifacts.FrontendTestInitializer,
+	"govulncheck":       artifacts.GovulncheckInitializer,
 	"npm":               artifacts.NPMPackagesInitializer,
 	"targz":             artifacts.TargzInitializer,
 	"zip":               artifacts.ZipInitializer,
 	"deb":               artifacts.DebInitializer,
 	"rpm":               artifacts.RPMInitializer,
+	"apk":               artifacts.APKInitializer,
+	"package-lint":      artifacts.PackageLintInitializer,
+	"asc":               artifacts.SignatureInitializer,
+	"apt-repo":          artifacts.AptRepoInitializer,
+	"yum-repo":          artifacts.YumRepoInitializer,
 	"docker":            artifacts.DockerInitializer,
 	"docker-pro":        artifacts.ProDockerInitializer,
 	"docker-enterprise": artifacts.EntDockerInitializer,
 	"storybook":         artifacts.StorybookInitializer,
+	"sbom":              artifacts.SBOMInitializer,
 	"msi":               artifacts.MSIInitializer,
 	"version":           artifacts.VersionInitializer,
+	"yarn-cache":        artifacts.YarnCacheInitializer,
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/grafana/grafana-build/arguments"
 	"github.com/grafana/grafana-build/cmd/flags"
+	"github.com/grafana/grafana-build/frontend"
 	"github.com/urfave/cli/v2"
 )
 
@@ -51,6 +52,29 @@ var NPMFlags = []cli.Flag{
 		Usage:    "Provides the tags to use when publishing packages",
 		Required: true,
 	},
+	&cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Run 'npm publish --dry-run' and don't add any dist-tags",
+	},
+	&cli.BoolFlag{
+		Name:  "provenance",
+		Usage: "Publish with 'npm publish --provenance'. The CI environment (like GitHub Actions' 'GITHUB_*' and 'ACTIONS_*' variables) is passed to npm",
+	},
+	&cli.StringFlag{
+		Name:  "if-published",
+		Usage: "What to do if a package version is already published: 'skip' (only adds the dist-tags) or 'fail'",
+		Value: string(frontend.NPMPublishFail),
+	},
+	&cli.StringFlag{
+		Name:  "node-version",
+		Usage: "The version of node in the container that publishes the packages",
+		Value: "lts",
+	},
+	&cli.StringFlag{
+		Name:  "node-image",
+		Usage: "The image of the container that publishes the packages, like a mirror of the official node image. Every '%s' is replaced with the node version",
+		Value: frontend.DefaultNodeImage,
+	},
 }
 
 // PublishFlags are flags that are used in commands that create artifacts.
@@ -2,6 +2,7 @@ package docker
 
 import (
 	"fmt"
+	"sort"
 
 	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/containers"
@@ -20,6 +21,8 @@ type BuildOpts struct {
 	BuildArgs []string
 	// Set the target build stage to build as '--target'
 	Target string
+	// Labels are provided as '--label' and override the labels in the Dockerfile.
+	Labels map[string]string
 
 	// Platform, if set to the non-default value, will use buildkit's emulation to build the docker image. This can be useful if building a docker image for a platform that doesn't match the host platform.
 	Platform dagger.Platform
@@ -57,6 +60,15 @@ func Build(d *dagger.Client, builder *dagger.Container, opts *BuildOpts) *dagger
 		args = append(args, "-t", v)
 	}
 
+	labels := make([]string, 0, len(opts.Labels))
+	for k := range opts.Labels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		args = append(args, fmt.Sprintf("--label=%s=%s", k, opts.Labels[k]))
+	}
+
 	if opts.Target != "" {
 		args = append(args, "--target", opts.Target)
 	}
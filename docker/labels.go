@@ -0,0 +1,26 @@
+package docker
+
+import (
+	"github.com/grafana/grafana-build/packages"
+)
+
+// Labels returns the OCI annotations of the image from the package 'metadata'. Fields that are empty, like the license of Grafana
+// Enterprise, are left out.
+func Labels(metadata packages.Metadata, version string, enterprise bool) map[string]string {
+	metadata = metadata.For(enterprise)
+	labels := map[string]string{}
+	for k, v := range map[string]string{
+		"org.opencontainers.image.vendor":      metadata.Vendor,
+		"org.opencontainers.image.authors":     metadata.Maintainer,
+		"org.opencontainers.image.url":         metadata.Homepage,
+		"org.opencontainers.image.description": metadata.Description,
+		"org.opencontainers.image.licenses":    metadata.License,
+		"org.opencontainers.image.version":     version,
+	} {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+
+	return labels
+}
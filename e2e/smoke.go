@@ -0,0 +1,210 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+)
+
+const (
+	// DefaultSmokePlugin is a core plugin that every Grafana edition loads.
+	DefaultSmokePlugin = "prometheus"
+
+	EditionOSS        = "Open Source"
+	EditionEnterprise = "Enterprise"
+)
+
+var ErrorSmoke = errors.New("smoke test failed")
+
+// SmokeOpts are the expectations of the smoke test.
+type SmokeOpts struct {
+	// Version is the version that Grafana should report. A leading 'v' is ignored.
+	Version    string
+	Enterprise bool
+	// Plugin is the ID of a built-in plugin that must be loaded. Defaults to DefaultSmokePlugin.
+	Plugin string
+	// User and Password are the credentials to log in with. Default to 'admin'.
+	User     string
+	Password string
+	// Timeout is how long to wait for Grafana to become healthy. Defaults to 2 minutes.
+	Timeout time.Duration
+	// Interval is the time between health checks. Defaults to 1 second.
+	Interval time.Duration
+}
+
+func (o SmokeOpts) withDefaults() SmokeOpts {
+	if o.Plugin == "" {
+		o.Plugin = DefaultSmokePlugin
+	}
+	if o.User == "" {
+		o.User = "admin"
+	}
+	if o.Password == "" {
+		o.Password = "admin"
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 2 * time.Minute
+	}
+	if o.Interval == 0 {
+		o.Interval = time.Second
+	}
+	return o
+}
+
+func (o SmokeOpts) edition() string {
+	if o.Enterprise {
+		return EditionEnterprise
+	}
+	return EditionOSS
+}
+
+type smokeClient struct {
+	client *http.Client
+	url    string
+}
+
+func (c *smokeClient) do(ctx context.Context, method, path string, body any, v any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = strings.NewReader(string(b))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, path, res.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%s %s: error parsing response: %w", method, path, err)
+	}
+
+	return nil
+}
+
+// waitHealthy polls '/api/health' until the database is ok or the timeout is reached.
+func (c *smokeClient) waitHealthy(ctx context.Context, opts SmokeOpts) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var health struct {
+		Database string `json:"database"`
+	}
+	for {
+		err := c.do(ctx, http.MethodGet, "/api/health", nil, &health)
+		if err == nil && health.Database == "ok" {
+			return nil
+		}
+		if err == nil {
+			err = fmt.Errorf("database is '%s'", health.Database)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("grafana did not become healthy in %s: %w", opts.Timeout, err)
+		case <-time.After(opts.Interval):
+		}
+	}
+}
+
+// Smoke runs the smoke test against the Grafana server at 'url', like 'http://localhost:3000':
+// it waits for '/api/health', logs in, checks the version and edition in '/api/frontend/settings', and checks that a built-in plugin is
+// loaded.
+func Smoke(ctx context.Context, url string, opts SmokeOpts) error {
+	opts = opts.withDefaults()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	c := &smokeClient{
+		client: &http.Client{Jar: jar, Timeout: 30 * time.Second},
+		url:    strings.TrimSuffix(url, "/"),
+	}
+
+	if err := c.waitHealthy(ctx, opts); err != nil {
+		return fmt.Errorf("%w: %w", ErrorSmoke, err)
+	}
+
+	login := map[string]string{"user": opts.User, "password": opts.Password}
+	if err := c.do(ctx, http.MethodPost, "/login", login, nil); err != nil {
+		return fmt.Errorf("%w: error logging in as '%s': %w", ErrorSmoke, opts.User, err)
+	}
+
+	var settings struct {
+		BuildInfo struct {
+			Version string `json:"version"`
+			Edition string `json:"edition"`
+		} `json:"buildInfo"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/frontend/settings", nil, &settings); err != nil {
+		return fmt.Errorf("%w: %w", ErrorSmoke, err)
+	}
+	if v := strings.TrimPrefix(opts.Version, "v"); v != "" && settings.BuildInfo.Version != v {
+		return fmt.Errorf("%w: expected version '%s', got '%s'", ErrorSmoke, v, settings.BuildInfo.Version)
+	}
+	if e := opts.edition(); settings.BuildInfo.Edition != e {
+		return fmt.Errorf("%w: expected edition '%s', got '%s'", ErrorSmoke, e, settings.BuildInfo.Edition)
+	}
+
+	var plugin struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/plugins/"+opts.Plugin+"/settings", nil, &plugin); err != nil {
+		return fmt.Errorf("%w: the '%s' plugin is not loaded: %w", ErrorSmoke, opts.Plugin, err)
+	}
+	if plugin.ID != opts.Plugin {
+		return fmt.Errorf("%w: expected the '%s' plugin, got '%s'", ErrorSmoke, opts.Plugin, plugin.ID)
+	}
+
+	return nil
+}
+
+// SmokeService runs the smoke test against the Grafana 'service', which must expose port 3000. The service is tunneled to the host so that
+// the checks run in this process instead of in a container.
+func SmokeService(ctx context.Context, d *dagger.Client, service *dagger.Service, opts SmokeOpts) error {
+	tunnel, err := d.Host().Tunnel(service).Start(ctx)
+	if err != nil {
+		return err
+	}
+	defer tunnel.Stop(ctx)
+
+	url, err := tunnel.Endpoint(ctx, dagger.ServiceEndpointOpts{
+		Scheme: "http",
+	})
+	if err != nil {
+		return err
+	}
+
+	return Smoke(ctx, url, opts)
+}
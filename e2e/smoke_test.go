@@ -0,0 +1,121 @@
+package e2e_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-build/e2e"
+)
+
+// fakeGrafana is a Grafana server that is healthy after 'unhealthy' requests to '/api/health'.
+func fakeGrafana(t *testing.T, unhealthy int, version, edition string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
+		if unhealthy > 0 {
+			unhealthy--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"database": "ok", "version": version})
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Method != http.MethodPost || body["user"] != "admin" || body["password"] != "admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "grafana_session", Value: "session", Path: "/"})
+		json.NewEncoder(w).Encode(map[string]string{"message": "Logged in"})
+	})
+	authorized := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Cookie("grafana_session"); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("/api/frontend/settings", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"buildInfo": map[string]string{"version": version, "edition": edition},
+		})
+	}))
+	mux.HandleFunc("/api/plugins/prometheus/settings", authorized(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"id": "prometheus", "type": "datasource"})
+	}))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSmoke(t *testing.T) {
+	ctx := context.Background()
+	opts := e2e.SmokeOpts{
+		Version:  "v12.0.0",
+		Interval: time.Millisecond,
+	}
+
+	t.Run("It should pass once Grafana is healthy", func(t *testing.T) {
+		srv := fakeGrafana(t, 3, "12.0.0", e2e.EditionOSS)
+		if err := e2e.Smoke(ctx, srv.URL, opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("It should fail if the version is wrong", func(t *testing.T) {
+		srv := fakeGrafana(t, 0, "11.6.0", e2e.EditionOSS)
+		if err := e2e.Smoke(ctx, srv.URL, opts); !errors.Is(err, e2e.ErrorSmoke) {
+			t.Fatalf("expected a smoke test error, got %v", err)
+		}
+	})
+	t.Run("It should fail if the edition is wrong", func(t *testing.T) {
+		srv := fakeGrafana(t, 0, "12.0.0", e2e.EditionOSS)
+		o := opts
+		o.Enterprise = true
+		if err := e2e.Smoke(ctx, srv.URL, o); !errors.Is(err, e2e.ErrorSmoke) {
+			t.Fatalf("expected a smoke test error, got %v", err)
+		}
+	})
+	t.Run("It should fail if the plugin is not loaded", func(t *testing.T) {
+		srv := fakeGrafana(t, 0, "12.0.0", e2e.EditionOSS)
+		o := opts
+		o.Plugin = "loki"
+		if err := e2e.Smoke(ctx, srv.URL, o); !errors.Is(err, e2e.ErrorSmoke) {
+			t.Fatalf("expected a smoke test error, got %v", err)
+		}
+	})
+	t.Run("It should fail if Grafana never becomes healthy", func(t *testing.T) {
+		srv := fakeGrafana(t, 1000, "12.0.0", e2e.EditionOSS)
+		o := opts
+		o.Timeout = 50 * time.Millisecond
+		if err := e2e.Smoke(ctx, srv.URL, o); !errors.Is(err, e2e.ErrorSmoke) {
+			t.Fatalf("expected a smoke test error, got %v", err)
+		}
+	})
+}
+
+func TestParseMode(t *testing.T) {
+	for in, expected := range map[string]e2e.Mode{
+		"":         e2e.ModeNone,
+		"false":    e2e.ModeNone,
+		"true":     e2e.ModeE2E,
+		"e2e":      e2e.ModeE2E,
+		"e2e-node": e2e.ModeE2ENode,
+		"smoke":    e2e.ModeSmoke,
+	} {
+		if mode, err := e2e.ParseMode(in); err != nil || mode != expected {
+			t.Fatalf("expected '%s' to be '%s', got '%s' (%v)", in, expected, mode, err)
+		}
+	}
+	if _, err := e2e.ParseMode("cypress"); !errors.Is(err, e2e.ErrorInvalidMode) {
+		t.Fatalf("expected an invalid mode, got %v", err)
+	}
+}
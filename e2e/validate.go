@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/containers"
+	"github.com/grafana/grafana-build/frontend"
+)
+
+// Mode is how packages are verified with '--verify'.
+type Mode string
+
+const (
+	// ModeNone doesn't verify packages.
+	ModeNone Mode = ""
+	// ModeE2E runs the cypress 'verify-release' script from the Grafana source tree in the CypressImage.
+	ModeE2E Mode = "e2e"
+	// ModeE2ENode runs the same script as ModeE2E, but in a node container with the node setup of the frontend build instead of in the
+	// CypressImage. Cypress and its browser are installed when the tests run.
+	ModeE2ENode Mode = "e2e-node"
+	// ModeSmoke runs the smoke test in Go (see Smoke), which doesn't need the source tree or cypress.
+	ModeSmoke Mode = "smoke"
+)
+
+var ErrorInvalidMode = errors.New("invalid verification mode; expected 'smoke', 'e2e', or 'e2e-node'")
+
+// ParseMode parses the value of '--verify'. 'true' is the same as 'e2e' and 'false' is the same as not verifying.
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "", "false":
+		return ModeNone, nil
+	case "true", string(ModeE2E):
+		return ModeE2E, nil
+	case string(ModeE2ENode):
+		return ModeE2ENode, nil
+	case string(ModeSmoke):
+		return ModeSmoke, nil
+	}
+
+	return ModeNone, fmt.Errorf("%w: '%s'", ErrorInvalidMode, s)
+}
+
+// ValidateOpts are the options for Validate. Src and YarnCache are only used by ModeE2E and ModeE2ENode, Node is only used by ModeE2ENode,
+// and Smoke is only used by ModeSmoke.
+type ValidateOpts struct {
+	Mode      Mode
+	Src       *dagger.Directory
+	YarnCache *dagger.CacheVolume
+	// Node is the node setup of the frontend build, which the e2e tests run with. If it's nil, the version from '.nvmrc' in Src is used.
+	Node  *frontend.Node
+	Smoke SmokeOpts
+}
+
+// Validate verifies the Grafana 'service' with the e2e tests or with the smoke test, depending on the mode. The service must expose port
+// 3000.
+func Validate(ctx context.Context, d *dagger.Client, service *dagger.Service, opts ValidateOpts) error {
+	if opts.Mode == ModeSmoke {
+		return SmokeService(ctx, d, service, opts.Smoke)
+	}
+
+	nodeVersion, err := frontend.NodeVersionFromSource(ctx, opts.Src)
+	if err != nil {
+		return err
+	}
+	if opts.Mode != ModeE2ENode {
+		_, err = containers.ExitError(ctx, ValidatePackage(d, service, opts.Src, opts.YarnCache, CypressContainer(d, CypressImage(nodeVersion))))
+		return err
+	}
+
+	node := opts.Node
+	if node == nil {
+		node = &frontend.Node{Version: nodeVersion, SourceVersion: nodeVersion}
+	}
+
+	_, err = containers.ExitError(ctx, ValidatePackage(d, service, opts.Src, opts.YarnCache, CypressNodeContainer(d, node)))
+	return err
+}
@@ -5,10 +5,25 @@ import (
 	"github.com/grafana/grafana-build/frontend"
 )
 
+// CypressImage has cypress and its browsers already installed, so the e2e tests don't download them.
 func CypressImage(version string) string {
 	return "cypress/included:13.1.0"
 }
 
+// CypressDependencies are the Debian packages that cypress needs to run, from https://docs.cypress.io/guides/getting-started/installing-cypress#Linux-Prerequisites.
+var CypressDependencies = []string{
+	"libgtk2.0-0",
+	"libgtk-3-0",
+	"libgbm-dev",
+	"libnotify-dev",
+	"libnss3",
+	"libxss1",
+	"libasound2",
+	"libxtst6",
+	"xauth",
+	"xvfb",
+}
+
 // CypressContainer returns a docker container with everything set up that is needed to build or run e2e tests.
 func CypressContainer(d *dagger.Client, base string) *dagger.Container {
 	container := d.Container().From(base).WithEntrypoint([]string{})
@@ -16,10 +31,17 @@ func CypressContainer(d *dagger.Client, base string) *dagger.Container {
 	return container
 }
 
-func ValidatePackage(d *dagger.Client, service *dagger.Service, src *dagger.Directory, yarnCacheVolume *dagger.CacheVolume, nodeVersion string) *dagger.Container {
-	// The cypress container should never be cached
-	c := CypressContainer(d, CypressImage(nodeVersion))
+// CypressNodeContainer returns a node container (see 'frontend.NodeContainer') with the system packages that cypress needs. It uses the same
+// node version, image, and tarball as the frontend build; cypress itself and its browser are installed by 'yarn install' when the tests run.
+func CypressNodeContainer(d *dagger.Client, node *frontend.Node) *dagger.Container {
+	return frontend.NodeContainer(d, node, "").
+		WithExec(append([]string{"apt-get", "install", "-yq"}, CypressDependencies...)).
+		WithEntrypoint([]string{})
+}
 
+// ValidatePackage runs the 'verify-release' script from 'src' in the cypress container 'c' against the Grafana 'service'.
+func ValidatePackage(d *dagger.Client, service *dagger.Service, src *dagger.Directory, yarnCacheVolume *dagger.CacheVolume, c *dagger.Container) *dagger.Container {
+	// The cypress container should never be cached
 	c = frontend.WithYarnCache(c, yarnCacheVolume)
 
 	return c.WithDirectory("/src", src).
@@ -0,0 +1,38 @@
+package flags
+
+import (
+	"github.com/grafana/grafana-build/frontend"
+	"github.com/grafana/grafana-build/pipeline"
+)
+
+const (
+	// FrontendScript is the package.json script used to build the frontend. It takes precedence over '--frontend-build-script'.
+	FrontendScript pipeline.FlagOption = "frontend-script"
+	// FrontendSourcemaps is the sourcemap policy for the frontend build. It takes precedence over '--frontend-sourcemaps'.
+	FrontendSourcemaps pipeline.FlagOption = "frontend-sourcemaps"
+)
+
+// FrontendVariantFlags select a frontend build variant. They can be used on the 'frontend' artifact, or on packages to select which variant is
+// embedded in the package.
+var FrontendVariantFlags = []pipeline.Flag{
+	{
+		// A development build; unminified and with sourcemaps, for debugging.
+		Name: "frontend-dev",
+		Options: map[pipeline.FlagOption]any{
+			FrontendScript:     "dev",
+			FrontendSourcemaps: string(frontend.SourcemapsKeep),
+		},
+	},
+	{
+		Name: "no-sourcemaps",
+		Options: map[pipeline.FlagOption]any{
+			FrontendSourcemaps: string(frontend.SourcemapsRemove),
+		},
+	},
+	{
+		Name: "hidden-sourcemaps",
+		Options: map[pipeline.FlagOption]any{
+			FrontendSourcemaps: string(frontend.SourcemapsHidden),
+		},
+	},
+}
@@ -20,6 +20,15 @@ const (
 	GoExperiments pipeline.FlagOption = "go-experiments"
 	Sign          pipeline.FlagOption = "sign"
 
+	// WithSBOM embeds the SPDX and CycloneDX SBOMs in the package (in the 'sbom' folder of the tar.gz).
+	WithSBOM pipeline.FlagOption = "with-sbom"
+	// SBOMFromTarball creates the SBOM from a built tar.gz instead of from the backend and frontend artifacts.
+	SBOMFromTarball pipeline.FlagOption = "sbom-from-targz"
+	// PackageLintType is the type of package ('deb' or 'rpm') that the 'package-lint' artifact lints.
+	PackageLintType pipeline.FlagOption = "package-lint-type"
+	// SignatureType is the type of package ('targz', 'zip', or 'msi') that the 'asc' artifact signs.
+	SignatureType pipeline.FlagOption = "signature-type"
+
 	// Pretty much only used to set the deb or RPM internal package name (and file name) to `{}-nightly` and/or `{}-rpi`
 	Nightly pipeline.FlagOption = "nightly"
 	RPI     pipeline.FlagOption = "rpi"
@@ -88,6 +97,59 @@ var NightlyFlag = pipeline.Flag{
 	},
 }
 
+// WithSBOMFlag can't be named 'sbom' because that is the name of the 'sbom' artifact.
+var WithSBOMFlag = pipeline.Flag{
+	Name: "with-sbom",
+	Options: map[pipeline.FlagOption]any{
+		WithSBOM: true,
+	},
+}
+
+var SBOMFromTarballFlag = pipeline.Flag{
+	Name: "from-targz",
+	Options: map[pipeline.FlagOption]any{
+		SBOMFromTarball: true,
+	},
+}
+
+// PackageLintFlags select which package the 'package-lint' artifact lints, like 'package-lint:deb:grafana:linux/amd64'.
+var PackageLintFlags = []pipeline.Flag{
+	{
+		Name: "deb",
+		Options: map[pipeline.FlagOption]any{
+			PackageLintType: "deb",
+		},
+	},
+	{
+		Name: "rpm",
+		Options: map[pipeline.FlagOption]any{
+			PackageLintType: "rpm",
+		},
+	},
+}
+
+// SignatureFlags select which package the 'asc' artifact signs, like 'asc:targz:grafana:linux/amd64'.
+var SignatureFlags = []pipeline.Flag{
+	{
+		Name: "targz",
+		Options: map[pipeline.FlagOption]any{
+			SignatureType: "targz",
+		},
+	},
+	{
+		Name: "zip",
+		Options: map[pipeline.FlagOption]any{
+			SignatureType: "zip",
+		},
+	},
+	{
+		Name: "msi",
+		Options: map[pipeline.FlagOption]any{
+			SignatureType: "msi",
+		},
+	},
+}
+
 func StdPackageFlags() []pipeline.Flag {
 	distros := DistroFlags()
 	names := PackageNameFlags
@@ -95,5 +157,6 @@ func StdPackageFlags() []pipeline.Flag {
 	return JoinFlags(
 		distros,
 		names,
+		[]pipeline.Flag{WithSBOMFlag},
 	)
 }
@@ -0,0 +1,17 @@
+package flags
+
+import "github.com/grafana/grafana-build/pipeline"
+
+const (
+	// TestShort sets the '-short' flag when running tests.
+	TestShort pipeline.FlagOption = "test-short"
+)
+
+var TestFlags = []pipeline.Flag{
+	{
+		Name: "short",
+		Options: map[pipeline.FlagOption]any{
+			TestShort: true,
+		},
+	},
+}
@@ -0,0 +1,298 @@
+package fpm
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/containers"
+	"github.com/grafana/grafana-build/e2e"
+)
+
+//go:embed apk/*
+var apkFiles embed.FS
+
+const (
+	// APKImage is the image that apks are built with abuild in and installed on when verifying them.
+	APKImage = "alpine:3.20"
+
+	// DefaultAPKKeyName is the name of the key that signs apks. apk looks for the public key in '/etc/apk/keys/<name>.rsa.pub'.
+	DefaultAPKKeyName = "grafana"
+)
+
+// APKSignOpts are the keys that sign an apk. Unlike debs and rpms, apks are signed with an RSA key, not a GPG key.
+type APKSignOpts struct {
+	Sign bool
+	// PrivateKey is an unencrypted PEM encoded RSA private key and PublicKey is its PEM encoded public key.
+	PrivateKey string
+	PublicKey  string
+	KeyName    string
+}
+
+// APKArch returns the Alpine architecture for the architecture 'arch' from backend.PackageArch.
+func APKArch(arch string) (string, error) {
+	switch arch {
+	case "amd64", "x86_64":
+		return "x86_64", nil
+	case "arm64", "aarch64":
+		return "aarch64", nil
+	case "armhf":
+		return "armv7", nil
+	case "armel":
+		return "armhf", nil
+	case "386":
+		return "x86", nil
+	case "ppc64el", "ppc64le":
+		return "ppc64le", nil
+	case "s390x", "riscv64":
+		return arch, nil
+	case "all", "noarch":
+		return "noarch", nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorUnknownArch, arch)
+}
+
+var (
+	apkSuffixRegex = regexp.MustCompile(`^(alpha|beta|pre|rc)(\d*)$`)
+	digitsRegex    = regexp.MustCompile(`\d+`)
+)
+
+// APKVersion returns the Alpine version of the Grafana version 'v'. apk versions don't allow dashes or semver pre-releases, so pre-releases
+// like '-beta1' become '_beta1', other pre-releases like build numbers become '_pre<number>', and security releases like '+security-01'
+// become '_p01'.
+func APKVersion(v string) string {
+	v = strings.TrimPrefix(v, "v")
+	i := strings.IndexAny(v, "-+")
+	if i == -1 {
+		return v
+	}
+
+	base, sep, suffix := v[:i], v[i], v[i+1:]
+	if sep == '+' {
+		return base + "_p" + strings.Join(digitsRegex.FindAllString(suffix, -1), "")
+	}
+	if m := apkSuffixRegex.FindStringSubmatch(suffix); m != nil {
+		return base + "_" + m[1] + m[2]
+	}
+
+	return base + "_pre" + strings.Join(digitsRegex.FindAllString(suffix, -1), "")
+}
+
+// APK returns the dependency in the format of an APKBUILD, like 'musl>=1.2'.
+func (d Dependency) APK() string {
+	return d.Name + d.Op + d.Version
+}
+
+const apkbuildTemplate = `# Maintainer: {{ .Maintainer }}
+pkgname={{ .Name }}
+pkgver={{ .Version }}
+pkgrel=0
+pkgdesc="{{ .Description }}"
+url="{{ .URL }}"
+arch="{{ .Arch }}"
+license="{{ .License }}"
+depends="{{ .Depends }}"
+install="$pkgname.pre-install $pkgname.post-install $pkgname.post-upgrade $pkgname.pre-deinstall"
+# The contents are the prebuilt files from the tarball in /pkg.
+options="!check !strip !tracedeps"
+
+package() {
+	mkdir -p "$pkgdir"
+	cp -a /pkg/. "$pkgdir"/
+}
+`
+
+type apkbuild struct {
+	Maintainer  string
+	Name        string
+	Version     string
+	Description string
+	URL         string
+	Arch        string
+	License     string
+	Depends     string
+}
+
+// APKBUILD returns the APKBUILD that abuild builds the apk described by 'opts' with.
+func APKBUILD(opts BuildOpts) (string, error) {
+	s := newSpec(opts)
+
+	arch, err := APKArch(s.Arch)
+	if err != nil {
+		return "", err
+	}
+	// backend.PackageArch is 'armhf' for both arm/v6 and arm/v7; Alpine's 'armhf' is arm/v6.
+	if _, a := backend.OSAndArch(opts.Distribution); a == "arm" && backend.ArchVersion(opts.Distribution) == "6" {
+		arch = "armhf"
+	}
+
+	license := s.License
+	switch license {
+	case "":
+		license = "custom"
+	case "AGPLv3":
+		license = "AGPL-3.0-only"
+	}
+
+	depends := []string{}
+	for _, v := range s.Depends {
+		d, err := ParseDependency(v)
+		if err != nil {
+			return "", err
+		}
+		depends = append(depends, d.APK())
+	}
+	for _, v := range s.Conflicts {
+		depends = append(depends, "!"+v)
+	}
+
+	b := &bytes.Buffer{}
+	if err := template.Must(template.New("APKBUILD").Parse(apkbuildTemplate)).Execute(b, apkbuild{
+		Maintainer:  fmt.Sprintf("%s <%s>", s.Vendor, s.Maintainer),
+		Name:        s.Name,
+		Version:     APKVersion(s.Version),
+		Description: s.Description,
+		URL:         s.URL,
+		Arch:        arch,
+		License:     license,
+		Depends:     strings.Join(depends, " "),
+	}); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// APKBuilder returns a container with abuild installed on the platform of 'distro', because abuild only builds packages for the architecture
+// that it runs on.
+func APKBuilder(d *dagger.Client, distro backend.Distribution) *dagger.Container {
+	return d.Container(dagger.ContainerOpts{
+		Platform: backend.Platform(distro),
+	}).From(APKImage).
+		WithExec([]string{"apk", "add", "--no-cache", "abuild", "tar"})
+}
+
+// apkKeyScript sets up abuild to sign with the key in /root/.abuild. Without a key, abuild-keygen creates a new key that nobody trusts.
+const apkKeyScript = `set -e
+if [ -f "/root/.abuild/$KEY_NAME.rsa" ]; then
+  echo "PACKAGER_PRIVKEY=/root/.abuild/$KEY_NAME.rsa" > /root/.abuild/abuild.conf
+else
+  abuild-keygen -a -n
+fi
+`
+
+const apkBuildScript = `set -e
+cd /apk
+abuild -F -d -P /out rootpkg
+find /out -name '*.apk' -exec mv {} /src/package.apk \;
+`
+
+// BuildAPK builds an apk with abuild in the container 'builder' (from APKBuilder) from the Grafana tar.gz 'targz', with the same layout as
+// Build and an OpenRC init script instead of the systemd unit.
+func BuildAPK(d *dagger.Client, builder *dagger.Container, opts BuildOpts, sign APKSignOpts, targz *dagger.File) (*dagger.File, error) {
+	opts.PackageType = PackageTypeAPK
+	spec := newSpec(opts)
+	build, err := APKBUILD(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	initd, err := apkFiles.ReadFile("apk/grafana-server.initd")
+	if err != nil {
+		return nil, err
+	}
+
+	container := layout(builder, spec, targz).
+		WithNewFile("/pkg/etc/init.d/grafana-server", string(initd), dagger.ContainerWithNewFileOpts{
+			Permissions: 0755,
+		}).
+		WithNewFile("/apk/APKBUILD", build)
+
+	scripts := map[string]string{
+		"pre-install":   "apk/grafana.pre-install",
+		"post-install":  "apk/grafana.post-install",
+		"post-upgrade":  "apk/grafana.post-install",
+		"pre-deinstall": "apk/grafana.pre-deinstall",
+	}
+	for k, v := range scripts {
+		b, err := apkFiles.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+		container = container.WithNewFile(fmt.Sprintf("/apk/%s.%s", spec.Name, k), string(b), dagger.ContainerWithNewFileOpts{
+			Permissions: 0755,
+		})
+	}
+
+	if sign.Sign {
+		container = container.
+			WithMountedSecret(fmt.Sprintf("/root/.abuild/%s.rsa", sign.KeyName), d.SetSecret("apk-private-key", sign.PrivateKey)).
+			WithNewFile(fmt.Sprintf("/root/.abuild/%s.rsa.pub", sign.KeyName), sign.PublicKey)
+	}
+
+	return container.
+		WithEnvVariable("KEY_NAME", sign.KeyName).
+		WithExec([]string{"/bin/sh", "-c", apkKeyScript}).
+		WithExec([]string{"/bin/sh", "-c", apkBuildScript}).
+		File("/src/package.apk"), nil
+}
+
+// apkInstallScript installs the apk and checks that Grafana and its OpenRC service are set up.
+const apkInstallScript = `set -e
+apk add $APK_OPTIONS /src/package.apk
+/usr/sbin/grafana-server -v
+id grafana
+test -x /etc/init.d/grafana-server
+test -f /etc/conf.d/grafana-server
+test -f /etc/grafana/grafana.ini
+`
+
+// apkServiceScript starts Grafana with OpenRC in a container that OpenRC did not boot.
+const apkServiceScript = `set -e
+sed -i 's/^#rc_sys=""/rc_sys="docker"/' /etc/rc.conf
+echo 'rc_provide="loopback net"' >> /etc/rc.conf
+mkdir -p /run/openrc
+touch /run/openrc/softlevel
+rc-service grafana-server start
+exec tail -F /var/log/grafana/grafana.log
+`
+
+// VerifyAPK installs the apk 'file' in Alpine, starts the OpenRC service, and runs the e2e tests or the smoke test against it. If the apk is
+// signed, apk checks the signature with only the public key.
+func VerifyAPK(ctx context.Context, d *dagger.Client, file *dagger.File, distro backend.Distribution, enterprise bool, sign APKSignOpts, opts e2e.ValidateOpts) error {
+	container := d.Container(dagger.ContainerOpts{
+		Platform: backend.Platform(distro),
+	}).From(APKImage).
+		WithFile("/src/package.apk", file)
+
+	if sign.Sign {
+		container = container.WithNewFile(fmt.Sprintf("/etc/apk/keys/%s.rsa.pub", sign.KeyName), sign.PublicKey)
+	} else {
+		container = container.WithEnvVariable("APK_OPTIONS", "--allow-untrusted")
+	}
+
+	container, err := containers.ExitError(ctx, container.
+		WithExec([]string{"apk", "add", "--no-cache", "openrc"}).
+		WithExec([]string{"/bin/sh", "-c", apkInstallScript}))
+	if err != nil {
+		return fmt.Errorf("installing the apk: %w", err)
+	}
+
+	if err := e2e.ValidateLicense(ctx, container, "/usr/share/grafana/LICENSE", enterprise); err != nil {
+		return err
+	}
+
+	service := container.
+		WithExec([]string{"/bin/sh", "-c", apkServiceScript}).
+		WithExposedPort(3000).
+		AsService()
+
+	return e2e.Validate(ctx, d, service, opts)
+}
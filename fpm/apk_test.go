@@ -0,0 +1,70 @@
+package fpm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/fpm"
+)
+
+func TestAPKVersion(t *testing.T) {
+	versions := map[string]string{
+		"v12.0.0":               "12.0.0",
+		"12.0.0-beta1":          "12.0.0_beta1",
+		"10.1.0-pre":            "10.1.0_pre",
+		"12.0.0-123456":         "12.0.0_pre123456",
+		"11.0.0+security-01":    "11.0.0_p01",
+		"12.1.0-12345pre":       "12.1.0_pre12345",
+		"12.0.0-rc2":            "12.0.0_rc2",
+		"12.0.0-abcdef-nightly": "12.0.0_pre",
+	}
+	for v, expected := range versions {
+		if got := fpm.APKVersion(v); got != expected {
+			t.Fatalf("expected '%s' for '%s', got '%s'", expected, v, got)
+		}
+	}
+}
+
+func TestAPKBUILD(t *testing.T) {
+	t.Run("It should conflict with grafana for enterprise packages", func(t *testing.T) {
+		b, err := fpm.APKBUILD(fpm.BuildOpts{
+			Name:         "grafana-enterprise",
+			Enterprise:   true,
+			Version:      "v12.0.0-beta1",
+			Distribution: "linux/arm64",
+			Depends:      []string{"musl >= 1.2"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			"pkgname=grafana-enterprise\n",
+			"pkgver=12.0.0_beta1\n",
+			`arch="aarch64"`,
+			`license="custom"`,
+			`depends="musl>=1.2 !grafana"`,
+		} {
+			if !strings.Contains(b, v) {
+				t.Fatalf("expected '%s' in the APKBUILD:\n%s", v, b)
+			}
+		}
+	})
+	t.Run("It should use the Alpine arm architectures", func(t *testing.T) {
+		for distro, arch := range map[string]string{"linux/arm/v7": "armv7", "linux/arm/v6": "armhf"} {
+			b, err := fpm.APKBUILD(fpm.BuildOpts{Name: "grafana", Version: "12.0.0", Distribution: backend.Distribution(distro)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(b, `arch="`+arch+`"`) || !strings.Contains(b, `license="AGPL-3.0-only"`) {
+				t.Fatalf("unexpected APKBUILD for '%s':\n%s", distro, b)
+			}
+		}
+	})
+	t.Run("It should return an error for an unknown architecture", func(t *testing.T) {
+		if _, err := fpm.APKBUILD(fpm.BuildOpts{Name: "grafana", Version: "12.0.0", Distribution: "linux/mips"}); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
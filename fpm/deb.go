@@ -0,0 +1,280 @@
+package fpm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// debScripts are the names of the maintainer scripts in the control tarball of a deb.
+var debScripts = map[Script]string{
+	ScriptAfterInstall: "postinst",
+	ScriptBeforeRemove: "prerm",
+}
+
+// arWriter writes the 'ar' archive that every deb is.
+type arWriter struct {
+	w       io.Writer
+	modTime time.Time
+}
+
+func newArWriter(w io.Writer, modTime time.Time) (*arWriter, error) {
+	if _, err := io.WriteString(w, "!<arch>\n"); err != nil {
+		return nil, err
+	}
+
+	return &arWriter{w: w, modTime: modTime}, nil
+}
+
+func (a *arWriter) add(name string, size int64, r io.Reader) error {
+	header := fmt.Sprintf("%-16s%-12d%-6d%-6d%-8o%-10d`\n", name, a.modTime.Unix(), 0, 0, 0o644, size)
+	if _, err := io.WriteString(a.w, header); err != nil {
+		return err
+	}
+	if n, err := io.Copy(a.w, r); err != nil {
+		return err
+	} else if n != size {
+		return fmt.Errorf("ar member '%s' is %d bytes, expected %d", name, n, size)
+	}
+
+	// Every member starts at an even offset.
+	if size%2 != 0 {
+		if _, err := io.WriteString(a.w, "\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func tarHeader(name string, mode fs.FileMode, size int64, modTime time.Time) *tar.Header {
+	h := &tar.Header{
+		Name:    name,
+		Mode:    int64(mode.Perm()),
+		Size:    size,
+		ModTime: modTime.Truncate(time.Second),
+		Uname:   "root",
+		Gname:   "root",
+	}
+
+	switch {
+	case mode.IsDir():
+		h.Typeflag = tar.TypeDir
+		h.Name += "/"
+	case mode&fs.ModeSymlink != 0:
+		h.Typeflag = tar.TypeSymlink
+	default:
+		h.Typeflag = tar.TypeReg
+	}
+
+	return h
+}
+
+// writeDebData writes 'data.tar.gz' to 'w', returning the installed size in KiB (counted the same way as dpkg-gencontrol) and the
+// 'md5sums' control file.
+func writeDebData(w io.Writer, c *contents) (int64, []byte, error) {
+	var (
+		gz     = gzip.NewWriter(w)
+		tw     = tar.NewWriter(gz)
+		size   int64
+		md5sum = &bytes.Buffer{}
+	)
+
+	if err := tw.WriteHeader(tarHeader(".", fs.ModeDir|0o755, 0, c.modTime)); err != nil {
+		return 0, nil, err
+	}
+
+	for _, f := range c.files {
+		h := tarHeader("."+f.Path, f.Mode, f.Size, f.ModTime)
+		h.Linkname = f.Linkname
+		if !f.Mode.IsRegular() {
+			h.Size = 0
+			size++
+		} else {
+			size += (f.Size + 1023) / 1024
+		}
+
+		if err := tw.WriteHeader(h); err != nil {
+			return 0, nil, err
+		}
+		if !f.Mode.IsRegular() {
+			continue
+		}
+
+		sum := md5.New()
+		if _, err := io.Copy(io.MultiWriter(tw, sum), c.open(f)); err != nil {
+			return 0, nil, err
+		}
+		fmt.Fprintf(md5sum, "%x  %s\n", sum.Sum(nil), strings.TrimPrefix(f.Path, "/"))
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, nil, err
+	}
+
+	return size, md5sum.Bytes(), nil
+}
+
+// debControl returns the 'control' file of a deb.
+func debControl(c *contents, arch string, installedSize int64) (string, error) {
+	s := c.spec
+	license := s.License
+	if license == "" {
+		// fpm's default
+		license = "unknown"
+	}
+
+	fields := [][2]string{
+		{"Package", s.Name},
+		{"Version", s.Version},
+		{"License", license},
+		{"Vendor", s.Vendor},
+		{"Architecture", arch},
+		{"Maintainer", s.Maintainer},
+		{"Installed-Size", fmt.Sprint(installedSize)},
+	}
+
+	for _, v := range []struct {
+		name string
+		deps []string
+	}{{"Depends", s.Depends}, {"Conflicts", s.Conflicts}} {
+		if len(v.deps) == 0 {
+			continue
+		}
+		deps := make([]string, len(v.deps))
+		for i, d := range v.deps {
+			dep, err := ParseDependency(d)
+			if err != nil {
+				return "", err
+			}
+			deps[i] = dep.Deb()
+		}
+		fields = append(fields, [2]string{v.name, strings.Join(deps, ", ")})
+	}
+
+	fields = append(fields,
+		[2]string{"Section", "default"},
+		[2]string{"Priority", "optional"},
+		[2]string{"Homepage", s.URL},
+	)
+
+	b := &strings.Builder{}
+	for _, v := range fields {
+		fmt.Fprintf(b, "%s: %s\n", v[0], v[1])
+	}
+
+	// The first line of the description is the synopsis; every other line is indented, and empty lines are a '.'.
+	lines := strings.Split(strings.TrimSpace(s.Description), "\n")
+	fmt.Fprintf(b, "Description: %s\n", lines[0])
+	for _, v := range lines[1:] {
+		if strings.TrimSpace(v) == "" {
+			v = "."
+		}
+		fmt.Fprintf(b, " %s\n", v)
+	}
+
+	return b.String(), nil
+}
+
+type controlFile struct {
+	name string
+	mode fs.FileMode
+	data []byte
+}
+
+// writeDebControl writes 'control.tar.gz' to 'w'.
+func writeDebControl(w io.Writer, c *contents, control string, md5sums []byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	files := []controlFile{
+		{"control", 0o644, []byte(control)},
+		{"md5sums", 0o644, md5sums},
+	}
+	if conf := c.configFiles(); len(conf) != 0 {
+		files = append(files, controlFile{"conffiles", 0o644, []byte(strings.Join(conf, "\n") + "\n")})
+	}
+	for _, k := range []Script{ScriptAfterInstall, ScriptBeforeRemove} {
+		if b, ok := c.scripts[k]; ok {
+			files = append(files, controlFile{debScripts[k], 0o755, b})
+		}
+	}
+
+	if err := tw.WriteHeader(tarHeader(".", fs.ModeDir|0o755, 0, c.modTime)); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := tw.WriteHeader(tarHeader("./"+f.name, f.mode, int64(len(f.data)), c.modTime)); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// writeDeb writes a deb to 'w', which is an 'ar' archive of 'debian-binary', 'control.tar.gz', and 'data.tar.gz'.
+func writeDeb(w io.Writer, c *contents) error {
+	arch, err := DebArch(c.spec.Arch)
+	if err != nil {
+		return err
+	}
+
+	// The data tarball is written first, because the control file has its size and checksums.
+	data, err := os.CreateTemp("", "data-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(data.Name())
+	defer data.Close()
+
+	installedSize, md5sums, err := writeDebData(data, c)
+	if err != nil {
+		return err
+	}
+	dataSize, err := data.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	control, err := debControl(c, arch, installedSize)
+	if err != nil {
+		return err
+	}
+	controlTar := &bytes.Buffer{}
+	if err := writeDebControl(controlTar, c, control, md5sums); err != nil {
+		return err
+	}
+
+	ar, err := newArWriter(w, c.modTime)
+	if err != nil {
+		return err
+	}
+	if err := ar.add("debian-binary", 4, strings.NewReader("2.0\n")); err != nil {
+		return err
+	}
+	if err := ar.add("control.tar.gz", int64(controlTar.Len()), controlTar); err != nil {
+		return err
+	}
+
+	return ar.add("data.tar.gz", dataSize, data)
+}
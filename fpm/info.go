@@ -0,0 +1,446 @@
+package fpm
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrorInvalidPackage = errors.New("invalid package")
+
+// PackageInfo is the metadata of a deb or rpm, regardless of how it was built. It's used to compare packages from fpm and the native
+// writers.
+type PackageInfo struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Arch        string            `json:"arch"`
+	License     string            `json:"license"`
+	Vendor      string            `json:"vendor"`
+	Maintainer  string            `json:"maintainer"`
+	URL         string            `json:"url"`
+	Description string            `json:"description"`
+	Depends     []string          `json:"depends"`
+	Conflicts   []string          `json:"conflicts"`
+	ConfigFiles []string          `json:"configFiles"`
+	Scripts     map[Script]string `json:"scripts"`
+	// Files are the installed paths of every regular file and symlink.
+	Files []string `json:"files"`
+}
+
+// readAr calls 'fn' with every member of the ar archive 'r'.
+func readAr(r io.Reader, fn func(name string, r io.Reader) error) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != "!<arch>\n" {
+		return fmt.Errorf("%w: not an ar archive", ErrorInvalidPackage)
+	}
+
+	header := make([]byte, 60)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		name := strings.TrimSuffix(strings.TrimSpace(string(header[:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: ar member '%s' has an invalid size", ErrorInvalidPackage, name)
+		}
+
+		lr := io.LimitReader(br, size)
+		if err := fn(name, lr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, lr); err != nil {
+			return err
+		}
+		if size%2 != 0 {
+			if _, err := br.Discard(1); err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+		}
+	}
+}
+
+// readTarGz calls 'fn' with every entry of a tar or tar.gz ar member.
+func readTarGz(name string, r io.Reader, fn func(h *tar.Header, r io.Reader) error) error {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(name, ".tar"):
+	default:
+		return fmt.Errorf("%w: unsupported compression for '%s'", ErrorInvalidPackage, name)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := fn(h, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// parseDebControl parses the fields of a debian control file. Continuation lines are joined with newlines.
+func parseDebControl(s string) map[string]string {
+	var (
+		fields = map[string]string{}
+		last   string
+	)
+
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, " ") && last != "" {
+			fields[last] += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		last = k
+		fields[k] = strings.TrimSpace(v)
+	}
+
+	return fields
+}
+
+func splitDebList(s string) []string {
+	r := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
+
+// ReadDebInfo reads the metadata of the deb 'r'.
+func ReadDebInfo(r io.Reader) (*PackageInfo, error) {
+	var (
+		info = &PackageInfo{
+			Scripts:     map[Script]string{},
+			ConfigFiles: []string{},
+			Files:       []string{},
+		}
+		control string
+	)
+
+	err := readAr(r, func(name string, r io.Reader) error {
+		switch {
+		case strings.HasPrefix(name, "control.tar"):
+			return readTarGz(name, r, func(h *tar.Header, r io.Reader) error {
+				b, err := io.ReadAll(r)
+				if err != nil {
+					return err
+				}
+
+				switch name := strings.TrimPrefix(h.Name, "./"); name {
+				case "control":
+					control = string(b)
+				case "conffiles":
+					info.ConfigFiles = strings.Fields(string(b))
+				default:
+					for k, v := range debScripts {
+						if v == name {
+							info.Scripts[k] = string(b)
+						}
+					}
+				}
+				return nil
+			})
+		case strings.HasPrefix(name, "data.tar"):
+			return readTarGz(name, r, func(h *tar.Header, r io.Reader) error {
+				if h.Typeflag != tar.TypeDir {
+					info.Files = append(info.Files, strings.TrimPrefix(h.Name, "."))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if control == "" {
+		return nil, fmt.Errorf("%w: the deb has no control file", ErrorInvalidPackage)
+	}
+
+	fields := parseDebControl(control)
+	info.Name = fields["Package"]
+	info.Version = fields["Version"]
+	info.Arch = fields["Architecture"]
+	info.License = fields["License"]
+	info.Vendor = fields["Vendor"]
+	info.Maintainer = fields["Maintainer"]
+	info.URL = fields["Homepage"]
+	info.Description = fields["Description"]
+	info.Depends = splitDebList(fields["Depends"])
+	info.Conflicts = splitDebList(fields["Conflicts"])
+
+	sort.Strings(info.ConfigFiles)
+	sort.Strings(info.Files)
+	return info, nil
+}
+
+// rpmTags are the entries of an rpm header by tag.
+type rpmTags map[int32]rpmEntry
+
+// readRPMHeader reads a header structure from 'r'.
+func readRPMHeader(r io.Reader) (rpmTags, int, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(intro[:8], rpmHeaderMagic) {
+		return nil, 0, fmt.Errorf("%w: bad rpm header magic", ErrorInvalidPackage)
+	}
+
+	var (
+		count = int(binary.BigEndian.Uint32(intro[8:]))
+		size  = int(binary.BigEndian.Uint32(intro[12:]))
+	)
+	if count > 1<<16 || size > 1<<28 {
+		return nil, 0, fmt.Errorf("%w: the rpm header is too large", ErrorInvalidPackage)
+	}
+
+	b := make([]byte, count*16+size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, 0, err
+	}
+
+	index, data := b[:count*16], b[count*16:]
+	tags := rpmTags{}
+	for i := 0; i < count; i++ {
+		e := index[i*16:]
+		entry := rpmEntry{
+			tag:   int32(binary.BigEndian.Uint32(e)),
+			typ:   int32(binary.BigEndian.Uint32(e[4:])),
+			count: int32(binary.BigEndian.Uint32(e[12:])),
+		}
+		offset := int(int32(binary.BigEndian.Uint32(e[8:])))
+		if offset < 0 || offset > len(data) {
+			return nil, 0, fmt.Errorf("%w: rpm header tag %d is out of range", ErrorInvalidPackage, entry.tag)
+		}
+		entry.data = data[offset:]
+		tags[entry.tag] = entry
+	}
+
+	return tags, len(intro) + len(b), nil
+}
+
+func (t rpmTags) strings(tag int32) []string {
+	e, ok := t[tag]
+	if !ok {
+		return []string{}
+	}
+	switch e.typ {
+	case rpmTypeString, rpmTypeStringArray, rpmTypeI18NString:
+	default:
+		return []string{}
+	}
+
+	r := make([]string, 0, e.count)
+	b := e.data
+	for i := int32(0); i < e.count; i++ {
+		s, rest, _ := bytes.Cut(b, []byte{0})
+		r = append(r, string(s))
+		b = rest
+	}
+
+	return r
+}
+
+func (t rpmTags) string(tag int32) string {
+	if s := t.strings(tag); len(s) != 0 {
+		return s[0]
+	}
+
+	return ""
+}
+
+func (t rpmTags) ints(tag int32) ([]int64, error) {
+	e, ok := t[tag]
+	if !ok {
+		return []int64{}, nil
+	}
+
+	size := 0
+	switch e.typ {
+	case rpmTypeInt16:
+		size = 2
+	case rpmTypeInt32:
+		size = 4
+	default:
+		return nil, fmt.Errorf("%w: rpm header tag %d is not an integer", ErrorInvalidPackage, tag)
+	}
+	if e.count < 0 || len(e.data) < int(e.count)*size {
+		return nil, fmt.Errorf("%w: rpm header tag %d is out of range", ErrorInvalidPackage, tag)
+	}
+
+	r := make([]int64, e.count)
+	for i := range r {
+		if size == 2 {
+			r[i] = int64(binary.BigEndian.Uint16(e.data[i*2:]))
+		} else {
+			r[i] = int64(int32(binary.BigEndian.Uint32(e.data[i*4:])))
+		}
+	}
+
+	return r, nil
+}
+
+// dependencies returns the dependencies in the name, flags, and version tags, without the rpmlib and script interpreter dependencies
+// that rpm adds by itself.
+func (t rpmTags) dependencies(nameTag, flagsTag, versionTag int32) ([]string, error) {
+	flags, err := t.ints(flagsTag)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		names    = t.strings(nameTag)
+		versions = t.strings(versionTag)
+		r        = []string{}
+	)
+
+	for i, name := range names {
+		if i >= len(flags) || i >= len(versions) {
+			break
+		}
+		if flags[i]&(rpmSenseRPMLib|rpmSenseInterp) != 0 || strings.HasPrefix(name, "rpmlib(") {
+			continue
+		}
+
+		op := ""
+		if flags[i]&rpmSenseLess != 0 {
+			op += "<"
+		}
+		if flags[i]&rpmSenseGreater != 0 {
+			op += ">"
+		}
+		if flags[i]&rpmSenseEqual != 0 {
+			op += "="
+		}
+		if op == "" || versions[i] == "" {
+			r = append(r, name)
+			continue
+		}
+		r = append(r, fmt.Sprintf("%s %s %s", name, op, versions[i]))
+	}
+
+	return r, nil
+}
+
+// ReadRPMInfo reads the metadata of the rpm 'r'. Only the header is read; the payload is not.
+func ReadRPMInfo(r io.Reader) (*PackageInfo, error) {
+	lead := make([]byte, 96)
+	if _, err := io.ReadFull(r, lead); err != nil || !bytes.Equal(lead[:4], []byte{0xed, 0xab, 0xee, 0xdb}) {
+		return nil, fmt.Errorf("%w: not an rpm", ErrorInvalidPackage)
+	}
+
+	_, n, err := readRPMHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	// The signature is padded to a multiple of 8 bytes.
+	if pad := n % 8; pad != 0 {
+		if _, err := io.ReadFull(r, make([]byte, 8-pad)); err != nil {
+			return nil, err
+		}
+	}
+
+	tags, _, err := readRPMHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	depends, err := tags.dependencies(rpmTagRequireName, rpmTagRequireFlags, rpmTagRequireVersion)
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := tags.dependencies(rpmTagConflictName, rpmTagConflictFlags, rpmTagConflictVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &PackageInfo{
+		Name:        tags.string(rpmTagName),
+		Version:     tags.string(rpmTagVersion) + "-" + tags.string(rpmTagRelease),
+		Arch:        tags.string(rpmTagArch),
+		License:     tags.string(rpmTagLicense),
+		Vendor:      tags.string(rpmTagVendor),
+		Maintainer:  tags.string(rpmTagPackager),
+		URL:         tags.string(rpmTagURL),
+		Description: tags.string(rpmTagDescription),
+		Depends:     depends,
+		Conflicts:   conflicts,
+		ConfigFiles: []string{},
+		Scripts:     map[Script]string{},
+		Files:       []string{},
+	}
+
+	for k, v := range rpmScripts {
+		if s, ok := tags[v[0]]; ok && s.typ == rpmTypeString {
+			info.Scripts[k] = tags.string(v[0])
+		}
+	}
+
+	var (
+		dirs  = tags.strings(rpmTagDirNames)
+		bases = tags.strings(rpmTagBaseNames)
+	)
+	indexes, err := tags.ints(rpmTagDirIndexes)
+	if err != nil {
+		return nil, err
+	}
+	modes, err := tags.ints(rpmTagFileModes)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := tags.ints(rpmTagFileFlags)
+	if err != nil {
+		return nil, err
+	}
+	if len(indexes) != len(bases) || len(modes) != len(bases) || len(flags) != len(bases) {
+		return nil, fmt.Errorf("%w: the rpm file list is inconsistent", ErrorInvalidPackage)
+	}
+	for i, base := range bases {
+		if int(indexes[i]) >= len(dirs) {
+			return nil, fmt.Errorf("%w: the rpm file list is inconsistent", ErrorInvalidPackage)
+		}
+		p := dirs[indexes[i]] + base
+		if flags[i]&rpmFileConfig != 0 {
+			info.ConfigFiles = append(info.ConfigFiles, p)
+		}
+		if modes[i]&0o170000 != 0o040000 {
+			info.Files = append(info.Files, p)
+		}
+	}
+
+	sort.Strings(info.ConfigFiles)
+	sort.Strings(info.Files)
+	return info, nil
+}
@@ -0,0 +1,249 @@
+package fpm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/containers"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultDebImage and DefaultRPMImage are the images that packages are installed on when verifying them if no other images are
+	// given.
+	DefaultDebImage = "debian:latest"
+	DefaultRPMImage = "redhat/ubi8:latest"
+
+	// ImageMatrix is expanded to every image in DebImageMatrix or RPMImageMatrix by ParseImages.
+	ImageMatrix = "matrix"
+)
+
+var (
+	// DebImageMatrix are the supported Ubuntu LTS releases and Debian stable and oldstable.
+	DebImageMatrix = []string{
+		"ubuntu:20.04",
+		"ubuntu:22.04",
+		"ubuntu:24.04",
+		"debian:stable",
+		"debian:oldstable",
+	}
+	// RPMImageMatrix are the supported RHEL-ish distributions (Rocky and Alma), Fedora, and openSUSE.
+	RPMImageMatrix = []string{
+		"rockylinux:8",
+		"rockylinux:9",
+		"almalinux:9",
+		"fedora:latest",
+		"opensuse/leap:15",
+	}
+)
+
+// ParseImages parses a comma-separated list of images. The value 'matrix' is replaced with every image in 'matrix'. Duplicate images are
+// removed.
+func ParseImages(s string, matrix []string) []string {
+	var (
+		images = []string{}
+		seen   = map[string]bool{}
+	)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		expanded := []string{v}
+		if v == ImageMatrix {
+			expanded = matrix
+		}
+		for _, image := range expanded {
+			if image == "" || seen[image] {
+				continue
+			}
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
+
+// InstallOpts are the options for the install, upgrade, and removal tests in VerifyInstall.
+type InstallOpts struct {
+	PackageType  PackageType
+	Distribution backend.Distribution
+	// Images are the distributions that the package is installed on. Debs are installed with apt and rpms with zypper, dnf, or yum,
+	// whichever the image has.
+	Images []string
+	// UpgradeFrom is a previous version of the package. If it is set, it's installed first and then upgraded to the new package.
+	UpgradeFrom *dagger.File
+}
+
+// The functions in these preludes are used by the test scripts so that the scripts work with every package manager. The package file is in
+// '$PACKAGE'.
+const (
+	debPrelude = `export DEBIAN_FRONTEND=noninteractive
+pkg_name() { dpkg-deb -f "$1" Package; }
+pkg_install() { apt-get install -y -o Dpkg::Options::=--force-confold "$1"; }
+pkg_files() { dpkg-query -L "$1"; }
+pkg_config_files() { dpkg-query -W -f='${Conffiles}\n' "$1" | awk 'NF { print $1 }'; }
+pkg_remove() { apt-get remove -y "$1"; }
+pkg_purge() { apt-get purge -y "$1"; }
+`
+	rpmPrelude = `pkg_name() { rpm -qp --qf '%{NAME}' "$1"; }
+if command -v zypper > /dev/null; then
+  pkg_install() { zypper --non-interactive install --allow-unsigned-rpm "$1"; }
+  pkg_remove() { zypper --non-interactive remove "$1"; }
+elif command -v dnf > /dev/null; then
+  pkg_install() { dnf install -y --nogpgcheck "$1"; }
+  pkg_remove() { dnf remove -y "$1"; }
+else
+  pkg_install() { yum install -y --nogpgcheck "$1"; }
+  pkg_remove() { yum remove -y "$1"; }
+fi
+pkg_files() { rpm -ql "$1"; }
+pkg_config_files() { rpm -qc "$1"; }
+# rpm has no purge; removing the package removes its unmodified config files.
+pkg_purge() { true; }
+`
+)
+
+const (
+	// installScript installs the package and checks that Grafana runs and is set up.
+	installScript = `set -e
+pkg_install "$PACKAGE"
+/usr/sbin/grafana-server -v
+/usr/sbin/grafana-cli -v
+id grafana
+test -f /etc/grafana/grafana.ini
+test "$(stat -c %G /etc/grafana/grafana.ini)" = grafana
+test -f /usr/lib/systemd/system/grafana-server.service
+`
+
+	// prepareUpgradeScript changes the config, writes data, and enables the service like a user would before upgrading.
+	prepareUpgradeScript = `set -e
+echo '# upgrade-test' >> /etc/grafana/grafana.ini
+echo upgrade-test > /var/lib/grafana/upgrade-test
+chown grafana:grafana /var/lib/grafana/upgrade-test
+mkdir -p /etc/systemd/system/multi-user.target.wants
+ln -sf /usr/lib/systemd/system/grafana-server.service /etc/systemd/system/multi-user.target.wants/grafana-server.service
+`
+
+	// checkUpgradeScript checks that the changes from prepareUpgradeScript survived the upgrade.
+	checkUpgradeScript = `set -e
+grep -qx '# upgrade-test' /etc/grafana/grafana.ini || { echo 'the config was replaced by the upgrade'; exit 1; }
+test "$(cat /var/lib/grafana/upgrade-test)" = upgrade-test || { echo 'the data was removed by the upgrade'; exit 1; }
+test "$(stat -c %U /var/lib/grafana/upgrade-test)" = grafana || { echo 'the data owner was changed by the upgrade'; exit 1; }
+test -e /etc/systemd/system/multi-user.target.wants/grafana-server.service || { echo 'the service was disabled by the upgrade'; exit 1; }
+`
+
+	// removeScript removes and purges the package and checks that none of its files are left behind. Config files are only checked
+	// after purging, and data in /var/lib/grafana must be kept.
+	removeScript = `set -e
+name=$(pkg_name "$PACKAGE")
+pkg_files "$name" > /tmp/files
+pkg_config_files "$name" > /tmp/config-files
+echo removal-test > /var/lib/grafana/removal-test
+
+leftovers() {
+  while read -r f; do
+    if [ -d "$f" ] || grep -qxF "$f" "$1"; then
+      continue
+    fi
+    if [ -e "$f" ] || [ -L "$f" ]; then
+      echo "$f"
+    fi
+  done < /tmp/files
+}
+
+pkg_remove "$name"
+left=$(leftovers /tmp/config-files)
+if [ -n "$left" ]; then
+  printf 'files left after removing the package:\n%s\n' "$left"
+  exit 1
+fi
+
+pkg_purge "$name"
+left=$(leftovers /dev/null)
+if [ -n "$left" ]; then
+  printf 'files left after purging the package:\n%s\n' "$left"
+  exit 1
+fi
+
+test -f /var/lib/grafana/removal-test || { echo 'the data was removed with the package'; exit 1; }
+`
+)
+
+// installContainer returns a container from 'image' that the test scripts can run in.
+func installContainer(d *dagger.Client, image string, opts InstallOpts) *dagger.Container {
+	c := d.Container(dagger.ContainerOpts{
+		Platform: backend.Platform(opts.Distribution),
+	}).From(image)
+
+	prelude := rpmPrelude
+	if opts.PackageType == PackageTypeDeb {
+		prelude = debPrelude
+		c = c.WithExec([]string{"apt-get", "update"})
+	}
+
+	// apt and zypper only install local packages if the file has the right extension.
+	return c.
+		WithNewFile("/src/prelude.sh", prelude).
+		WithEnvVariable("PACKAGE", "/src/package."+string(opts.PackageType))
+}
+
+func runScript(ctx context.Context, c *dagger.Container, name, script string) (*dagger.Container, error) {
+	c, err := containers.ExitError(ctx, c.WithExec([]string{"/bin/sh", "-c", ". /src/prelude.sh\n" + script}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return c, nil
+}
+
+func verifyInstallImage(ctx context.Context, d *dagger.Client, file *dagger.File, image string, opts InstallOpts) error {
+	var (
+		c   = installContainer(d, image, opts)
+		err error
+	)
+
+	if opts.UpgradeFrom != nil {
+		c, err = runScript(ctx, c.WithFile("/src/package."+string(opts.PackageType), opts.UpgradeFrom), "installing the previous package", installScript)
+		if err != nil {
+			return err
+		}
+		c, err = runScript(ctx, c, "preparing the upgrade", prepareUpgradeScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	c, err = runScript(ctx, c.WithFile("/src/package."+string(opts.PackageType), file), "installing the package", installScript)
+	if err != nil {
+		return err
+	}
+
+	if opts.UpgradeFrom != nil {
+		c, err = runScript(ctx, c, "checking the upgrade", checkUpgradeScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = runScript(ctx, c, "removing the package", removeScript)
+	return err
+}
+
+// VerifyInstall installs the package 'file' on every image in 'opts.Images', optionally upgrading from a previous package, and then removes
+// it and checks for leftovers. The images are tested in parallel.
+func VerifyInstall(ctx context.Context, d *dagger.Client, file *dagger.File, opts InstallOpts) error {
+	wg, ctx := errgroup.WithContext(ctx)
+	for _, image := range opts.Images {
+		image := image
+		wg.Go(func() error {
+			if err := verifyInstallImage(ctx, d, file, image, opts); err != nil {
+				return fmt.Errorf("%s: %w", image, err)
+			}
+			return nil
+		})
+	}
+
+	return wg.Wait()
+}
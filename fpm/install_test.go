@@ -0,0 +1,29 @@
+package fpm_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grafana/grafana-build/fpm"
+)
+
+func TestParseImages(t *testing.T) {
+	t.Run("It should return the images in order without duplicates", func(t *testing.T) {
+		images := fpm.ParseImages("debian:stable, ubuntu:24.04,debian:stable,", fpm.DebImageMatrix)
+		if expected := []string{"debian:stable", "ubuntu:24.04"}; !reflect.DeepEqual(images, expected) {
+			t.Fatalf("expected %v, got %v", expected, images)
+		}
+	})
+	t.Run("It should expand 'matrix'", func(t *testing.T) {
+		images := fpm.ParseImages("redhat/ubi8:latest,matrix", fpm.RPMImageMatrix)
+		expected := append([]string{"redhat/ubi8:latest"}, fpm.RPMImageMatrix...)
+		if !reflect.DeepEqual(images, expected) {
+			t.Fatalf("expected %v, got %v", expected, images)
+		}
+	})
+	t.Run("It should return no images for an empty list", func(t *testing.T) {
+		if images := fpm.ParseImages("", fpm.DebImageMatrix); len(images) != 0 {
+			t.Fatalf("expected no images, got %v", images)
+		}
+	})
+}
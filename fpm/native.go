@@ -0,0 +1,342 @@
+package fpm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+)
+
+// PackageBuilder is what builds deb and rpm packages.
+type PackageBuilder string
+
+const (
+	// PackageBuilderFPM builds packages with fpm in a ruby container.
+	PackageBuilderFPM PackageBuilder = "fpm"
+	// PackageBuilderNative builds packages with the native Go writers in this package, without a container.
+	PackageBuilderNative PackageBuilder = "native"
+)
+
+var ErrorInvalidPackageBuilder = errors.New("invalid package builder; expected 'fpm' or 'native'")
+
+func ParsePackageBuilder(s string) (PackageBuilder, error) {
+	switch b := PackageBuilder(s); b {
+	case PackageBuilderFPM, PackageBuilderNative:
+		return b, nil
+	case "":
+		return PackageBuilderFPM, nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorInvalidPackageBuilder, s)
+}
+
+// ID is added to the names of packages so that packages from fpm and from the native writers don't have the same names. It is empty for fpm,
+// so that the names of the default packages don't change.
+func (b PackageBuilder) ID() string {
+	if b == PackageBuilderFPM {
+		return ""
+	}
+
+	return string(b)
+}
+
+// BuildNative builds the same package as Build, but with the native writers instead of fpm. The tarball is exported to the host and read
+// once without extracting it; the package is written on the host and then loaded into dagger.
+func BuildNative(ctx context.Context, d *dagger.Client, opts BuildOpts, targz *dagger.File) (*dagger.File, error) {
+	dir, err := os.MkdirTemp("", "grafana-package-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "grafana.tar.gz")
+	if _, err := targz.Export(ctx, src); err != nil {
+		return nil, err
+	}
+
+	dst := filepath.Join(dir, fmt.Sprintf("package.%s", opts.PackageType))
+	if err := writeFile(dst, src, opts); err != nil {
+		return nil, err
+	}
+
+	return d.Host().File(dst).Sync(ctx)
+}
+
+func writeFile(dst, src string, opts BuildOpts) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(w, opts, r); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// Write writes the package described by 'opts' to 'w' from the Grafana tar.gz 'targz', without fpm.
+// The tarball is decompressed into a temporary file so that its contents can be read in any order.
+func Write(w io.Writer, opts BuildOpts, targz io.Reader) error {
+	s := newSpec(opts)
+	if len(s.unsupported) != 0 {
+		return fmt.Errorf("%w: %s", ErrorUnsupportedArgument, strings.Join(s.unsupported, " "))
+	}
+
+	tmp, err := os.CreateTemp("", "grafana-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	c, err := readContents(s, targz, tmp)
+	if err != nil {
+		return err
+	}
+
+	switch s.Type {
+	case PackageTypeDeb:
+		return writeDeb(w, c)
+	case PackageTypeRPM:
+		return writeRPM(w, c)
+	}
+
+	return fmt.Errorf("unknown package type '%s'", s.Type)
+}
+
+// A file is a regular file, directory, or symlink that is installed by a package.
+type file struct {
+	// Path is the installed path, like '/usr/share/grafana/bin/grafana'.
+	Path     string
+	Mode     fs.FileMode
+	Size     int64
+	ModTime  time.Time
+	Linkname string
+	Config   bool
+
+	// offset is the offset of the contents of a regular file in the uncompressed tarball.
+	offset int64
+}
+
+// contents is everything that is installed by a package, with the uncompressed tarball that has the contents of every file.
+type contents struct {
+	spec    *spec
+	files   []*file
+	scripts map[Script][]byte
+	tar     io.ReaderAt
+	// modTime is the latest modification time in the tarball, which is used for directories that are not in it and as the build time.
+	modTime time.Time
+}
+
+func (c *contents) open(f *file) io.Reader {
+	return io.NewSectionReader(c.tar, f.offset, f.Size)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// stripComponent returns the name of a tarball entry without its first component, like 'tar --strip-components=1'.
+func stripComponent(name string) (string, bool) {
+	_, rel, ok := strings.Cut(strings.TrimPrefix(path.Clean(name), "./"), "/")
+	return rel, ok && rel != ""
+}
+
+// readContents decompresses 'targz' into 'tmp' and maps every entry to where it's installed, the same way that Build does with 'tar', 'cp',
+// and 'mkdir'.
+func readContents(s *spec, targz io.Reader, tmp *os.File) (*contents, error) {
+	gz, err := gzip.NewReader(targz)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var (
+		cr      = &countingReader{r: io.TeeReader(gz, tmp)}
+		tr      = tar.NewReader(cr)
+		entries = map[string]*file{}
+		c       = &contents{
+			spec:    s,
+			scripts: map[Script][]byte{},
+			tar:     tmp,
+		}
+	)
+
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		rel, ok := stripComponent(h.Name)
+		if !ok || slices.Contains(strings.Split(rel, "/"), "storybook") {
+			continue
+		}
+
+		f := &file{
+			Mode:    h.FileInfo().Mode(),
+			ModTime: h.ModTime,
+			offset:  cr.n,
+		}
+		if h.ModTime.After(c.modTime) {
+			c.modTime = h.ModTime
+		}
+
+		switch h.Typeflag {
+		case tar.TypeReg:
+			f.Size = h.Size
+		case tar.TypeDir:
+		case tar.TypeSymlink:
+			f.Linkname = h.Linkname
+		case tar.TypeLink:
+			target, ok := stripComponent(h.Linkname)
+			if !ok || entries[target] == nil {
+				return nil, fmt.Errorf("'%s' is a hard link to '%s', which is not in the tarball", h.Name, h.Linkname)
+			}
+			f.Mode, f.Size, f.offset = entries[target].Mode, entries[target].Size, entries[target].offset
+		default:
+			return nil, fmt.Errorf("'%s' has an unsupported type in the tarball", h.Name)
+		}
+
+		entries[rel] = f
+	}
+
+	// Read the rest of the tarball, so that all of it is in 'tmp'.
+	if _, err := io.Copy(io.Discard, cr); err != nil {
+		return nil, err
+	}
+
+	files := map[string]*file{}
+	add := func(p string, f *file) {
+		v := *f
+		v.Path = p
+		files[p] = &v
+	}
+
+	for rel, f := range entries {
+		add(path.Join("/usr/share/grafana", rel), f)
+	}
+
+	for _, v := range s.Wrappers {
+		rel, err := srcPath(v)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := entries[rel]
+		if !ok {
+			return nil, fmt.Errorf("'%s' not found in the tarball", rel)
+		}
+		add(path.Join("/usr/sbin", path.Base(rel)), f)
+	}
+
+	for _, v := range s.ConfigFiles {
+		src, err := srcPath(v[0])
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for rel, f := range entries {
+			if rel != src && !strings.HasPrefix(rel, src+"/") {
+				continue
+			}
+			found = true
+			add(path.Join(v[1], strings.TrimPrefix(rel, src)), f)
+			if !f.Mode.IsDir() {
+				files[path.Join(v[1], strings.TrimPrefix(rel, src))].Config = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("config file '%s' not found in the tarball", src)
+		}
+	}
+
+	for k, v := range s.Scripts {
+		rel, err := srcPath(v)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := entries[rel]
+		if !ok || !f.Mode.IsRegular() {
+			return nil, fmt.Errorf("%s script '%s' not found in the tarball", k, rel)
+		}
+		b := make([]byte, f.Size)
+		if _, err := tmp.ReadAt(b, f.offset); err != nil {
+			return nil, err
+		}
+		c.scripts[k] = b
+	}
+
+	// Every directory in s.Dirs and every parent of every file is created.
+	dirs := slices.Clone(s.Dirs)
+	for p := range files {
+		dirs = append(dirs, path.Dir(p))
+	}
+	for _, v := range dirs {
+		for p := v; p != "/" && p != "."; p = path.Dir(p) {
+			if _, ok := files[p]; ok {
+				continue
+			}
+			files[p] = &file{
+				Path:    p,
+				Mode:    fs.ModeDir | 0o755,
+				ModTime: c.modTime,
+			}
+		}
+	}
+
+	for p, f := range files {
+		if s.DefaultConfigFiles && strings.HasPrefix(p, "/etc/") && !f.Mode.IsDir() {
+			f.Config = true
+		}
+		c.files = append(c.files, f)
+	}
+
+	sort.Slice(c.files, func(i, j int) bool {
+		return c.files[i].Path < c.files[j].Path
+	})
+
+	return c, nil
+}
+
+// configFiles returns the installed paths of every config file.
+func (c *contents) configFiles() []string {
+	r := []string{}
+	for _, f := range c.files {
+		if f.Config {
+			r = append(r, f.Path)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,364 @@
+package fpm_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/fpm"
+)
+
+var update = flag.Bool("update", false, "build the packages with fpm and update the golden files in testdata with their metadata")
+
+// testTarball returns a tar.gz with the files that the packages need, in the same layout as a Grafana tarball.
+func testTarball(t *testing.T) []byte {
+	t.Helper()
+	var (
+		buf   = &bytes.Buffer{}
+		gz    = gzip.NewWriter(buf)
+		tw    = tar.NewWriter(gz)
+		mtime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+
+	files := []struct {
+		name     string
+		mode     int64
+		contents string
+		link     string
+	}{
+		{name: "grafana-12.0.0/", mode: 0o755},
+		{name: "grafana-12.0.0/LICENSE", mode: 0o644, contents: "GNU AFFERO GENERAL PUBLIC LICENSE\n"},
+		{name: "grafana-12.0.0/bin/", mode: 0o755},
+		{name: "grafana-12.0.0/bin/grafana", mode: 0o755, contents: "#!/bin/sh\necho grafana\n"},
+		{name: "grafana-12.0.0/bin/grafana-server", mode: 0o777, link: "grafana"},
+		{name: "grafana-12.0.0/conf/defaults.ini", mode: 0o644, contents: "[server]\nhttp_port = 3000\n"},
+		{name: "grafana-12.0.0/public/storybook/index.html", mode: 0o644, contents: "<html></html>"},
+		{name: "grafana-12.0.0/packaging/wrappers/grafana-server", mode: 0o755, contents: "#!/bin/sh\nexec grafana server \"$@\"\n"},
+		{name: "grafana-12.0.0/packaging/wrappers/grafana-cli", mode: 0o755, contents: "#!/bin/sh\nexec grafana cli \"$@\"\n"},
+		{name: "grafana-12.0.0/packaging/deb/default/grafana-server", mode: 0o644, contents: "GRAFANA_USER=grafana\n"},
+		{name: "grafana-12.0.0/packaging/deb/init.d/grafana-server", mode: 0o755, contents: "#!/bin/sh\n"},
+		{name: "grafana-12.0.0/packaging/deb/systemd/grafana-server.service", mode: 0o644, contents: "[Unit]\nDescription=Grafana\n"},
+		{name: "grafana-12.0.0/packaging/deb/control/postinst", mode: 0o755, contents: "#!/bin/sh\necho deb postinst\n"},
+		{name: "grafana-12.0.0/packaging/deb/control/prerm", mode: 0o755, contents: "#!/bin/sh\necho deb prerm\n"},
+		{name: "grafana-12.0.0/packaging/rpm/sysconfig/grafana-server", mode: 0o644, contents: "GRAFANA_USER=grafana\n"},
+		{name: "grafana-12.0.0/packaging/rpm/systemd/grafana-server.service", mode: 0o644, contents: "[Unit]\nDescription=Grafana\n"},
+		{name: "grafana-12.0.0/packaging/rpm/control/postinst", mode: 0o755, contents: "#!/bin/sh\necho rpm postinst\n"},
+		{name: "grafana-12.0.0/packaging/rpm/control/posttrans", mode: 0o755, contents: "#!/bin/sh\necho rpm posttrans\n"},
+	}
+
+	for _, f := range files {
+		h := &tar.Header{Name: f.name, Mode: f.mode, ModTime: mtime, Size: int64(len(f.contents)), Typeflag: tar.TypeReg}
+		switch {
+		case f.link != "":
+			h.Typeflag, h.Linkname = tar.TypeSymlink, f.link
+		case f.name[len(f.name)-1] == '/':
+			h.Typeflag = tar.TypeDir
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// debOpts and rpmOpts are the same options that the 'deb' and 'rpm' artifacts use.
+func debOpts(enterprise bool) fpm.BuildOpts {
+	return fpm.BuildOpts{
+		Name:         "grafana",
+		Enterprise:   enterprise,
+		Version:      "12.0.0-12345",
+		Distribution: "linux/amd64",
+		PackageType:  fpm.PackageTypeDeb,
+		ConfigFiles: [][]string{
+			{"/src/packaging/deb/default/grafana-server", "/pkg/etc/default/grafana-server"},
+			{"/src/packaging/deb/init.d/grafana-server", "/pkg/etc/init.d/grafana-server"},
+			{"/src/packaging/deb/systemd/grafana-server.service", "/pkg/usr/lib/systemd/system/grafana-server.service"},
+		},
+		AfterInstall: "/src/packaging/deb/control/postinst",
+		BeforeRemove: "/src/packaging/deb/control/prerm",
+		Depends:      []string{"adduser", "musl"},
+		EnvFolder:    "/pkg/etc/default",
+		ExtraArgs:    []string{"--deb-no-default-config-files"},
+	}
+}
+
+func rpmOpts(enterprise bool) fpm.BuildOpts {
+	return fpm.BuildOpts{
+		Name:         "grafana",
+		Enterprise:   enterprise,
+		Version:      "12.0.0^12345",
+		Distribution: "linux/arm64",
+		PackageType:  fpm.PackageTypeRPM,
+		ConfigFiles: [][]string{
+			{"/src/packaging/rpm/sysconfig/grafana-server", "/pkg/etc/sysconfig/grafana-server"},
+			{"/src/packaging/rpm/systemd/grafana-server.service", "/pkg/usr/lib/systemd/system/grafana-server.service"},
+		},
+		AfterInstall: "/src/packaging/rpm/control/postinst",
+		Depends:      []string{"/sbin/service"},
+		ExtraArgs:    []string{"--rpm-posttrans=/src/packaging/rpm/control/posttrans", "--rpm-digest=sha256"},
+		EnvFolder:    "/pkg/etc/sysconfig",
+	}
+}
+
+func writePackage(t *testing.T, opts fpm.BuildOpts) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if err := fpm.Write(buf, opts, bytes.NewReader(testTarball(t))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func readInfo(t *testing.T, packageType fpm.PackageType, b []byte) *fpm.PackageInfo {
+	t.Helper()
+	read := fpm.ReadDebInfo
+	if packageType == fpm.PackageTypeRPM {
+		read = fpm.ReadRPMInfo
+	}
+
+	info, err := read(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return info
+}
+
+// compareGolden compares the metadata of a package with the golden file, which has the metadata of the same package built with fpm.
+func compareGolden(t *testing.T, name string, info *fpm.PackageInfo) {
+	t.Helper()
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", name)
+	expect, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(expect) != string(b)+"\n" {
+		t.Fatalf("%s does not match; run 'dagger run go test ./fpm -update' to build the golden files with fpm again if fpm's output changed.\nexpected:\n%s\ngot:\n%s", golden, expect, b)
+	}
+}
+
+// updateGolden builds the package with fpm and writes its metadata to the golden file, so that the native writers are compared with fpm's
+// packages and not with their own output.
+func updateGolden(t *testing.T, d *dagger.Client, name string, opts fpm.BuildOpts) {
+	t.Helper()
+	info := readInfo(t, opts.PackageType, fpmPackage(t, d, opts))
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join("testdata", name), append(b, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// connect connects to the dagger session that is needed to build packages with fpm.
+func connect(t *testing.T) *dagger.Client {
+	t.Helper()
+	if _, ok := os.LookupEnv("DAGGER_SESSION_PORT"); !ok {
+		t.Fatal("building packages with fpm needs a dagger session; run the test with 'dagger run go test'")
+	}
+
+	d, err := dagger.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+
+	return d
+}
+
+// fpmPackage builds the package with fpm from the test tarball.
+func fpmPackage(t *testing.T, d *dagger.Client, opts fpm.BuildOpts) []byte {
+	t.Helper()
+	targz := filepath.Join(t.TempDir(), "grafana.tar.gz")
+	if err := os.WriteFile(targz, testTarball(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := fpm.Build(fpm.Builder(d), opts, d.Host().File(targz)).Contents(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return []byte(contents)
+}
+
+func TestWrite(t *testing.T) {
+	var d *dagger.Client
+	if *update {
+		d = connect(t)
+	}
+
+	for name, opts := range map[string]fpm.BuildOpts{
+		"grafana.deb.json":            debOpts(false),
+		"grafana-enterprise.deb.json": debOpts(true),
+		"grafana.rpm.json":            rpmOpts(false),
+		"grafana-enterprise.rpm.json": rpmOpts(true),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if *update {
+				updateGolden(t, d, name, opts)
+			}
+
+			info := readInfo(t, opts.PackageType, writePackage(t, opts))
+			compareGolden(t, name, info)
+
+			if slices.Contains(info.Files, "/usr/share/grafana/public/storybook/index.html") {
+				t.Fatal("the storybook should not be in the package")
+			}
+			for _, v := range []string{"/usr/sbin/grafana-server", "/usr/sbin/grafana-cli", "/usr/share/grafana/bin/grafana-server"} {
+				if !slices.Contains(info.Files, v) {
+					t.Fatalf("expected '%s' in the package", v)
+				}
+			}
+		})
+	}
+
+	t.Run("The output should be reproducible", func(t *testing.T) {
+		for _, opts := range []fpm.BuildOpts{debOpts(false), rpmOpts(false)} {
+			if !bytes.Equal(writePackage(t, opts), writePackage(t, opts)) {
+				t.Fatalf("expected the same %s for the same tarball", opts.PackageType)
+			}
+		}
+	})
+
+	t.Run("Unsupported fpm arguments should be an error", func(t *testing.T) {
+		opts := debOpts(false)
+		opts.ExtraArgs = append(opts.ExtraArgs, "--deb-compression=xz")
+		if err := fpm.Write(&bytes.Buffer{}, opts, bytes.NewReader(testTarball(t))); !errors.Is(err, fpm.ErrorUnsupportedArgument) {
+			t.Fatalf("expected an unsupported argument error, got %v", err)
+		}
+	})
+
+	t.Run("Missing config files should be an error", func(t *testing.T) {
+		opts := rpmOpts(false)
+		opts.ConfigFiles = append(opts.ConfigFiles, []string{"/src/packaging/rpm/missing", "/pkg/etc/missing"})
+		if err := fpm.Write(&bytes.Buffer{}, opts, bytes.NewReader(testTarball(t))); err == nil {
+			t.Fatal("expected an error for a config file that is not in the tarball")
+		}
+	})
+}
+
+func TestReadRPMInfo(t *testing.T) {
+	t.Run("An integer tag with more values than data should be an error", func(t *testing.T) {
+		// The lead, an empty signature header, and a header with a file modes tag (1030) of 4 int16 values (type 3) but only 2 bytes of data.
+		lead := make([]byte, 96)
+		copy(lead, []byte{0xed, 0xab, 0xee, 0xdb})
+		header := func(entries []uint32, data []byte) []byte {
+			b := binary.BigEndian.AppendUint32([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0}, uint32(len(entries)/4))
+			b = binary.BigEndian.AppendUint32(b, uint32(len(data)))
+			for _, v := range entries {
+				b = binary.BigEndian.AppendUint32(b, v)
+			}
+			return append(b, data...)
+		}
+
+		rpm := append(lead, header(nil, nil)...)
+		rpm = append(rpm, header([]uint32{1030, 3, 0, 4}, []byte{0x81, 0xa4})...)
+		if _, err := fpm.ReadRPMInfo(bytes.NewReader(rpm)); !errors.Is(err, fpm.ErrorInvalidPackage) {
+			t.Fatalf("expected an invalid package error, got %v", err)
+		}
+	})
+}
+
+func TestArch(t *testing.T) {
+	for arch, expect := range map[string][2]string{
+		"amd64":  {"amd64", "x86_64"},
+		"arm64":  {"arm64", "aarch64"},
+		"armhf":  {"armhf", "armv7hl"},
+		"386":    {"i386", "i386"},
+		"s390x":  {"s390x", "s390x"},
+		"noarch": {"all", "noarch"},
+	} {
+		deb, err := fpm.DebArch(arch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpm, err := fpm.RPMArch(arch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deb != expect[0] || rpm != expect[1] {
+			t.Fatalf("expected '%s' and '%s' for '%s', got '%s' and '%s'", expect[0], expect[1], arch, deb, rpm)
+		}
+	}
+
+	if _, err := fpm.DebArch(""); !errors.Is(err, fpm.ErrorUnknownArch) {
+		t.Fatalf("expected an unknown architecture error, got %v", err)
+	}
+}
+
+func TestParseDependency(t *testing.T) {
+	for s, expect := range map[string]string{
+		"adduser":          "adduser",
+		"libc6 >= 2.34":    "libc6 (>= 2.34)",
+		"grafana < 10.0.0": "grafana (<< 10.0.0)",
+		"musl >> 1.2":      "musl (>> 1.2)",
+	} {
+		dep, err := fpm.ParseDependency(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deb := dep.Deb(); deb != expect {
+			t.Fatalf("expected '%s' for '%s', got '%s'", expect, s, deb)
+		}
+	}
+
+	for _, s := range []string{"", "a b", "libc6 ~ 2.34"} {
+		if _, err := fpm.ParseDependency(s); err == nil {
+			t.Fatalf("expected '%s' to be invalid", s)
+		}
+	}
+}
+
+// TestNativeMatchesFPM builds every package with fpm and with the native writers and compares their metadata. It runs in CI with
+// 'dagger run go test ./fpm -run TestNativeMatchesFPM'.
+func TestNativeMatchesFPM(t *testing.T) {
+	if _, ok := os.LookupEnv("DAGGER_SESSION_PORT"); !ok || testing.Short() {
+		t.Skip("this test needs a dagger session; run it with 'dagger run go test'")
+	}
+
+	d := connect(t)
+	for _, opts := range []fpm.BuildOpts{debOpts(false), debOpts(true), rpmOpts(false), rpmOpts(true)} {
+		var (
+			expect = readInfo(t, opts.PackageType, fpmPackage(t, d, opts))
+			actual = readInfo(t, opts.PackageType, writePackage(t, opts))
+		)
+
+		if !reflect.DeepEqual(expect, actual) {
+			e, _ := json.MarshalIndent(expect, "", "  ")
+			a, _ := json.MarshalIndent(actual, "", "  ")
+			t.Fatalf("the native %s does not match fpm's.\nfpm:\n%s\nnative:\n%s", opts.PackageType, e, a)
+		}
+	}
+}
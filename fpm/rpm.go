@@ -0,0 +1,599 @@
+package fpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// rpm header tag types
+const (
+	rpmTypeInt16       = 3
+	rpmTypeInt32       = 4
+	rpmTypeString      = 6
+	rpmTypeBin         = 7
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// rpm header tags; see 'rpmtag.h' in rpm.
+const (
+	rpmTagHeaderSignatures = 62
+	rpmTagHeaderImmutable  = 63
+	rpmTagHeaderI18NTable  = 100
+
+	rpmSigTagSHA1        = 269
+	rpmSigTagSHA256      = 273
+	rpmSigTagSize        = 1000
+	rpmSigTagMD5         = 1004
+	rpmSigTagPayloadSize = 1007
+
+	rpmTagName              = 1000
+	rpmTagVersion           = 1001
+	rpmTagRelease           = 1002
+	rpmTagSummary           = 1004
+	rpmTagDescription       = 1005
+	rpmTagBuildTime         = 1006
+	rpmTagBuildHost         = 1007
+	rpmTagSize              = 1009
+	rpmTagVendor            = 1011
+	rpmTagLicense           = 1014
+	rpmTagPackager          = 1015
+	rpmTagGroup             = 1016
+	rpmTagURL               = 1020
+	rpmTagOS                = 1021
+	rpmTagArch              = 1022
+	rpmTagPostIn            = 1024
+	rpmTagPreUn             = 1025
+	rpmTagFileSizes         = 1028
+	rpmTagFileModes         = 1030
+	rpmTagFileRDevs         = 1033
+	rpmTagFileMTimes        = 1034
+	rpmTagFileDigests       = 1035
+	rpmTagFileLinkTos       = 1036
+	rpmTagFileFlags         = 1037
+	rpmTagFileUserName      = 1039
+	rpmTagFileGroupName     = 1040
+	rpmTagSourceRPM         = 1044
+	rpmTagFileVerifyFlags   = 1045
+	rpmTagProvideName       = 1047
+	rpmTagRequireFlags      = 1048
+	rpmTagRequireName       = 1049
+	rpmTagRequireVersion    = 1050
+	rpmTagConflictFlags     = 1053
+	rpmTagConflictName      = 1054
+	rpmTagConflictVersion   = 1055
+	rpmTagPostInProg        = 1086
+	rpmTagPreUnProg         = 1087
+	rpmTagFileDevices       = 1095
+	rpmTagFileInodes        = 1096
+	rpmTagFileLangs         = 1097
+	rpmTagProvideFlags      = 1112
+	rpmTagProvideVersion    = 1113
+	rpmTagDirIndexes        = 1116
+	rpmTagBaseNames         = 1117
+	rpmTagDirNames          = 1118
+	rpmTagPayloadFormat     = 1124
+	rpmTagPayloadCompressor = 1125
+	rpmTagPayloadFlags      = 1126
+	rpmTagPostTrans         = 1152
+	rpmTagPostTransProg     = 1154
+	rpmTagFileDigestAlgo    = 5011
+)
+
+// rpm dependency flags
+const (
+	rpmSenseLess        = 1 << 1
+	rpmSenseGreater     = 1 << 2
+	rpmSenseEqual       = 1 << 3
+	rpmSensePostTrans   = 1 << 5
+	rpmSenseInterp      = 1 << 8
+	rpmSenseScriptPost  = 1 << 10
+	rpmSenseScriptPreUn = 1 << 11
+	rpmSenseRPMLib      = 1 << 24
+)
+
+const (
+	rpmFileConfig    = 1 << 0
+	rpmFileNoReplace = 1 << 4
+
+	rpmDigestSHA256 = 8
+)
+
+// rpmScripts are the tags of the script and its interpreter for every maintainer script, and the dependency flag for its interpreter.
+var rpmScripts = map[Script][3]int32{
+	ScriptAfterInstall:     {rpmTagPostIn, rpmTagPostInProg, rpmSenseScriptPost},
+	ScriptBeforeRemove:     {rpmTagPreUn, rpmTagPreUnProg, rpmSenseScriptPreUn},
+	ScriptAfterTransaction: {rpmTagPostTrans, rpmTagPostTransProg, rpmSensePostTrans},
+}
+
+// rpmLibRequires are the rpm features that packages from writeRPM need.
+var rpmLibRequires = [][2]string{
+	{"rpmlib(CompressedFileNames)", "3.0.4-1"},
+	{"rpmlib(FileDigests)", "4.6.0-1"},
+	{"rpmlib(PayloadFilesHavePrefix)", "4.0-1"},
+}
+
+var rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0}
+
+type rpmEntry struct {
+	tag   int32
+	typ   int32
+	count int32
+	data  []byte
+}
+
+// rpmHeader is an rpm header structure, which is used for both the signature and the header of an rpm.
+type rpmHeader struct {
+	entries []rpmEntry
+}
+
+func (h *rpmHeader) add(tag, typ int32, count int, data []byte) {
+	h.entries = append(h.entries, rpmEntry{tag: tag, typ: typ, count: int32(count), data: data})
+}
+
+func (h *rpmHeader) addString(tag int32, s string) {
+	h.add(tag, rpmTypeString, 1, append([]byte(s), 0))
+}
+
+func (h *rpmHeader) addI18NString(tag int32, s string) {
+	h.add(tag, rpmTypeI18NString, 1, append([]byte(s), 0))
+}
+
+func (h *rpmHeader) addStrings(tag int32, s []string) {
+	b := []byte{}
+	for _, v := range s {
+		b = append(append(b, v...), 0)
+	}
+	h.add(tag, rpmTypeStringArray, len(s), b)
+}
+
+func (h *rpmHeader) addInt32(tag int32, v ...int32) {
+	b := make([]byte, 4*len(v))
+	for i, n := range v {
+		binary.BigEndian.PutUint32(b[i*4:], uint32(n))
+	}
+	h.add(tag, rpmTypeInt32, len(v), b)
+}
+
+func (h *rpmHeader) addInt16(tag int32, v ...uint16) {
+	b := make([]byte, 2*len(v))
+	for i, n := range v {
+		binary.BigEndian.PutUint16(b[i*2:], n)
+	}
+	h.add(tag, rpmTypeInt16, len(v), b)
+}
+
+func rpmAlignment(typ int32) int {
+	switch typ {
+	case rpmTypeInt16:
+		return 2
+	case rpmTypeInt32:
+		return 4
+	}
+	return 1
+}
+
+// bytes returns the header, with every entry in the region 'region'. The region tag is the first entry and points at a trailer at the end
+// of the data, which has the negative size of the index.
+func (h *rpmHeader) bytes(region int32) []byte {
+	entries := append([]rpmEntry{}, h.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].tag < entries[j].tag
+	})
+
+	var (
+		count   = len(entries) + 1
+		index   = &bytes.Buffer{}
+		data    = &bytes.Buffer{}
+		putInfo = func(w io.Writer, tag, typ, offset, count int32) {
+			binary.Write(w, binary.BigEndian, [4]int32{tag, typ, offset, count})
+		}
+	)
+
+	for _, e := range entries {
+		if pad := data.Len() % rpmAlignment(e.typ); pad != 0 {
+			data.Write(make([]byte, rpmAlignment(e.typ)-pad))
+		}
+		putInfo(index, e.tag, e.typ, int32(data.Len()), e.count)
+		data.Write(e.data)
+	}
+
+	trailer := int32(data.Len())
+	putInfo(data, region, rpmTypeBin, -int32(count*16), 16)
+
+	b := &bytes.Buffer{}
+	b.Write(rpmHeaderMagic)
+	binary.Write(b, binary.BigEndian, [2]int32{int32(count), int32(data.Len())})
+	putInfo(b, region, rpmTypeBin, trailer, 16)
+	b.Write(index.Bytes())
+	b.Write(data.Bytes())
+
+	return b.Bytes()
+}
+
+// rpmLead returns the (mostly unused) lead that every rpm starts with.
+func rpmLead(name string) []byte {
+	b := make([]byte, 96)
+	copy(b, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	// type (binary) and architecture
+	binary.BigEndian.PutUint16(b[6:], 0)
+	binary.BigEndian.PutUint16(b[8:], 1)
+	copy(b[10:75], name)
+	// os (linux) and signature type (header-style signature)
+	binary.BigEndian.PutUint16(b[76:], 1)
+	binary.BigEndian.PutUint16(b[78:], 5)
+
+	return b
+}
+
+type rpmDependencies struct {
+	names    []string
+	flags    []int32
+	versions []string
+}
+
+func (d *rpmDependencies) add(name string, flags int32, version string) {
+	d.names = append(d.names, name)
+	d.flags = append(d.flags, flags)
+	d.versions = append(d.versions, version)
+}
+
+func (d *rpmDependencies) addParsed(deps []string) error {
+	for _, v := range deps {
+		dep, err := ParseDependency(v)
+		if err != nil {
+			return err
+		}
+
+		var flags int32
+		if strings.Contains(dep.Op, "<") {
+			flags |= rpmSenseLess
+		}
+		if strings.Contains(dep.Op, ">") {
+			flags |= rpmSenseGreater
+		}
+		if strings.Contains(dep.Op, "=") {
+			flags |= rpmSenseEqual
+		}
+		d.add(dep.Name, flags, dep.Version)
+	}
+
+	return nil
+}
+
+// writeRPMPayload writes the gzipped cpio archive of every file in 'c' to 'w', returning the sha256 digest of every regular file and the
+// uncompressed size.
+func writeRPMPayload(w io.Writer, c *contents) ([]string, int64, error) {
+	var (
+		gz      = gzip.NewWriter(w)
+		cw      = &countingWriter{w: gz}
+		digests = make([]string, len(c.files))
+	)
+
+	writeHeader := func(ino int, name string, mode uint32, nlink int, mtime int64, size int64) error {
+		if _, err := fmt.Fprintf(cw, "070701%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%s\x00",
+			ino, mode, 0, 0, nlink, mtime, size, 0, 0, 0, 0, len(name)+1, 0, name); err != nil {
+			return err
+		}
+		return cw.pad()
+	}
+
+	for i, f := range c.files {
+		var (
+			size  = f.Size
+			nlink = 1
+			r     io.Reader
+			sum   = sha256.New()
+		)
+
+		switch {
+		case f.Mode.IsDir():
+			size, nlink = 0, 2
+		case f.Mode&fs.ModeSymlink != 0:
+			size, r = int64(len(f.Linkname)), strings.NewReader(f.Linkname)
+		default:
+			r = io.TeeReader(c.open(f), sum)
+		}
+
+		if err := writeHeader(i+1, "."+f.Path, rpmFileMode(f.Mode), nlink, f.ModTime.Unix(), size); err != nil {
+			return nil, 0, err
+		}
+		if r == nil {
+			continue
+		}
+		if _, err := io.Copy(cw, r); err != nil {
+			return nil, 0, err
+		}
+		if err := cw.pad(); err != nil {
+			return nil, 0, err
+		}
+		if f.Mode.IsRegular() {
+			digests[i] = fmt.Sprintf("%x", sum.Sum(nil))
+		}
+	}
+
+	if err := writeHeader(0, "TRAILER!!!", 0, 1, 0, 0); err != nil {
+		return nil, 0, err
+	}
+	size := cw.n
+
+	if err := gz.Close(); err != nil {
+		return nil, 0, err
+	}
+
+	return digests, size, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// pad pads the cpio archive to a multiple of 4 bytes.
+func (c *countingWriter) pad() error {
+	if n := c.n % 4; n != 0 {
+		_, err := c.Write(make([]byte, 4-n))
+		return err
+	}
+
+	return nil
+}
+
+// rpmFileMode returns the 'st_mode' of a file.
+func rpmFileMode(m fs.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	switch {
+	case m.IsDir():
+		mode |= 0o040000
+	case m&fs.ModeSymlink != 0:
+		mode |= 0o120000
+	default:
+		mode |= 0o100000
+	}
+
+	return mode
+}
+
+// rpmMainHeader returns the header of an rpm with the payload from writeRPMPayload.
+func rpmMainHeader(c *contents, arch string, digests []string) ([]byte, error) {
+	var (
+		s                = c.spec
+		version, release = RPMVersion(s.Version)
+		h                = &rpmHeader{}
+		license          = s.License
+	)
+	if license == "" {
+		// fpm's default
+		license = "unknown"
+	}
+
+	h.addStrings(rpmTagHeaderI18NTable, []string{"C"})
+	h.addString(rpmTagName, s.Name)
+	h.addString(rpmTagVersion, version)
+	h.addString(rpmTagRelease, release)
+	h.addI18NString(rpmTagSummary, strings.SplitN(strings.TrimSpace(s.Description), "\n", 2)[0])
+	h.addI18NString(rpmTagDescription, s.Description)
+	h.addInt32(rpmTagBuildTime, int32(c.modTime.Unix()))
+	h.addString(rpmTagBuildHost, "localhost")
+	h.addString(rpmTagVendor, s.Vendor)
+	h.addString(rpmTagLicense, license)
+	h.addString(rpmTagPackager, s.Maintainer)
+	h.addI18NString(rpmTagGroup, "default")
+	h.addString(rpmTagURL, s.URL)
+	h.addString(rpmTagOS, "linux")
+	h.addString(rpmTagArch, arch)
+	h.addString(rpmTagSourceRPM, fmt.Sprintf("%s-%s-%s.src.rpm", s.Name, version, release))
+	h.addString(rpmTagPayloadFormat, "cpio")
+	h.addString(rpmTagPayloadCompressor, "gzip")
+	h.addString(rpmTagPayloadFlags, "9")
+
+	requires := &rpmDependencies{}
+	if err := requires.addParsed(s.Depends); err != nil {
+		return nil, err
+	}
+	for _, k := range []Script{ScriptAfterInstall, ScriptBeforeRemove, ScriptAfterTransaction} {
+		b, ok := c.scripts[k]
+		if !ok {
+			continue
+		}
+		tags := rpmScripts[k]
+		h.addString(tags[0], string(b))
+		h.addString(tags[1], "/bin/sh")
+		requires.add("/bin/sh", rpmSenseInterp|tags[2], "")
+	}
+	for _, v := range rpmLibRequires {
+		requires.add(v[0], rpmSenseRPMLib|rpmSenseLess|rpmSenseEqual, v[1])
+	}
+	h.addStrings(rpmTagRequireName, requires.names)
+	h.addInt32(rpmTagRequireFlags, requires.flags...)
+	h.addStrings(rpmTagRequireVersion, requires.versions)
+
+	conflicts := &rpmDependencies{}
+	if err := conflicts.addParsed(s.Conflicts); err != nil {
+		return nil, err
+	}
+	if len(conflicts.names) != 0 {
+		h.addStrings(rpmTagConflictName, conflicts.names)
+		h.addInt32(rpmTagConflictFlags, conflicts.flags...)
+		h.addStrings(rpmTagConflictVersion, conflicts.versions)
+	}
+
+	h.addStrings(rpmTagProvideName, []string{s.Name})
+	h.addInt32(rpmTagProvideFlags, rpmSenseEqual)
+	h.addStrings(rpmTagProvideVersion, []string{fmt.Sprintf("%s-%s", version, release)})
+
+	var (
+		n         = len(c.files)
+		sizes     = make([]int32, n)
+		modes     = make([]uint16, n)
+		rdevs     = make([]uint16, n)
+		mtimes    = make([]int32, n)
+		linktos   = make([]string, n)
+		flags     = make([]int32, n)
+		users     = make([]string, n)
+		verify    = make([]int32, n)
+		devices   = make([]int32, n)
+		inodes    = make([]int32, n)
+		langs     = make([]string, n)
+		dirIndex  = make([]int32, n)
+		baseNames = make([]string, n)
+		dirNames  = []string{}
+		dirs      = map[string]int32{}
+		total     int64
+	)
+
+	for i, f := range c.files {
+		dir, base := path.Split(f.Path)
+		if _, ok := dirs[dir]; !ok {
+			dirs[dir] = int32(len(dirNames))
+			dirNames = append(dirNames, dir)
+		}
+
+		switch {
+		case f.Mode.IsRegular():
+			sizes[i] = int32(f.Size)
+		case f.Mode&fs.ModeSymlink != 0:
+			sizes[i] = int32(len(f.Linkname))
+		}
+		if f.Config {
+			flags[i] = rpmFileConfig | rpmFileNoReplace
+		}
+
+		total += int64(sizes[i])
+		modes[i] = uint16(rpmFileMode(f.Mode))
+		mtimes[i] = int32(f.ModTime.Unix())
+		linktos[i] = f.Linkname
+		users[i] = "root"
+		verify[i] = -1
+		devices[i] = 1
+		inodes[i] = int32(i + 1)
+		dirIndex[i] = dirs[dir]
+		baseNames[i] = base
+	}
+
+	// The 32-bit size tags can't describe packages that are larger than 2GB.
+	if total > math.MaxInt32 {
+		return nil, fmt.Errorf("the package is %d bytes; the native rpm writer only supports packages up to %d bytes", total, math.MaxInt32)
+	}
+
+	h.addInt32(rpmTagSize, int32(total))
+	h.addInt32(rpmTagFileSizes, sizes...)
+	h.addInt16(rpmTagFileModes, modes...)
+	h.addInt16(rpmTagFileRDevs, rdevs...)
+	h.addInt32(rpmTagFileMTimes, mtimes...)
+	h.addStrings(rpmTagFileDigests, digests)
+	h.addStrings(rpmTagFileLinkTos, linktos)
+	h.addInt32(rpmTagFileFlags, flags...)
+	h.addStrings(rpmTagFileUserName, users)
+	h.addStrings(rpmTagFileGroupName, users)
+	h.addInt32(rpmTagFileVerifyFlags, verify...)
+	h.addInt32(rpmTagFileDevices, devices...)
+	h.addInt32(rpmTagFileInodes, inodes...)
+	h.addStrings(rpmTagFileLangs, langs)
+	h.addInt32(rpmTagDirIndexes, dirIndex...)
+	h.addStrings(rpmTagBaseNames, baseNames)
+	h.addStrings(rpmTagDirNames, dirNames)
+	h.addInt32(rpmTagFileDigestAlgo, rpmDigestSHA256)
+
+	return h.bytes(rpmTagHeaderImmutable), nil
+}
+
+// writeRPM writes an rpm to 'w', which is a lead, a signature header with the sizes and digests of the rest, the header, and a gzipped
+// cpio payload.
+func writeRPM(w io.Writer, c *contents) error {
+	arch, err := RPMArch(c.spec.Arch)
+	if err != nil {
+		return err
+	}
+
+	// Like fpm, rpms only have files and empty directories, so that they don't own directories like '/usr/lib' that other packages own.
+	parents := map[string]bool{}
+	for _, f := range c.files {
+		parents[path.Dir(f.Path)] = true
+	}
+	leaves := *c
+	leaves.files = nil
+	for _, f := range c.files {
+		if f.Mode.IsDir() && parents[f.Path] {
+			continue
+		}
+		leaves.files = append(leaves.files, f)
+	}
+	c = &leaves
+
+	// The payload is written first, because the header has the digest of every file.
+	payload, err := os.CreateTemp("", "payload-*.cpio.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(payload.Name())
+	defer payload.Close()
+
+	digests, payloadSize, err := writeRPMPayload(payload, c)
+	if err != nil {
+		return err
+	}
+	if _, err := payload.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	header, err := rpmMainHeader(c, arch, digests)
+	if err != nil {
+		return err
+	}
+
+	// The signature has digests of the header and the header + payload.
+	md5sum := md5.New()
+	md5sum.Write(header)
+	compressedSize, err := io.Copy(md5sum, payload)
+	if err != nil {
+		return err
+	}
+	if _, err := payload.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if size := int64(len(header)) + compressedSize; size > math.MaxInt32 || payloadSize > math.MaxInt32 {
+		return fmt.Errorf("the package is %d bytes; the native rpm writer only supports packages up to %d bytes", size, math.MaxInt32)
+	}
+
+	sig := &rpmHeader{}
+	sig.addString(rpmSigTagSHA1, fmt.Sprintf("%x", sha1.Sum(header)))
+	sig.addString(rpmSigTagSHA256, fmt.Sprintf("%x", sha256.Sum256(header)))
+	sig.addInt32(rpmSigTagSize, int32(int64(len(header))+compressedSize))
+	sig.add(rpmSigTagMD5, rpmTypeBin, md5.Size, md5sum.Sum(nil))
+	sig.addInt32(rpmSigTagPayloadSize, int32(payloadSize))
+
+	signature := sig.bytes(rpmTagHeaderSignatures)
+	// The signature is padded to a multiple of 8 bytes.
+	if n := len(signature) % 8; n != 0 {
+		signature = append(signature, make([]byte, 8-n)...)
+	}
+
+	version, release := RPMVersion(c.spec.Version)
+	for _, b := range [][]byte{rpmLead(fmt.Sprintf("%s-%s-%s", c.spec.Name, version, release)), signature, header} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.Copy(w, payload)
+	return err
+}
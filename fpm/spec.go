@@ -0,0 +1,300 @@
+package fpm
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/grafana/grafana-build/backend"
+	"github.com/grafana/grafana-build/versions"
+)
+
+var (
+	ErrorUnsupportedArgument = errors.New("argument is not supported by the native package writer")
+	ErrorUnknownArch         = errors.New("unknown package architecture")
+)
+
+// A Script is a maintainer script that runs when a package is installed, upgraded, or removed.
+type Script string
+
+const (
+	ScriptAfterInstall     Script = "after-install"
+	ScriptBeforeRemove     Script = "before-remove"
+	ScriptAfterTransaction Script = "after-transaction"
+)
+
+// spec is everything that's in a package, derived from the BuildOpts. Both the fpm arguments and the native writers use it, so that both
+// produce the same package.
+type spec struct {
+	Type        PackageType
+	Name        string
+	Version     string
+	Arch        string
+	Vendor      string
+	URL         string
+	Maintainer  string
+	License     string
+	Description string
+	Depends     []string
+	Conflicts   []string
+
+	// ConfigFiles are the [source, destination] pairs of config files, like
+	// {"/src/packaging/deb/default/grafana-server", "/etc/default/grafana-server"}.
+	ConfigFiles [][2]string
+	// DefaultConfigFiles marks every file in '/etc' as a config file, which is what fpm does for debs by default.
+	DefaultConfigFiles bool
+	// Scripts are the paths of the maintainer scripts in the extracted tarball ('/src').
+	Scripts map[Script]string
+	// Dirs are the directories that are created even if they are empty.
+	Dirs []string
+	// Wrappers are copied from the extracted tarball into '/usr/sbin'.
+	Wrappers []string
+
+	// ExtraArgs are passed to fpm unchanged.
+	ExtraArgs []string
+	// unsupported are the ExtraArgs that the native writers don't understand.
+	unsupported []string
+}
+
+// pkgPath returns the installed path of a path in '/pkg'.
+func pkgPath(p string) string {
+	return path.Join("/", strings.TrimPrefix(path.Clean(p), "/pkg"))
+}
+
+// srcPath returns the path in the tarball of a path in the extracted tarball ('/src').
+func srcPath(p string) (string, error) {
+	rel, ok := strings.CutPrefix(path.Clean(p), "/src/")
+	if !ok {
+		return "", fmt.Errorf("'%s' is not in the extracted tarball ('/src')", p)
+	}
+
+	return rel, nil
+}
+
+func newSpec(opts BuildOpts) *spec {
+	metadata := opts.Metadata.For(opts.Enterprise)
+	s := &spec{
+		Type:        opts.PackageType,
+		Name:        string(opts.Name),
+		Version:     strings.TrimPrefix(opts.Version, "v"),
+		Arch:        backend.PackageArch(opts.Distribution),
+		Vendor:      metadata.Vendor,
+		URL:         metadata.Homepage,
+		Maintainer:  metadata.Maintainer,
+		Description: metadata.Description,
+		License:     metadata.License,
+		Depends:     append(append([]string{}, opts.Depends...), metadata.Depends...),
+		Scripts:     map[Script]string{},
+		Dirs: []string{
+			"/usr/sbin",
+			"/usr/share",
+			// holds default environment variables for the grafana-server service
+			pkgPath(opts.EnvFolder),
+			// /etc/grafana is empty in the installation, but is set up by the postinstall script and must be created first.
+			"/etc/grafana",
+		},
+		// the "wrappers" scripts are the same as grafana-cli/grafana-server but with some extra shell commands before/after execution.
+		Wrappers: []string{
+			"/src/packaging/wrappers/grafana-server",
+			"/src/packaging/wrappers/grafana-cli",
+		},
+		DefaultConfigFiles: opts.PackageType == PackageTypeDeb,
+		ExtraArgs:          opts.ExtraArgs,
+	}
+
+	if n := opts.NameOverride; n != "" {
+		s.Name = n
+	}
+
+	// Honestly we don't care about making fpm installers for non-enterprise or non-grafana flavors of grafana
+	if opts.Enterprise {
+		s.Conflicts = []string{"grafana"}
+	}
+
+	// these are our systemd unit files that allow systemd to start/stop/restart/enable the grafana service.
+	// Alpine uses OpenRC instead of systemd.
+	if opts.PackageType != PackageTypeAPK {
+		s.Dirs = append(s.Dirs, "/usr/lib/systemd/system")
+	}
+
+	// init.d scripts are service management scripts that start/stop/restart/enable the grafana service without systemd.
+	// these are likely to be deprecated as systemd is now the default pretty much everywhere.
+	if opts.PackageType != PackageTypeRPM {
+		s.Dirs = append(s.Dirs, "/etc/init.d")
+	}
+
+	// These paths need to be absolute when installed on the machine and not the package structure.
+	for _, c := range opts.ConfigFiles {
+		s.ConfigFiles = append(s.ConfigFiles, [2]string{c[0], pkgPath(c[1])})
+	}
+
+	if opts.AfterInstall != "" {
+		s.Scripts[ScriptAfterInstall] = opts.AfterInstall
+	}
+
+	// If this is a debian installer and this version had a prerm script (introduced in v9.5)...
+	// TODO: this logic means that rpms can't also have a beforeremove. Not important at the moment because it's static (in pipelines/rpm.go) and it doesn't have beforeremove set.
+	vopts := versions.OptionsFor(opts.Version)
+	if vopts.DebPreRM.IsSet && vopts.DebPreRM.Value && opts.PackageType == PackageTypeDeb && opts.BeforeRemove != "" {
+		s.Scripts[ScriptBeforeRemove] = opts.BeforeRemove
+	}
+
+	for _, v := range opts.ExtraArgs {
+		name, value, _ := strings.Cut(v, "=")
+		switch {
+		case name == "--deb-no-default-config-files":
+			s.DefaultConfigFiles = false
+		case name == "--rpm-posttrans" && opts.PackageType == PackageTypeRPM:
+			s.Scripts[ScriptAfterTransaction] = value
+		// The native rpm writer always uses sha256 file digests.
+		case name == "--rpm-digest" && value == "sha256":
+		default:
+			s.unsupported = append(s.unsupported, v)
+		}
+	}
+
+	return s
+}
+
+// fpmArgs returns the arguments for fpm to build the package from the directory '/pkg' into 'destination'.
+func (s *spec) fpmArgs(destination string) []string {
+	args := []string{
+		"fpm",
+		"--input-type=dir",
+		"--chdir=/pkg",
+		fmt.Sprintf("--output-type=%s", s.Type),
+		fmt.Sprintf("--vendor=%s", s.Vendor),
+		fmt.Sprintf("--url=%s", s.URL),
+		fmt.Sprintf("--maintainer=%s", s.Maintainer),
+		fmt.Sprintf("--version=%s", s.Version),
+		fmt.Sprintf("--package=%s", destination),
+	}
+
+	if v, ok := s.Scripts[ScriptBeforeRemove]; ok {
+		args = append(args, fmt.Sprintf("--before-remove=%s", v))
+	}
+
+	for _, c := range s.ConfigFiles {
+		args = append(args, fmt.Sprintf("--config-files=%s", c[1]))
+	}
+
+	if v, ok := s.Scripts[ScriptAfterInstall]; ok {
+		args = append(args, fmt.Sprintf("--after-install=%s", v))
+	}
+
+	for _, d := range s.Depends {
+		args = append(args, fmt.Sprintf("--depends=%s", d))
+	}
+
+	// The after-transaction script is in ExtraArgs as '--rpm-posttrans'.
+	args = append(args, s.ExtraArgs...)
+
+	if s.Arch != "" {
+		args = append(args, fmt.Sprintf("--architecture=%s", s.Arch))
+	}
+
+	args = append(args, fmt.Sprintf("--description=%s", s.Description))
+	for _, c := range s.Conflicts {
+		args = append(args, fmt.Sprintf("--conflicts=%s", c))
+	}
+	if s.License != "" {
+		args = append(args, fmt.Sprintf("--license=%s", s.License))
+	}
+
+	args = append(args, fmt.Sprintf("--name=%s", s.Name))
+
+	// The last fpm arg which is required to say, "use the PWD to build the package".
+	return append(args, ".")
+}
+
+// DebArch returns the debian architecture for the architecture 'arch' from backend.PackageArch. Like fpm, the rpm names for amd64 and
+// arm64 are also accepted.
+func DebArch(arch string) (string, error) {
+	switch arch {
+	case "amd64", "x86_64":
+		return "amd64", nil
+	case "arm64", "aarch64":
+		return "arm64", nil
+	case "armhf", "armel", "s390x", "ppc64el", "riscv64":
+		return arch, nil
+	case "386":
+		return "i386", nil
+	case "all", "noarch":
+		return "all", nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorUnknownArch, arch)
+}
+
+// RPMArch returns the rpm architecture for the architecture 'arch' from backend.PackageArch.
+func RPMArch(arch string) (string, error) {
+	switch arch {
+	case "amd64", "x86_64":
+		return "x86_64", nil
+	case "arm64", "aarch64":
+		return "aarch64", nil
+	case "armhf":
+		return "armv7hl", nil
+	case "armel":
+		return "armv6l", nil
+	case "386":
+		return "i386", nil
+	case "s390x", "riscv64":
+		return arch, nil
+	case "all", "noarch":
+		return "noarch", nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorUnknownArch, arch)
+}
+
+// RPMVersion returns the version and release of the package version 'v' the same way that fpm does; dashes are not allowed in rpm versions,
+// so they are replaced with underscores, and the release is always '1'.
+func RPMVersion(v string) (string, string) {
+	return strings.ReplaceAll(v, "-", "_"), "1"
+}
+
+// A Dependency is a package name with an optional version constraint, like 'adduser' or 'libc6 >= 2.34'.
+type Dependency struct {
+	Name string
+	// Op is one of '<', '<=', '=', '>=', '>', or empty.
+	Op      string
+	Version string
+}
+
+func ParseDependency(s string) (Dependency, error) {
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		return Dependency{Name: fields[0]}, nil
+	case 3:
+		switch op := fields[1]; op {
+		case "<", "<=", "=", ">=", ">":
+			return Dependency{Name: fields[0], Op: op, Version: fields[2]}, nil
+		case "<<":
+			return Dependency{Name: fields[0], Op: "<", Version: fields[2]}, nil
+		case ">>":
+			return Dependency{Name: fields[0], Op: ">", Version: fields[2]}, nil
+		}
+	}
+
+	return Dependency{}, fmt.Errorf("invalid dependency '%s'; expected 'name' or 'name <op> version'", s)
+}
+
+// Deb returns the dependency in the format of a debian control file, like 'libc6 (>= 2.34)'.
+func (d Dependency) Deb() string {
+	if d.Op == "" {
+		return d.Name
+	}
+
+	op := d.Op
+	switch op {
+	case "<":
+		op = "<<"
+	case ">":
+		op = ">>"
+	}
+
+	return fmt.Sprintf("%s (%s %s)", d.Name, op, d.Version)
+}
@@ -2,22 +2,15 @@ package fpm
 
 import (
 	"context"
-	"fmt"
 
 	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/backend"
-	"github.com/grafana/grafana-build/containers"
 	"github.com/grafana/grafana-build/e2e"
-	"github.com/grafana/grafana-build/frontend"
 	"github.com/grafana/grafana-build/gpg"
 )
 
-func VerifyDeb(ctx context.Context, d *dagger.Client, file *dagger.File, src *dagger.Directory, yarn *dagger.CacheVolume, distro backend.Distribution, enterprise bool) error {
-	nodeVersion, err := frontend.NodeVersion(d, src).Stdout(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get node version from source code: %w", err)
-	}
-
+// VerifyDeb installs the deb 'file' on 'image' and runs the e2e tests or the smoke test against it.
+func VerifyDeb(ctx context.Context, d *dagger.Client, file *dagger.File, distro backend.Distribution, enterprise bool, image string, opts e2e.ValidateOpts) error {
 	var (
 		platform = backend.Platform(distro)
 	)
@@ -25,7 +18,7 @@ func VerifyDeb(ctx context.Context, d *dagger.Client, file *dagger.File, src *da
 	// This grafana service runs in the background for the e2e tests
 	service := d.Container(dagger.ContainerOpts{
 		Platform: platform,
-	}).From("debian:latest").
+	}).From(image).
 		WithFile("/src/package.deb", file).
 		WithExec([]string{"apt-get", "update"}).
 		WithExec([]string{"apt-get", "install", "-y", "/src/package.deb"}).
@@ -39,19 +32,11 @@ func VerifyDeb(ctx context.Context, d *dagger.Client, file *dagger.File, src *da
 		WithExec([]string{"grafana-server"}).
 		WithExposedPort(3000).AsService()
 
-	if _, err := containers.ExitError(ctx, e2e.ValidatePackage(d, svc, src, yarn, nodeVersion)); err != nil {
-		return err
-	}
-
-	return nil
+	return e2e.Validate(ctx, d, svc, opts)
 }
 
-func VerifyRpm(ctx context.Context, d *dagger.Client, file *dagger.File, src *dagger.Directory, yarn *dagger.CacheVolume, distro backend.Distribution, enterprise, sign bool, pubkey, privkey, passphrase string) error {
-	nodeVersion, err := frontend.NodeVersion(d, src).Stdout(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get node version from source code: %w", err)
-	}
-
+// VerifyRpm installs the rpm 'file' on 'image' and runs the e2e tests or the smoke test against it.
+func VerifyRpm(ctx context.Context, d *dagger.Client, file *dagger.File, distro backend.Distribution, enterprise, sign bool, pubkey, privkey, passphrase, image string, opts e2e.ValidateOpts) error {
 	var (
 		platform = backend.Platform(distro)
 	)
@@ -59,7 +44,7 @@ func VerifyRpm(ctx context.Context, d *dagger.Client, file *dagger.File, src *da
 	// This grafana service runs in the background for the e2e tests
 	service := d.Container(dagger.ContainerOpts{
 		Platform: platform,
-	}).From("redhat/ubi8:latest").
+	}).From(image).
 		WithFile("/src/package.rpm", file).
 		WithExec([]string{"yum", "install", "-y", "/src/package.rpm"}).
 		WithWorkdir("/usr/share/grafana")
@@ -72,7 +57,7 @@ func VerifyRpm(ctx context.Context, d *dagger.Client, file *dagger.File, src *da
 		WithExec([]string{"grafana-server"}).
 		WithExposedPort(3000)
 
-	if _, err := containers.ExitError(ctx, e2e.ValidatePackage(d, service.AsService(), src, yarn, nodeVersion)); err != nil {
+	if err := e2e.Validate(ctx, d, service.AsService(), opts); err != nil {
 		return err
 	}
 	if !sign {
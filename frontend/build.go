@@ -1,16 +1,121 @@
 package frontend
 
 import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
 	"dagger.io/dagger"
 )
 
-func Build(builder *dagger.Container) *dagger.Directory {
-	public := builder.
-		WithExec([]string{"yarn", "run", "build"}).
-		WithExec([]string{"/bin/sh", "-c", "find /src/public -type d -name node_modules -print0 | xargs -0 rm -rf"}).
-		Directory("/src/public")
+// DefaultBuildScript is the script in Grafana's package.json that is used to build the frontend.
+const DefaultBuildScript = "build"
 
-	return public
+type SourcemapPolicy string
+
+const (
+	// SourcemapsKeep keeps whatever sourcemaps the build script produced.
+	SourcemapsKeep SourcemapPolicy = "keep"
+	// SourcemapsRemove deletes all '.map' files from the build output.
+	SourcemapsRemove SourcemapPolicy = "remove"
+	// SourcemapsHidden keeps the '.map' files but removes the 'sourceMappingURL' comments, so browsers don't load them.
+	// This is useful when the sourcemaps are only uploaded to an error tracking service.
+	SourcemapsHidden SourcemapPolicy = "hidden"
+)
+
+var (
+	ErrorInvalidSourcemapPolicy = errors.New("invalid sourcemap policy; expected 'keep', 'remove', or 'hidden'")
+	ErrorInvalidEnv             = errors.New("invalid environment variable; expected 'KEY=VALUE'")
+)
+
+func ParseSourcemapPolicy(s string) (SourcemapPolicy, error) {
+	switch p := SourcemapPolicy(s); p {
+	case SourcemapsKeep, SourcemapsRemove, SourcemapsHidden:
+		return p, nil
+	case "":
+		return SourcemapsKeep, nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorInvalidSourcemapPolicy, s)
+}
+
+// ParseEnv validates a list of 'KEY=VALUE' environment variables, ignoring empty values.
+func ParseEnv(env []string) ([]string, error) {
+	r := []string{}
+	for _, v := range env {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		if k, _, ok := strings.Cut(v, "="); !ok || k == "" {
+			return nil, fmt.Errorf("%w: '%s'", ErrorInvalidEnv, v)
+		}
+		r = append(r, v)
+	}
+
+	return r, nil
+}
+
+// BuildOpts change how the frontend is built. The zero value builds the frontend the same way as a release.
+type BuildOpts struct {
+	// Script is the package.json script that builds the frontend, like 'build' or 'dev'. Defaults to DefaultBuildScript.
+	Script string
+	// Env is a list of 'KEY=VALUE' environment variables that are set when running the script.
+	// Setting 'NODE_OPTIONS' replaces the default set in 'NodeContainer'.
+	Env        []string
+	Sourcemaps SourcemapPolicy
+}
+
+func (o *BuildOpts) script() string {
+	if o == nil || o.Script == "" {
+		return DefaultBuildScript
+	}
+
+	return o.Script
+}
+
+// ID returns a short identifier for the build options that can be used in file names. It is empty when the options are the defaults, so that
+// release builds keep their existing names.
+func (o *BuildOpts) ID() string {
+	if o == nil || (o.script() == DefaultBuildScript && len(o.Env) == 0 && (o.Sourcemaps == "" || o.Sourcemaps == SourcemapsKeep)) {
+		return ""
+	}
+
+	sourcemaps := o.Sourcemaps
+	if sourcemaps == "" {
+		sourcemaps = SourcemapsKeep
+	}
+
+	id := fmt.Sprintf("%s-%s", o.script(), sourcemaps)
+	if len(o.Env) != 0 {
+		id += fmt.Sprintf("-%x", sha256.Sum256([]byte(strings.Join(o.Env, "\n"))))[:9]
+	}
+
+	return id
+}
+
+func Build(builder *dagger.Container, opts *BuildOpts) *dagger.Directory {
+	if opts == nil {
+		opts = &BuildOpts{}
+	}
+
+	for _, v := range opts.Env {
+		k, val, _ := strings.Cut(v, "=")
+		builder = builder.WithEnvVariable(k, val)
+	}
+
+	builder = builder.
+		WithExec([]string{"yarn", "run", opts.script()}).
+		WithExec([]string{"/bin/sh", "-c", "find /src/public -type d -name node_modules -print0 | xargs -0 rm -rf"})
+
+	switch opts.Sourcemaps {
+	case SourcemapsRemove:
+		builder = builder.WithExec([]string{"/bin/sh", "-c", "find /src/public -type f -name '*.map' -delete"})
+	case SourcemapsHidden:
+		builder = builder.WithExec([]string{"/bin/sh", "-c", `find /src/public -type f \( -name '*.js' -o -name '*.css' \) -exec sed -i -E 's#//[#@] sourceMappingURL=.*$##; s#/\*[#@] sourceMappingURL=.*\*/##' {} +`})
+	}
+
+	return builder.Directory("/src/public")
 }
 
 func BuildPlugins(builder *dagger.Container) *dagger.Directory {
@@ -26,6 +131,8 @@ func BuildPlugins(builder *dagger.Container) *dagger.Directory {
 // WithYarnCache mounts the given YarnCacheDir in the provided container
 func WithYarnCache(container *dagger.Container, vol *dagger.CacheVolume) *dagger.Container {
 	yarnCacheDir := "/yarn/cache"
-	c := container.WithEnvVariable("YARN_CACHE_FOLDER", yarnCacheDir)
+	// The global cache is disabled so that yarn uses YARN_CACHE_FOLDER, even if the project's '.yarnrc.yml' enables it.
+	c := container.WithEnvVariable("YARN_CACHE_FOLDER", yarnCacheDir).
+		WithEnvVariable("YARN_ENABLE_GLOBAL_CACHE", "false")
 	return c.WithMountedCache(yarnCacheDir, vol)
 }
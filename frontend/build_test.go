@@ -0,0 +1,59 @@
+package frontend_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-build/frontend"
+)
+
+func TestBuildOptsID(t *testing.T) {
+	t.Run("Default options should not have an ID", func(t *testing.T) {
+		for _, v := range []*frontend.BuildOpts{nil, {}, {Script: "build", Sourcemaps: frontend.SourcemapsKeep}} {
+			if id := v.ID(); id != "" {
+				t.Fatalf("expected an empty ID for '%+v', got '%s'", v, id)
+			}
+		}
+	})
+
+	t.Run("Variants should have unique IDs", func(t *testing.T) {
+		dev := (&frontend.BuildOpts{Script: "dev"}).ID()
+		if dev != "dev-keep" {
+			t.Fatalf("expected 'dev-keep', got '%s'", dev)
+		}
+
+		a := (&frontend.BuildOpts{Env: []string{"PUBLIC_PATH=https://cdn.example.com/"}}).ID()
+		b := (&frontend.BuildOpts{Env: []string{"PUBLIC_PATH=https://cdn.example.org/"}}).ID()
+		if a == b || !strings.HasPrefix(a, "build-keep-") {
+			t.Fatalf("expected different IDs for different environments, got '%s' and '%s'", a, b)
+		}
+
+		if id := (&frontend.BuildOpts{Sourcemaps: frontend.SourcemapsRemove}).ID(); id != "build-remove" {
+			t.Fatalf("expected 'build-remove', got '%s'", id)
+		}
+	})
+}
+
+func TestParseEnv(t *testing.T) {
+	env, err := frontend.ParseEnv([]string{"NODE_OPTIONS=--max_old_space_size=4000", "", "EMPTY="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env) != 2 {
+		t.Fatalf("expected 2 variables, got %v", env)
+	}
+
+	if _, err := frontend.ParseEnv([]string{"NODE_ENV"}); !errors.Is(err, frontend.ErrorInvalidEnv) {
+		t.Fatalf("expected ErrorInvalidEnv, got '%v'", err)
+	}
+}
+
+func TestParseSourcemapPolicy(t *testing.T) {
+	if p, err := frontend.ParseSourcemapPolicy(""); err != nil || p != frontend.SourcemapsKeep {
+		t.Fatalf("expected the default policy to be 'keep', got '%s', '%v'", p, err)
+	}
+	if _, err := frontend.ParseSourcemapPolicy("inline"); !errors.Is(err, frontend.ErrorInvalidSourcemapPolicy) {
+		t.Fatalf("expected ErrorInvalidSourcemapPolicy, got '%v'", err)
+	}
+}
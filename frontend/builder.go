@@ -5,9 +5,9 @@ import (
 )
 
 // Builder mounts all of the necessary files to run yarn build commands and includes a yarn install exec
-func Builder(d *dagger.Client, platform dagger.Platform, src *dagger.Directory, nodeVersion string, cache *dagger.CacheVolume) *dagger.Container {
+func Builder(d *dagger.Client, platform dagger.Platform, src *dagger.Directory, node *Node, cache *dagger.CacheVolume) *dagger.Container {
 	container := WithYarnCache(
-		NodeContainer(d, NodeImage(nodeVersion), platform),
+		NodeContainer(d, node, platform),
 		cache,
 	).
 		WithDirectory("/src",
@@ -0,0 +1,282 @@
+package frontend
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+const (
+	// CacheControlImmutable is used for assets that have a content hash in their name; a new build will always produce a new name.
+	CacheControlImmutable = "public, max-age=31536000, immutable"
+	// CacheControlMutable is used for every other asset, like images and fonts without a hash, which can change between builds with the same name.
+	CacheControlMutable = "public, max-age=0, must-revalidate"
+
+	// CompressMinSize is the smallest file (in bytes) that is precompressed; smaller files are rarely smaller after compression.
+	CompressMinSize = 1024
+)
+
+// CompressibleExtensions are the extensions of text-based files that get '.gz' and '.br' siblings.
+// Images (other than svg) and woff / woff2 fonts are already compressed.
+var CompressibleExtensions = []string{".js", ".mjs", ".css", ".html", ".json", ".map", ".svg", ".txt", ".xml", ".ttf", ".eot", ".ico", ".wasm"}
+
+// Encodings are the precompressed siblings of an asset, keyed by the 'Content-Encoding' they should be served with.
+var Encodings = map[string]string{
+	"gzip": ".gz",
+	"br":   ".br",
+}
+
+// contentTypes are set explicitly so that the manifest doesn't depend on the mime.types of the machine that produced it.
+var contentTypes = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".eot":   "application/vnd.ms-fontobject",
+	".gif":   "image/gif",
+	".html":  "text/html; charset=utf-8",
+	".ico":   "image/x-icon",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".js":    "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".map":   "application/json",
+	".md":    "text/markdown; charset=utf-8",
+	".mjs":   "text/javascript; charset=utf-8",
+	".png":   "image/png",
+	".svg":   "image/svg+xml",
+	".ttf":   "font/ttf",
+	".txt":   "text/plain; charset=utf-8",
+	".wasm":  "application/wasm",
+	".webp":  "image/webp",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".xml":   "application/xml",
+}
+
+// ContentType returns the 'Content-Type' of an asset by its extension.
+func ContentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if v, ok := contentTypes[ext]; ok {
+		return v
+	}
+	if v := mime.TypeByExtension(ext); v != "" {
+		return v
+	}
+
+	return "application/octet-stream"
+}
+
+// IsImmutable returns true if the asset has a content hash in its name. Only files in 'build' (the webpack output) are considered, because
+// other files like 'img/...' are committed to the repository and keep their names.
+func IsImmutable(name string) bool {
+	if !strings.HasPrefix(name, "build/") {
+		return false
+	}
+
+	return ChunkName(name) != name
+}
+
+func isCompressible(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, v := range CompressibleExtensions {
+		if v == ext {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CDNScript compresses every compressible file in '/src/public' with gzip and brotli, keeping the original, and then prints the path, size,
+// and sha256 of every file (including the compressed ones), separated by tabs.
+func CDNScript() string {
+	patterns := make([]string, len(CompressibleExtensions))
+	for i, v := range CompressibleExtensions {
+		patterns[i] = "*" + v
+	}
+
+	return fmt.Sprintf(`set -e
+cd /src/public
+find . -type f | while read -r f; do
+  case "$f" in
+    %[1]s)
+      if [ "$(wc -c < "$f")" -ge %[2]d ]; then
+        gzip -9 -n -k -f "$f"
+        brotli -q 11 -k -f "$f"
+      fi
+      ;;
+  esac
+done
+find . -type f | sort | while read -r f; do
+  printf '%%s\t%%s\t%%s\n' "${f#./}" "$(wc -c < "$f")" "$(sha256sum "$f" | cut -d ' ' -f 1)"
+done`, strings.Join(patterns, "|"), CompressMinSize)
+}
+
+// CDN returns a container that has the precompressed assets in '/src/public'. Its stdout is the listing for ParseCDNListing.
+func CDN(d *dagger.Client, public *dagger.Directory) *dagger.Container {
+	return d.Container().From("alpine:3.18.4").
+		WithExec([]string{"apk", "add", "--no-cache", "brotli"}).
+		WithDirectory("/src/public", public).
+		WithExec([]string{"/bin/sh", "-c", CDNScript()})
+}
+
+type EncodedAsset struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type Asset struct {
+	Path         string `json:"path"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"`
+	ContentType  string `json:"content_type"`
+	CacheControl string `json:"cache_control"`
+	Immutable    bool   `json:"immutable"`
+
+	// Encodings is keyed by the 'Content-Encoding', like 'gzip' or 'br'.
+	Encodings map[string]EncodedAsset `json:"encodings,omitempty"`
+}
+
+// Manifest lists every asset in the 'public' folder that is uploaded to the CDN.
+type Manifest struct {
+	Version string  `json:"version"`
+	Assets  []Asset `json:"assets"`
+}
+
+type listedFile struct {
+	size   int64
+	sha256 string
+}
+
+// ParseCDNListing creates a Manifest from the output of CDNScript. Files that end with the extension of an encoding are added to the original
+// file's encodings, if the original file exists.
+func ParseCDNListing(version, out string) (*Manifest, error) {
+	files := map[string]listedFile{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected line in CDN listing: '%s'", line)
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected size in CDN listing: '%s': %w", line, err)
+		}
+
+		files[fields[0]] = listedFile{size: size, sha256: fields[2]}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Version: version, Assets: []Asset{}}
+	for name, f := range files {
+		if isEncoding(name, files) {
+			continue
+		}
+
+		asset := Asset{
+			Path:         name,
+			Size:         f.size,
+			SHA256:       f.sha256,
+			ContentType:  ContentType(name),
+			CacheControl: CacheControlMutable,
+			Immutable:    IsImmutable(name),
+		}
+		if asset.Immutable {
+			asset.CacheControl = CacheControlImmutable
+		}
+
+		for encoding, ext := range Encodings {
+			e, ok := files[name+ext]
+			if !ok {
+				continue
+			}
+			if asset.Encodings == nil {
+				asset.Encodings = map[string]EncodedAsset{}
+			}
+			asset.Encodings[encoding] = EncodedAsset{Path: name + ext, Size: e.size, SHA256: e.sha256}
+		}
+
+		m.Assets = append(m.Assets, asset)
+	}
+
+	sort.Slice(m.Assets, func(i, j int) bool {
+		return m.Assets[i].Path < m.Assets[j].Path
+	})
+
+	return m, nil
+}
+
+func isEncoding(name string, files map[string]listedFile) bool {
+	for _, ext := range Encodings {
+		if original, ok := strings.CutSuffix(name, ext); ok {
+			if _, ok := files[original]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// CacheControlGroup is a set of files that are uploaded with the same 'Cache-Control' header.
+type CacheControlGroup struct {
+	CacheControl string   `json:"cache_control"`
+	Files        []string `json:"files"`
+}
+
+// CacheControlMap separates the immutable, content-hashed assets from the mutable ones. The files include the precompressed siblings.
+type CacheControlMap struct {
+	Immutable CacheControlGroup `json:"immutable"`
+	Mutable   CacheControlGroup `json:"mutable"`
+}
+
+func (m *Manifest) CacheControl() *CacheControlMap {
+	c := &CacheControlMap{
+		Immutable: CacheControlGroup{CacheControl: CacheControlImmutable, Files: []string{}},
+		Mutable:   CacheControlGroup{CacheControl: CacheControlMutable, Files: []string{}},
+	}
+
+	for _, v := range m.Assets {
+		group := &c.Mutable
+		if v.Immutable {
+			group = &c.Immutable
+		}
+
+		group.Files = append(group.Files, v.Path)
+		for _, e := range v.Encodings {
+			group.Files = append(group.Files, e.Path)
+		}
+	}
+
+	sort.Strings(c.Immutable.Files)
+	sort.Strings(c.Mutable.Files)
+
+	return c
+}
+
+// Files returns every file in the manifest, including the precompressed siblings, relative to 'public'.
+func (m *Manifest) Files() []string {
+	files := []string{}
+	for _, v := range m.Assets {
+		files = append(files, v.Path)
+		for _, e := range v.Encodings {
+			files = append(files, e.Path)
+		}
+	}
+	sort.Strings(files)
+
+	return files
+}
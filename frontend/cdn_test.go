@@ -0,0 +1,90 @@
+package frontend_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grafana/grafana-build/frontend"
+)
+
+const cdnListing = "build/app.6b3c1d2e4f5a6b7c.js\t4000\tsha-app\n" +
+	"build/app.6b3c1d2e4f5a6b7c.js.br\t900\tsha-app-br\n" +
+	"build/app.6b3c1d2e4f5a6b7c.js.gz\t1000\tsha-app-gz\n" +
+	"build/runtime.js\t500\tsha-runtime\n" +
+	"img/grafana_icon.svg\t2048\tsha-icon\n" +
+	"img/grafana_icon.svg.gz\t800\tsha-icon-gz\n" +
+	"img/logo.png\t3000\tsha-logo\n" +
+	"test-data/archive.tar.gz\t100\tsha-archive\n"
+
+func TestParseCDNListing(t *testing.T) {
+	m, err := frontend.ParseCDNListing("11.1.0", cdnListing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{}
+	for _, v := range m.Assets {
+		paths = append(paths, v.Path)
+	}
+	expect := []string{"build/app.6b3c1d2e4f5a6b7c.js", "build/runtime.js", "img/grafana_icon.svg", "img/logo.png", "test-data/archive.tar.gz"}
+	if !reflect.DeepEqual(paths, expect) {
+		t.Fatalf("unexpected assets: %v", paths)
+	}
+
+	app := m.Assets[0]
+	if !app.Immutable || app.CacheControl != frontend.CacheControlImmutable || app.ContentType != "text/javascript; charset=utf-8" {
+		t.Fatalf("unexpected app asset: %+v", app)
+	}
+	if app.Encodings["br"].Path != "build/app.6b3c1d2e4f5a6b7c.js.br" || app.Encodings["gzip"].SHA256 != "sha-app-gz" {
+		t.Fatalf("unexpected app encodings: %+v", app.Encodings)
+	}
+
+	icon := m.Assets[2]
+	if icon.Immutable || icon.CacheControl != frontend.CacheControlMutable || icon.ContentType != "image/svg+xml" || len(icon.Encodings) != 1 {
+		t.Fatalf("unexpected icon asset: %+v", icon)
+	}
+
+	// A '.gz' file without an original is an asset itself.
+	if m.Assets[4].Encodings != nil {
+		t.Fatalf("unexpected archive encodings: %+v", m.Assets[4].Encodings)
+	}
+
+	if _, err := frontend.ParseCDNListing("11.1.0", "build/app.js\t1\n"); err == nil {
+		t.Fatal("expected an error for malformed output")
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	m, err := frontend.ParseCDNListing("11.1.0", cdnListing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := m.CacheControl()
+	immutable := []string{"build/app.6b3c1d2e4f5a6b7c.js", "build/app.6b3c1d2e4f5a6b7c.js.br", "build/app.6b3c1d2e4f5a6b7c.js.gz"}
+	if !reflect.DeepEqual(c.Immutable.Files, immutable) {
+		t.Fatalf("unexpected immutable files: %v", c.Immutable.Files)
+	}
+	if len(c.Mutable.Files) != 5 || c.Mutable.CacheControl != frontend.CacheControlMutable {
+		t.Fatalf("unexpected mutable files: %+v", c.Mutable)
+	}
+	if len(m.Files()) != 8 {
+		t.Fatalf("expected 8 files, got %v", m.Files())
+	}
+}
+
+func TestIsImmutable(t *testing.T) {
+	tests := map[string]bool{
+		"build/app.6b3c1d2e4f5a6b7c.js":   true,
+		"build/grafana.dark.0a1b2c3d.css": true,
+		"build/runtime.js":                false,
+		"img/icon.0a1b2c3d4e5f.svg":       false,
+		"views/index.html":                false,
+	}
+
+	for input, expect := range tests {
+		if res := frontend.IsImmutable(input); res != expect {
+			t.Fatalf("for '%s' got %t, expected %t", input, res, expect)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package frontend
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Error     *JUnitFailure `xml:"error,omitempty"`
+}
+
+func (c JUnitTestCase) Failed() bool {
+	return c.Failure != nil || c.Error != nil
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestSuites is the root element of a JUnit report, like the one written by jest-junit.
+type JUnitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	TestSuites []JUnitTestSuite `xml:"testsuite"`
+}
+
+func ParseJUnit(b []byte) (*JUnitTestSuites, error) {
+	s := &JUnitTestSuites{}
+	if err := xml.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("error parsing JUnit report: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *JUnitTestSuites) XML() ([]byte, error) {
+	b, err := xml.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
+
+// FailedTestSuite is a test suite (like a jest test file) and the names of the test cases in it that failed.
+type FailedTestSuite struct {
+	Name  string
+	Cases []string
+}
+
+func (s *JUnitTestSuites) FailedSuites() []FailedTestSuite {
+	r := []FailedTestSuite{}
+	for _, suite := range s.TestSuites {
+		failed := FailedTestSuite{Name: suite.Name}
+		for _, c := range suite.TestCases {
+			if c.Failed() {
+				failed.Cases = append(failed.Cases, c.Name)
+			}
+		}
+		if len(failed.Cases) > 0 || suite.Errors > 0 {
+			r = append(r, failed)
+		}
+	}
+
+	return r
+}
+
+func newJUnit(name string, suites []JUnitTestSuite) *JUnitTestSuites {
+	s := &JUnitTestSuites{Name: name, TestSuites: suites}
+	for i, suite := range suites {
+		for _, c := range suite.TestCases {
+			s.TestSuites[i].Tests++
+			if c.Failed() {
+				s.TestSuites[i].Failures++
+			}
+		}
+		s.Tests += s.TestSuites[i].Tests
+		s.Failures += s.TestSuites[i].Failures
+	}
+
+	return s
+}
+
+// CommandJUnit creates a JUnit report for a command that doesn't produce one, with a single test case that has the command output if it failed.
+func CommandJUnit(name, out string, failed bool) *JUnitTestSuites {
+	c := JUnitTestCase{Name: name, Classname: name}
+	if failed {
+		c.Failure = &JUnitFailure{Message: fmt.Sprintf("%s failed", name), Text: out}
+	}
+
+	return newJUnit(name, []JUnitTestSuite{{Name: name, TestCases: []JUnitTestCase{c}}})
+}
+
+// tsc reports errors like 'public/app/app.ts(10,5): error TS2322: Type 'string' is not assignable to type 'number'.'
+var tscErrorRegex = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): error (TS\d+): (.*)$`)
+
+// TypecheckJUnit creates a JUnit report from the output of 'tsc', with a test case for every file that has errors.
+// If the command failed without any recognizable errors, then the whole output is reported like CommandJUnit.
+func TypecheckJUnit(out string, failed bool) *JUnitTestSuites {
+	files := map[string][]string{}
+	for _, line := range strings.Split(out, "\n") {
+		m := tscErrorRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		files[m[1]] = append(files[m[1]], fmt.Sprintf("%s:%s:%s: %s: %s", m[1], m[2], m[3], m[4], m[5]))
+	}
+
+	if len(files) == 0 {
+		return CommandJUnit(string(TestSuiteTypecheck), out, failed)
+	}
+
+	names := make([]string, 0, len(files))
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	cases := make([]JUnitTestCase, len(names))
+	for i, v := range names {
+		cases[i] = JUnitTestCase{
+			Name:      v,
+			Classname: string(TestSuiteTypecheck),
+			Failure: &JUnitFailure{
+				Message: fmt.Sprintf("%d type error(s)", len(files[v])),
+				Text:    strings.Join(files[v], "\n"),
+			},
+		}
+	}
+
+	return newJUnit(string(TestSuiteTypecheck), []JUnitTestSuite{{Name: string(TestSuiteTypecheck), TestCases: cases}})
+}
+
+// TestResult is the result of running one test suite.
+type TestResult struct {
+	Suite    TestSuite
+	ExitCode int
+	// Output is the combined stdout / stderr of the suite.
+	Output string
+	JUnit  *JUnitTestSuites
+}
+
+func (r *TestResult) Failed() bool {
+	return r.ExitCode != 0
+}
+
+// Tail returns the last 'n' lines of the output.
+func (r *TestResult) Tail(n int) string {
+	lines := strings.Split(strings.TrimRight(r.Output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// TestResults reads the results of every suite in 'opts' from the TestReportsDir of a container created with 'Test'.
+// The returned directory is the reports directory with a 'junit-{suite}.xml' file for every suite.
+func TestResults(ctx context.Context, c *dagger.Container, opts *TestOpts) ([]*TestResult, *dagger.Directory, error) {
+	dir := c.Directory(TestReportsDir)
+	results := []*TestResult{}
+	for _, suite := range opts.RunSuites() {
+		exit, err := dir.File(string(suite) + ".exit").Contents(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		code, err := strconv.Atoi(strings.TrimSpace(exit))
+		if err != nil {
+			return nil, nil, fmt.Errorf("unexpected exit code for frontend test suite '%s': %w", suite, err)
+		}
+		out, err := dir.File(string(suite) + ".log").Contents(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		r := &TestResult{Suite: suite, ExitCode: code, Output: out}
+		junitName := fmt.Sprintf("junit-%s.xml", suite)
+
+		switch suite {
+		case TestSuiteJest:
+			// jest-junit doesn't write a report if jest failed to start, so fall back to the output.
+			if report, err := dir.File(junitName).Contents(ctx); err == nil {
+				r.JUnit, err = ParseJUnit([]byte(report))
+				if err != nil {
+					return nil, nil, err
+				}
+				results = append(results, r)
+				continue
+			}
+			r.JUnit = CommandJUnit(string(suite), out, r.Failed())
+		case TestSuiteTypecheck:
+			r.JUnit = TypecheckJUnit(out, r.Failed())
+		default:
+			r.JUnit = CommandJUnit(string(suite), out, r.Failed())
+		}
+
+		b, err := r.JUnit.XML()
+		if err != nil {
+			return nil, nil, err
+		}
+		dir = dir.WithNewFile(junitName, string(b))
+		results = append(results, r)
+	}
+
+	return results, dir, nil
+}
@@ -1,32 +1,154 @@
 package frontend
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"dagger.io/dagger"
 )
 
-// NodeVersionContainer returns a container whose `stdout` will return the node version from the '.nvmrc' file in the directory 'src'.
-func NodeVersion(d *dagger.Client, src *dagger.Directory) *dagger.Container {
-	return d.Container().From("alpine:3.17").
-		WithMountedDirectory("/src", src).
-		WithWorkdir("/src").
-		WithExec([]string{"cat", ".nvmrc"})
+const (
+	// DefaultNodeImage is the template for the image of node containers. Every '%s' is replaced with the node version.
+	DefaultNodeImage = "node:%s-slim"
+	// DefaultNodeTarballImage is the image that node is installed into when it's installed from a tarball.
+	DefaultNodeTarballImage = "debian:bookworm-slim"
+)
+
+// NodeVersionFromSource returns the node version in the '.nvmrc' file in the directory 'src', without the 'v' prefix.
+func NodeVersionFromSource(ctx context.Context, src *dagger.Directory) (string, error) {
+	v, err := src.File(".nvmrc").Contents(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get node version from source code: %w", err)
+	}
+
+	return TrimNodeVersion(v), nil
+}
+
+// TrimNodeVersion removes whitespace and the 'v' prefix from a node version.
+func TrimNodeVersion(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
+
+var nodeTarballRegex = regexp.MustCompile(`^node-v(\d+\.\d+\.\d+)-linux-[a-z0-9]+\.tar(\.gz|\.xz)?$`)
+
+// NodeTarballVersion returns the node version from the name of an official node release tarball, like 'node-v20.11.1-linux-x64.tar.xz'.
+func NodeTarballVersion(name string) (string, bool) {
+	m := nodeTarballRegex.FindStringSubmatch(name)
+	if m == nil {
+		return "", false
+	}
+
+	return m[1], true
 }
 
 func NodeImage(version string) string {
-	return fmt.Sprintf("node:%s-slim", strings.TrimPrefix(strings.TrimSpace(version), "v"))
+	return NodeImageFromTemplate(DefaultNodeImage, version)
 }
 
-// NodeContainer returns a docker container with everything set up that is needed to build or run frontend tests.
-func NodeContainer(d *dagger.Client, base string, platform dagger.Platform) *dagger.Container {
-	container := d.Container(dagger.ContainerOpts{
+// NodeImageFromTemplate replaces every '%s' in the image template 'tmpl' with the node version.
+func NodeImageFromTemplate(tmpl, version string) string {
+	return strings.ReplaceAll(tmpl, "%s", TrimNodeVersion(version))
+}
+
+// Node decides which version of node is used in node containers, and where it comes from.
+// The zero value (with a Version) uses the default node image.
+type Node struct {
+	// Version is the node version, without the 'v' prefix.
+	Version string
+	// SourceVersion is the version in the '.nvmrc' file of the source code. It's only used to decide if the version was overridden.
+	SourceVersion string
+	// Image is the template for the node image (see NodeImageFromTemplate). Defaults to DefaultNodeImage.
+	Image string
+
+	// Tarball is an official node release tarball. When it is set, node is installed from the tarball into TarballImage instead of using Image.
+	Tarball *dagger.File
+	// TarballImage defaults to DefaultNodeTarballImage.
+	TarballImage string
+
+	// YarnOffline disables the network for yarn, so that a package that is missing from a seeded yarn cache fails 'yarn install' instead of
+	// being downloaded. It doesn't change what is built, so it's not part of the ID.
+	YarnOffline bool
+}
+
+func (n *Node) image() string {
+	if n.Image == "" {
+		return DefaultNodeImage
+	}
+
+	return n.Image
+}
+
+func (n *Node) tarballImage() string {
+	if n.TarballImage == "" {
+		return DefaultNodeTarballImage
+	}
+
+	return n.TarballImage
+}
+
+// BaseImage returns the image that node containers start from.
+func (n *Node) BaseImage() string {
+	if n.Tarball != nil {
+		return n.tarballImage()
+	}
+
+	return NodeImageFromTemplate(n.image(), n.Version)
+}
+
+// ID returns a short identifier for the node setup that can be used in file names. It is empty when node is the version from '.nvmrc' in the
+// default image, so that release builds keep their existing names.
+func (n *Node) ID() string {
+	if n == nil || (n.Version == n.SourceVersion && n.image() == DefaultNodeImage && n.Tarball == nil) {
+		return ""
+	}
+
+	id := "node" + n.Version
+	if n.Tarball != nil {
+		return id + "-tarball"
+	}
+	if n.image() != DefaultNodeImage {
+		id += fmt.Sprintf("-%x", sha256.Sum256([]byte(n.image())))[:9]
+	}
+
+	return id
+}
+
+// NodeBase returns a container with node installed, either from the node image or from the node tarball.
+func NodeBase(d *dagger.Client, node *Node, platform dagger.Platform) *dagger.Container {
+	c := d.Container(dagger.ContainerOpts{
 		Platform: platform,
-	}).From(base).
+	}).From(node.BaseImage())
+
+	if node.Tarball == nil {
+		return c
+	}
+
+	// The official images have yarn 1 installed globally, which runs the yarn release that's checked in to the repository; corepack does
+	// the same using the 'packageManager' in package.json.
+	return c.
+		WithExec([]string{"apt-get", "update", "-yq"}).
+		WithExec([]string{"apt-get", "install", "-yq", "xz-utils"}).
+		WithMountedFile("/tmp/node.tar", node.Tarball).
+		WithExec([]string{"tar", "-xf", "/tmp/node.tar", "-C", "/usr/local", "--strip-components=1", "--no-same-owner"}).
+		WithoutMount("/tmp/node.tar").
+		WithExec([]string{"/bin/sh", "-c", fmt.Sprintf(`test "$(node --version)" = "v%s" || { echo "node tarball is not version %s: $(node --version)"; exit 1; }`, node.Version, node.Version)}).
+		WithEnvVariable("COREPACK_ENABLE_DOWNLOAD_PROMPT", "0").
+		WithExec([]string{"corepack", "enable"})
+}
+
+// NodeContainer returns a docker container with everything set up that is needed to build or run frontend tests.
+func NodeContainer(d *dagger.Client, node *Node, platform dagger.Platform) *dagger.Container {
+	container := NodeBase(d, node, platform).
 		WithExec([]string{"apt-get", "update", "-yq"}).
 		WithExec([]string{"apt-get", "install", "-yq", "make", "git", "g++", "python3"}).
 		WithEnvVariable("NODE_OPTIONS", "--max_old_space_size=8000")
 
+	if node.YarnOffline {
+		container = container.WithEnvVariable("YARN_ENABLE_NETWORK", "false")
+	}
+
 	return container
 }
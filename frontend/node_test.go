@@ -0,0 +1,77 @@
+package frontend_test
+
+import (
+	"strings"
+	"testing"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/frontend"
+)
+
+func TestNodeImageFromTemplate(t *testing.T) {
+	cases := map[string][2]string{
+		"node:20.11.1-slim":                       {frontend.DefaultNodeImage, "v20.11.1\n"},
+		"mirror.example.com/library/node:20.11.1": {"mirror.example.com/library/node:%s", "20.11.1"},
+		"mirror.example.com/node:lts":             {"mirror.example.com/node:lts", "20.11.1"},
+	}
+
+	for expect, v := range cases {
+		if img := frontend.NodeImageFromTemplate(v[0], v[1]); img != expect {
+			t.Fatalf("expected '%s' for template '%s' and version '%s', got '%s'", expect, v[0], v[1], img)
+		}
+	}
+}
+
+func TestNodeTarballVersion(t *testing.T) {
+	for name, expect := range map[string]string{
+		"node-v20.11.1-linux-x64.tar.xz":    "20.11.1",
+		"node-v22.0.0-linux-arm64.tar.gz":   "22.0.0",
+		"node-v18.19.0-linux-armv7l.tar":    "18.19.0",
+		"node-v20.11.1-darwin-x64.tar.gz":   "",
+		"node-v20.11.1-linux-x64.zip":       "",
+		"my-node-v20.11.1-linux-x64.tar.xz": "",
+	} {
+		v, ok := frontend.NodeTarballVersion(name)
+		if v != expect || ok != (expect != "") {
+			t.Fatalf("expected '%s' for '%s', got '%s' (%t)", expect, name, v, ok)
+		}
+	}
+}
+
+func TestNodeID(t *testing.T) {
+	t.Run("The version from .nvmrc in the default image should not have an ID", func(t *testing.T) {
+		for _, v := range []*frontend.Node{
+			nil,
+			{Version: "20.11.1", SourceVersion: "20.11.1"},
+			{Version: "20.11.1", SourceVersion: "20.11.1", Image: frontend.DefaultNodeImage},
+		} {
+			if id := v.ID(); id != "" {
+				t.Fatalf("expected an empty ID for '%+v', got '%s'", v, id)
+			}
+		}
+	})
+
+	t.Run("Overridden versions should have the version in the ID", func(t *testing.T) {
+		if id := (&frontend.Node{Version: "22.0.0", SourceVersion: "20.11.1"}).ID(); id != "node22.0.0" {
+			t.Fatalf("expected 'node22.0.0', got '%s'", id)
+		}
+	})
+
+	t.Run("Different images should have different IDs", func(t *testing.T) {
+		a := (&frontend.Node{Version: "20.11.1", SourceVersion: "20.11.1", Image: "mirror.example.com/node:%s-slim"}).ID()
+		b := (&frontend.Node{Version: "20.11.1", SourceVersion: "20.11.1", Image: "mirror.example.org/node:%s-slim"}).ID()
+		if a == b || !strings.HasPrefix(a, "node20.11.1-") {
+			t.Fatalf("expected different IDs for different images, got '%s' and '%s'", a, b)
+		}
+	})
+
+	t.Run("Tarballs should have an ID", func(t *testing.T) {
+		node := &frontend.Node{Version: "20.11.1", SourceVersion: "20.11.1", Tarball: &dagger.File{}}
+		if id := node.ID(); id != "node20.11.1-tarball" {
+			t.Fatalf("expected 'node20.11.1-tarball', got '%s'", id)
+		}
+		if img := node.BaseImage(); img != frontend.DefaultNodeTarballImage {
+			t.Fatalf("expected the tarball to be installed in '%s', got '%s'", frontend.DefaultNodeTarballImage, img)
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/containers"
+)
+
+// NPMPublishPolicy decides what happens when a package version is already in the registry.
+type NPMPublishPolicy string
+
+const (
+	// NPMPublishSkip skips publishing the package, but still adds the dist-tags. This allows re-running a publish that partially failed.
+	NPMPublishSkip NPMPublishPolicy = "skip"
+	// NPMPublishFail fails with ErrorAlreadyPublished.
+	NPMPublishFail NPMPublishPolicy = "fail"
+)
+
+var (
+	ErrorAlreadyPublished        = errors.New("npm package version is already published")
+	ErrorInvalidNPMPublishPolicy = errors.New("invalid npm publish policy; expected 'skip' or 'fail'")
+)
+
+func ParseNPMPublishPolicy(s string) (NPMPublishPolicy, error) {
+	switch p := NPMPublishPolicy(s); p {
+	case NPMPublishSkip, NPMPublishFail:
+		return p, nil
+	case "":
+		return NPMPublishFail, nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorInvalidNPMPublishPolicy, s)
+}
+
+// provenanceEnvPrefixes are the environment variables that npm reads to create provenance statements in GitHub Actions.
+var provenanceEnvPrefixes = []string{"GITHUB_", "ACTIONS_", "RUNNER_"}
+
+// provenanceSecrets are provenance environment variables that are set as secrets instead of plain environment variables.
+var provenanceSecrets = []string{"ACTIONS_ID_TOKEN_REQUEST_TOKEN", "ACTIONS_RUNTIME_TOKEN"}
+
+// ProvenanceEnv returns the environment variables from 'environ' (like 'os.Environ()') that npm needs to create provenance statements.
+func ProvenanceEnv(environ []string) map[string]string {
+	env := map[string]string{}
+	for _, v := range environ {
+		k, val, ok := strings.Cut(v, "=")
+		if !ok {
+			continue
+		}
+		if k == "CI" {
+			env[k] = val
+			continue
+		}
+		for _, p := range provenanceEnvPrefixes {
+			if strings.HasPrefix(k, p) {
+				env[k] = val
+			}
+		}
+	}
+
+	return env
+}
+
+type NPMPublishOpts struct {
+	// Registry is the host of the registry, like 'registry.npmjs.org', or a URL like 'http://verdaccio:4873'. Hosts use 'https'.
+	Registry string
+	Token    string
+	// Tags are the dist-tags of the published version; the first tag is used for 'npm publish'.
+	Tags []string
+
+	DryRun bool
+	// Provenance publishes with '--provenance'. ProvenanceEnv has the CI environment that npm uses to create the provenance statement.
+	Provenance    bool
+	ProvenanceEnv map[string]string
+
+	IfPublished NPMPublishPolicy
+
+	// RegistryService is bound to the host of the registry URL when set, for registries that run in dagger (like Verdaccio).
+	RegistryService *dagger.Service
+
+	// Node is the node setup of the container that runs npm. If it's nil, the 'lts' version of DefaultNodeImage is used.
+	Node *Node
+}
+
+func (o *NPMPublishOpts) node() *Node {
+	if o.Node == nil {
+		return &Node{Version: "lts"}
+	}
+
+	return o.Node
+}
+
+// RegistryURL returns the registry as a URL, adding 'https://' to hosts.
+func (o *NPMPublishOpts) RegistryURL() string {
+	if strings.Contains(o.Registry, "://") {
+		return strings.TrimSuffix(o.Registry, "/")
+	}
+
+	return "https://" + strings.TrimSuffix(o.Registry, "/")
+}
+
+// authKey returns the npm config key for the registry token, like '//registry.npmjs.org/:_authToken'.
+func (o *NPMPublishOpts) authKey() string {
+	u := o.RegistryURL()
+	_, u, _ = strings.Cut(u, "://")
+	return fmt.Sprintf("//%s/:_authToken", u)
+}
+
+// PublishArgs returns the 'npm publish' command for the package at 'pkg'.
+func (o *NPMPublishOpts) PublishArgs(pkg string) []string {
+	args := []string{"npm", "publish", pkg, "--registry", o.RegistryURL()}
+	if len(o.Tags) > 0 {
+		args = append(args, "--tag", o.Tags[0])
+	}
+	if o.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if o.Provenance {
+		args = append(args, "--provenance")
+	}
+
+	return args
+}
+
+// NPMPublishContainer returns a node container that is authenticated with the registry.
+func NPMPublishContainer(d *dagger.Client, opts *NPMPublishOpts) (*dagger.Container, error) {
+	c := NodeBase(d, opts.node(), "").
+		WithSecretVariable("NPM_TOKEN", d.SetSecret("npm-token", opts.Token))
+
+	if opts.RegistryService != nil {
+		u, err := url.Parse(opts.RegistryURL())
+		if err != nil {
+			return nil, err
+		}
+		c = c.WithServiceBinding(u.Hostname(), opts.RegistryService)
+	}
+
+	if opts.Provenance {
+		for k, v := range opts.ProvenanceEnv {
+			if slices.Contains(provenanceSecrets, k) {
+				c = c.WithSecretVariable(k, d.SetSecret(strings.ToLower(k), v))
+				continue
+			}
+			c = c.WithEnvVariable(k, v)
+		}
+	}
+
+	return c.
+		WithExec([]string{"/bin/sh", "-c", fmt.Sprintf("npm config set '%s' \"$NPM_TOKEN\"", opts.authKey())}).
+		// The registry changes outside of dagger, so the results of these commands should never be cached.
+		WithEnvVariable("CACHEBUSTER", time.Now().String()), nil
+}
+
+// NPMViewScript returns a shell script that prints the version if 'name@version' is in the registry and nothing if it isn't. 'npm view'
+// fails with E404 if the package doesn't exist; any other error, like an authentication, network, or registry error, fails the script
+// with npm's output, so that it isn't mistaken for an unpublished version.
+func NPMViewScript(registry, name, version string) string {
+	return fmt.Sprintf(`dir=$(mktemp -d)
+if npm view '%s@%s' version --registry '%s' >"$dir/out" 2>"$dir/err"; then
+  cat "$dir/out"
+  exit 0
+fi
+if grep -q E404 "$dir/err"; then
+  exit 0
+fi
+cat "$dir/err" >&2
+exit 1
+`, name, version, registry)
+}
+
+// NPMIsPublished returns true if 'name@version' is in the registry. 'c' should be created with NPMPublishContainer.
+func NPMIsPublished(ctx context.Context, c *dagger.Container, opts *NPMPublishOpts, name, version string) (bool, error) {
+	out, err := c.WithExec([]string{"/bin/sh", "-c", NPMViewScript(opts.RegistryURL(), name, version)}).Stdout(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error checking if %s@%s is published: %w", name, version, err)
+	}
+
+	return strings.TrimSpace(out) == version, nil
+}
+
+// PublishNPM publishes an npm package tarball. If the version is already published, then IfPublished decides whether to skip it or fail.
+// With DryRun, nothing is changed in the registry, but 'npm publish --dry-run' still runs.
+func PublishNPM(ctx context.Context, d *dagger.Client, log *slog.Logger, pkg *dagger.File, opts *NPMPublishOpts) (string, error) {
+	src := containers.ExtractedArchive(d, pkg)
+
+	version, err := containers.GetJSONValue(ctx, d, src, "package.json", "version")
+	if err != nil {
+		return "", err
+	}
+
+	name, err := containers.GetJSONValue(ctx, d, src, "package.json", "name")
+	if err != nil {
+		return "", err
+	}
+	log = log.With("package", name, "version", version, "registry", opts.RegistryURL(), "dry-run", opts.DryRun)
+
+	c, err := NPMPublishContainer(d, opts)
+	if err != nil {
+		return "", err
+	}
+	c = c.WithFile("/pkg.tgz", pkg)
+
+	published, err := NPMIsPublished(ctx, c, opts, name, version)
+	if err != nil {
+		return "", err
+	}
+
+	if published {
+		if opts.IfPublished != NPMPublishSkip {
+			return "", fmt.Errorf("%w: %s@%s", ErrorAlreadyPublished, name, version)
+		}
+		log.Warn("version is already published; skipping")
+		// Still add every tag; 'npm dist-tag add' doesn't fail if the tag already points to this version.
+		return addDistTags(ctx, c, log, opts, name, version, opts.Tags)
+	}
+
+	log.Info("publishing package", "tags", opts.Tags, "provenance", opts.Provenance)
+	c = c.WithExec(opts.PublishArgs("/pkg.tgz"))
+
+	if len(opts.Tags) > 1 {
+		return addDistTags(ctx, c, log, opts, name, version, opts.Tags[1:])
+	}
+
+	return c.Stdout(ctx)
+}
+
+func addDistTags(ctx context.Context, c *dagger.Container, log *slog.Logger, opts *NPMPublishOpts, name, version string, tags []string) (string, error) {
+	for _, tag := range tags {
+		if opts.DryRun {
+			log.Info("dry-run: not adding dist-tag", "tag", tag)
+			continue
+		}
+		c = c.WithExec([]string{"npm", "dist-tag", "add", fmt.Sprintf("%s@%s", name, version), tag, "--registry", opts.RegistryURL()})
+	}
+
+	return c.Stdout(ctx)
+}
@@ -0,0 +1,174 @@
+package frontend_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/frontend"
+)
+
+func TestParseNPMPublishPolicy(t *testing.T) {
+	if p, err := frontend.ParseNPMPublishPolicy(""); err != nil || p != frontend.NPMPublishFail {
+		t.Fatalf("expected the default policy to be 'fail', got '%s' / '%v'", p, err)
+	}
+	if p, err := frontend.ParseNPMPublishPolicy("skip"); err != nil || p != frontend.NPMPublishSkip {
+		t.Fatalf("expected 'skip', got '%s' / '%v'", p, err)
+	}
+	if _, err := frontend.ParseNPMPublishPolicy("overwrite"); !errors.Is(err, frontend.ErrorInvalidNPMPublishPolicy) {
+		t.Fatalf("expected ErrorInvalidNPMPublishPolicy, got '%v'", err)
+	}
+}
+
+func TestNPMPublishOpts(t *testing.T) {
+	opts := &frontend.NPMPublishOpts{Registry: "registry.npmjs.org", Tags: []string{"latest", "next"}, DryRun: true, Provenance: true}
+	if u := opts.RegistryURL(); u != "https://registry.npmjs.org" {
+		t.Fatalf("unexpected registry URL '%s'", u)
+	}
+
+	expect := []string{"npm", "publish", "/pkg.tgz", "--registry", "https://registry.npmjs.org", "--tag", "latest", "--dry-run", "--provenance"}
+	if args := opts.PublishArgs("/pkg.tgz"); !reflect.DeepEqual(args, expect) {
+		t.Fatalf("unexpected publish args: %v", args)
+	}
+
+	local := &frontend.NPMPublishOpts{Registry: "http://verdaccio:4873/"}
+	if u := local.RegistryURL(); u != "http://verdaccio:4873" {
+		t.Fatalf("unexpected registry URL '%s'", u)
+	}
+}
+
+func TestProvenanceEnv(t *testing.T) {
+	env := frontend.ProvenanceEnv([]string{
+		"CI=true",
+		"GITHUB_ACTIONS=true",
+		"ACTIONS_ID_TOKEN_REQUEST_URL=https://example.com",
+		"HOME=/root",
+		"NPM_TOKEN=secret",
+	})
+
+	expect := map[string]string{
+		"CI":                           "true",
+		"GITHUB_ACTIONS":               "true",
+		"ACTIONS_ID_TOKEN_REQUEST_URL": "https://example.com",
+	}
+	if !reflect.DeepEqual(env, expect) {
+		t.Fatalf("unexpected env: %v", env)
+	}
+}
+
+func TestNPMViewScript(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("this test needs sh")
+	}
+
+	// run runs the script with an 'npm' that prints 'stdout' and 'stderr' and exits with 'code'.
+	run := func(t *testing.T, stdout, stderr string, code int) (string, error) {
+		t.Helper()
+		dir := t.TempDir()
+		npm := fmt.Sprintf("#!/bin/sh\nprintf '%s'\nprintf '%s' >&2\nexit %d\n", stdout, stderr, code)
+		if err := os.WriteFile(filepath.Join(dir, "npm"), []byte(npm), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := exec.Command(sh, "-c", frontend.NPMViewScript("https://registry.npmjs.org", "@grafana/data", "11.1.0"))
+		cmd.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		out, err := cmd.Output()
+		return string(out), err
+	}
+
+	t.Run("A published version should be printed", func(t *testing.T) {
+		if out, err := run(t, "11.1.0\\n", "", 0); err != nil || out != "11.1.0\n" {
+			t.Fatalf("expected '11.1.0', got '%s' / '%v'", out, err)
+		}
+	})
+
+	t.Run("E404 should not be an error", func(t *testing.T) {
+		if out, err := run(t, "", "npm ERR! code E404\\n", 1); err != nil || out != "" {
+			t.Fatalf("expected no output and no error, got '%s' / '%v'", out, err)
+		}
+	})
+
+	t.Run("Other errors should be an error", func(t *testing.T) {
+		if _, err := run(t, "", "npm ERR! code E401\\n", 1); err == nil {
+			t.Fatal("expected an error for an authentication error")
+		}
+	})
+}
+
+// TestPublishNPMVerdaccio publishes a package to a local Verdaccio registry. It needs a dagger engine, so it only runs with 'dagger run go test';
+// the 'npm-publish-test' job runs it in CI.
+func TestPublishNPMVerdaccio(t *testing.T) {
+	if _, ok := os.LookupEnv("DAGGER_SESSION_PORT"); !ok || testing.Short() {
+		t.Skip("this test needs a dagger session; run it with 'dagger run go test'")
+	}
+
+	ctx := context.Background()
+	d, err := dagger.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	pkgPath := func(version string) *dagger.File {
+		p := filepath.Join(t.TempDir(), "pkg.tgz")
+		buf := npmTarball(t, map[string]string{
+			"package.json": `{"name": "@grafana/test-package", "version": "` + version + `", "main": "index.js"}`,
+			"index.js":     "module.exports = {};",
+		})
+		if err := os.WriteFile(p, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return d.Host().File(p)
+	}
+
+	svc := frontend.Verdaccio(d)
+	token, err := frontend.VerdaccioToken(ctx, d, svc, "grafana-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := func(policy frontend.NPMPublishPolicy, dryRun bool) *frontend.NPMPublishOpts {
+		return &frontend.NPMPublishOpts{
+			Registry:        "http://verdaccio:4873",
+			Token:           token,
+			Tags:            []string{"latest", "next"},
+			DryRun:          dryRun,
+			IfPublished:     policy,
+			RegistryService: svc,
+		}
+	}
+	log := slog.Default()
+
+	if _, err := frontend.PublishNPM(ctx, d, log, pkgPath("1.0.0"), opts(frontend.NPMPublishFail, false)); err != nil {
+		t.Fatalf("error publishing: %v", err)
+	}
+	if _, err := frontend.PublishNPM(ctx, d, log, pkgPath("1.0.0"), opts(frontend.NPMPublishFail, false)); !errors.Is(err, frontend.ErrorAlreadyPublished) {
+		t.Fatalf("expected ErrorAlreadyPublished, got '%v'", err)
+	}
+	if _, err := frontend.PublishNPM(ctx, d, log, pkgPath("1.0.0"), opts(frontend.NPMPublishSkip, false)); err != nil {
+		t.Fatalf("expected already published versions to be skipped, got '%v'", err)
+	}
+
+	if _, err := frontend.PublishNPM(ctx, d, log, pkgPath("1.0.1"), opts(frontend.NPMPublishFail, true)); err != nil {
+		t.Fatalf("error publishing with dry-run: %v", err)
+	}
+	c, err := frontend.NPMPublishContainer(d, opts(frontend.NPMPublishFail, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := frontend.NPMIsPublished(ctx, c, opts(frontend.NPMPublishFail, false), "@grafana/test-package", "1.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published {
+		t.Fatal("dry-run should not publish the package")
+	}
+}
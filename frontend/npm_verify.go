@@ -0,0 +1,224 @@
+package frontend
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// NPMPackagePrefix is the scope of every npm package that Grafana publishes.
+const NPMPackagePrefix = "@grafana/"
+
+var ErrorInvalidNPMPackage = errors.New("invalid npm package")
+
+// PackageJSON has the fields of an npm package's 'package.json' that are verified.
+type PackageJSON struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Main    string `json:"main"`
+	Module  string `json:"module"`
+	Types   string `json:"types"`
+	Typings string `json:"typings"`
+
+	Dependencies         map[string]string `json:"dependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// ReadNPMTarball reads the 'package.json' and the list of files from an npm package tarball. The file names are relative to the package,
+// without the 'package/' prefix.
+func ReadNPMTarball(r io.Reader) (*PackageJSON, []string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	var (
+		pkg   *PackageJSON
+		files = []string{}
+		tr    = tar.NewReader(gz)
+	)
+
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// npm and yarn put every file in a 'package' folder.
+		_, name, _ := strings.Cut(path.Clean(h.Name), "/")
+		files = append(files, name)
+
+		if name != "package.json" {
+			continue
+		}
+		pkg = &PackageJSON{}
+		if err := json.NewDecoder(tr).Decode(pkg); err != nil {
+			return nil, nil, fmt.Errorf("%w: error parsing package.json: %s", ErrorInvalidNPMPackage, err)
+		}
+	}
+
+	if pkg == nil {
+		return nil, nil, fmt.Errorf("%w: package.json not found", ErrorInvalidNPMPackage)
+	}
+
+	sort.Strings(files)
+	return pkg, files, nil
+}
+
+// entrypointExists returns true if 'entry' is in 'files', using the same extension / index lookups as node's 'require' for files without
+// an extension.
+func entrypointExists(entry string, files []string) bool {
+	entry = path.Clean(strings.TrimPrefix(entry, "./"))
+	for _, v := range []string{entry, entry + ".js", entry + ".json", path.Join(entry, "index.js")} {
+		i := sort.SearchStrings(files, v)
+		if i < len(files) && files[i] == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckNPMPackage checks that a package has the expected name prefix and version, that its entrypoints are in the tarball, and that no
+// dependency still uses the 'workspace:' protocol.
+func CheckNPMPackage(pkg *PackageJSON, files []string, prefix, version string) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s: %s", ErrorInvalidNPMPackage, pkg.Name, fmt.Sprintf(format, args...))
+	}
+
+	if !strings.HasPrefix(pkg.Name, prefix) {
+		return invalid("name does not start with '%s'", prefix)
+	}
+
+	version = strings.TrimPrefix(version, "v")
+	if pkg.Version != version {
+		return invalid("expected version '%s', got '%s'", version, pkg.Version)
+	}
+
+	entrypoints := map[string]string{
+		"main":    pkg.Main,
+		"module":  pkg.Module,
+		"types":   pkg.Types,
+		"typings": pkg.Typings,
+	}
+	for _, field := range []string{"main", "module", "types", "typings"} {
+		entry := entrypoints[field]
+		if entry == "" {
+			continue
+		}
+		if !entrypointExists(entry, files) {
+			return invalid("'%s' entrypoint '%s' is not in the tarball", field, entry)
+		}
+	}
+
+	deps := map[string]map[string]string{
+		"dependencies":         pkg.Dependencies,
+		"peerDependencies":     pkg.PeerDependencies,
+		"optionalDependencies": pkg.OptionalDependencies,
+	}
+	for _, field := range []string{"dependencies", "peerDependencies", "optionalDependencies"} {
+		names := make([]string, 0, len(deps[field]))
+		for k := range deps[field] {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if v := deps[field][name]; strings.HasPrefix(v, "workspace:") {
+				return invalid("%s '%s' uses the workspace protocol ('%s')", field, name, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// NPMImportScript returns a shell script that installs every tarball in '/src/npm-packages' into a new project and imports each package
+// with node. 'names' are the names of the packages.
+func NPMImportScript(names []string) string {
+	lines := []string{
+		"set -e",
+		"mkdir -p /scratch && cd /scratch",
+		"npm init -y > /dev/null",
+		// The packages depend on each other with their exact version, which isn't published yet, so they're all installed together.
+		"npm install --no-audit --no-fund /src/npm-packages/*.tgz",
+	}
+	for _, v := range names {
+		lines = append(lines, fmt.Sprintf("node -e %s", strconv.Quote(fmt.Sprintf("import('%[1]s').then(() => console.log('imported %[1]s'))", v))))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// VerifyNPMPackages checks every '.tgz' in the 'npm-packages' directory 'dir' with CheckNPMPackage, and then installs and imports
+// them in a node container.
+func VerifyNPMPackages(ctx context.Context, d *dagger.Client, dir *dagger.Directory, node *Node, version string) error {
+	tmp, err := os.MkdirTemp("", "npm-packages-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := dir.Export(ctx, tmp); err != nil {
+		return err
+	}
+
+	tarballs, err := filepath.Glob(filepath.Join(tmp, "*.tgz"))
+	if err != nil {
+		return err
+	}
+	if len(tarballs) == 0 {
+		return fmt.Errorf("%w: no tarballs in npm-packages", ErrorInvalidNPMPackage)
+	}
+
+	names := []string{}
+	for _, v := range tarballs {
+		pkg, err := verifyNPMTarball(v, version)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(v), err)
+		}
+		names = append(names, pkg.Name)
+	}
+
+	_, err = NodeBase(d, node, "").
+		WithMountedDirectory("/src/npm-packages", dir).
+		WithExec([]string{"/bin/sh", "-c", NPMImportScript(names)}).
+		Sync(ctx)
+
+	return err
+}
+
+func verifyNPMTarball(name, version string) (*PackageJSON, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pkg, files, err := ReadNPMTarball(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg, CheckNPMPackage(pkg, files, NPMPackagePrefix, version)
+}
@@ -0,0 +1,107 @@
+package frontend_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-build/frontend"
+)
+
+func npmTarball(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: "package/" + name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
+
+func TestReadNPMTarball(t *testing.T) {
+	buf := npmTarball(t, map[string]string{
+		"package.json":      `{"name": "@grafana/data", "version": "11.1.0", "main": "dist/index.js"}`,
+		"dist/index.js":     "",
+		"dist/index.d.ts":   "",
+		"dist/esm/index.js": "",
+	})
+
+	pkg, files, err := frontend.ReadNPMTarball(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Name != "@grafana/data" || pkg.Version != "11.1.0" {
+		t.Fatalf("unexpected package.json: %+v", pkg)
+	}
+	if !reflect.DeepEqual(files, []string{"dist/esm/index.js", "dist/index.d.ts", "dist/index.js", "package.json"}) {
+		t.Fatalf("unexpected files: %v", files)
+	}
+
+	if _, _, err := frontend.ReadNPMTarball(npmTarball(t, map[string]string{"index.js": ""})); !errors.Is(err, frontend.ErrorInvalidNPMPackage) {
+		t.Fatalf("expected ErrorInvalidNPMPackage without package.json, got '%v'", err)
+	}
+}
+
+func TestCheckNPMPackage(t *testing.T) {
+	files := []string{"dist/esm/index.js", "dist/index.d.ts", "dist/index.js", "package.json"}
+	valid := func() *frontend.PackageJSON {
+		return &frontend.PackageJSON{
+			Name:         "@grafana/ui",
+			Version:      "11.1.0",
+			Main:         "./dist/index",
+			Module:       "dist/esm/index.js",
+			Types:        "dist/index.d.ts",
+			Dependencies: map[string]string{"@grafana/data": "11.1.0"},
+		}
+	}
+
+	if err := frontend.CheckNPMPackage(valid(), files, frontend.NPMPackagePrefix, "v11.1.0"); err != nil {
+		t.Fatalf("expected a valid package, got '%v'", err)
+	}
+
+	tests := map[string]struct {
+		modify func(p *frontend.PackageJSON)
+		expect string
+	}{
+		"name":      {func(p *frontend.PackageJSON) { p.Name = "grafana-ui" }, "name does not start with '@grafana/'"},
+		"version":   {func(p *frontend.PackageJSON) { p.Version = "11.1.0-pre" }, "expected version '11.1.0', got '11.1.0-pre'"},
+		"main":      {func(p *frontend.PackageJSON) { p.Main = "dist/main.js" }, "'main' entrypoint 'dist/main.js'"},
+		"types":     {func(p *frontend.PackageJSON) { p.Types = "dist/types.d.ts" }, "'types' entrypoint"},
+		"workspace": {func(p *frontend.PackageJSON) { p.PeerDependencies = map[string]string{"@grafana/data": "workspace:*"} }, "peerDependencies '@grafana/data' uses the workspace protocol"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := valid()
+			test.modify(p)
+			err := frontend.CheckNPMPackage(p, files, frontend.NPMPackagePrefix, "11.1.0")
+			if !errors.Is(err, frontend.ErrorInvalidNPMPackage) || !strings.Contains(err.Error(), test.expect) {
+				t.Fatalf("expected an error containing '%s', got '%v'", test.expect, err)
+			}
+		})
+	}
+}
+
+func TestNPMImportScript(t *testing.T) {
+	script := frontend.NPMImportScript([]string{"@grafana/data", "@grafana/ui"})
+	if !strings.Contains(script, `node -e "import('@grafana/ui').then(() => console.log('imported @grafana/ui'))"`) {
+		t.Fatalf("unexpected script:\n%s", script)
+	}
+}
@@ -0,0 +1,287 @@
+package frontend
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// SizesScript prints the raw, gzip, and brotli sizes of every JavaScript and CSS file in 'public/build', one file per line, separated by tabs.
+const SizesScript = `cd /src/public/build && find . -type f \( -name '*.js' -o -name '*.css' \) | sort | while read -r f; do
+  printf '%s\t%s\t%s\t%s\n' "${f#./}" "$(wc -c < "$f")" "$(gzip -9 -c "$f" | wc -c)" "$(brotli -q 11 -c "$f" | wc -c)"
+done`
+
+// Sizes returns the output of the SizesScript for the built frontend in 'public'.
+func Sizes(d *dagger.Client, public *dagger.Directory) *dagger.Container {
+	return d.Container().From("alpine:3.18.4").
+		WithExec([]string{"apk", "add", "--no-cache", "brotli"}).
+		WithMountedDirectory("/src/public", public).
+		WithExec([]string{"/bin/sh", "-c", SizesScript})
+}
+
+var ErrorBudgetExceeded = errors.New("frontend bundle size budget exceeded")
+
+type Size struct {
+	Raw    int64 `json:"raw"`
+	Gzip   int64 `json:"gzip"`
+	Brotli int64 `json:"brotli"`
+}
+
+func (s Size) sub(o Size) Size {
+	return Size{Raw: s.Raw - o.Raw, Gzip: s.Gzip - o.Gzip, Brotli: s.Brotli - o.Brotli}
+}
+
+type Chunk struct {
+	// Name is the chunk file name without the content hash, so that the same chunk can be compared between builds.
+	Name string `json:"name"`
+	File string `json:"file"`
+	Size
+}
+
+type ChunkDiff struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Before Size   `json:"before"`
+	After  Size   `json:"after"`
+	Delta  Size   `json:"delta"`
+}
+
+type Report struct {
+	Version string  `json:"version"`
+	Total   Size    `json:"total"`
+	Chunks  []Chunk `json:"chunks"`
+
+	// Diff is only set when the report was compared against a previous report.
+	Diff *ReportDiff `json:"diff,omitempty"`
+}
+
+type ReportDiff struct {
+	PreviousVersion string      `json:"previous_version"`
+	Total           Size        `json:"total"`
+	Chunks          []ChunkDiff `json:"chunks"`
+}
+
+// webpack adds a content hash to chunk file names, like 'app.6b3c1d2e4f5a6b7c.js'
+var contentHashRegex = regexp.MustCompile(`\.[0-9a-f]{8,}(\.|$)`)
+
+// ChunkName removes the content hash from a chunk file name.
+func ChunkName(file string) string {
+	dir, base := path.Split(file)
+	return dir + contentHashRegex.ReplaceAllString(base, "$1")
+}
+
+// ParseSizes creates a Report from the output of SizesScript.
+func ParseSizes(version, out string) (*Report, error) {
+	r := &Report{Version: version, Chunks: []Chunk{}}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected line in sizes output: '%s'", line)
+		}
+
+		var sizes [3]int64
+		for i, v := range fields[1:] {
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected size in sizes output: '%s': %w", line, err)
+			}
+			sizes[i] = n
+		}
+
+		c := Chunk{
+			Name: ChunkName(fields[0]),
+			File: fields[0],
+			Size: Size{Raw: sizes[0], Gzip: sizes[1], Brotli: sizes[2]},
+		}
+		r.Chunks = append(r.Chunks, c)
+		r.Total.Raw += c.Raw
+		r.Total.Gzip += c.Gzip
+		r.Total.Brotli += c.Brotli
+	}
+
+	sort.Slice(r.Chunks, func(i, j int) bool {
+		return r.Chunks[i].Name < r.Chunks[j].Name
+	})
+
+	return r, scanner.Err()
+}
+
+// Compare sets the Diff of the report to the differences between 'previous' and this report.
+func (r *Report) Compare(previous *Report) {
+	before := map[string]Size{}
+	for _, v := range previous.Chunks {
+		before[v.Name] = v.Size
+	}
+
+	diff := &ReportDiff{
+		PreviousVersion: previous.Version,
+		Total:           r.Total.sub(previous.Total),
+		Chunks:          []ChunkDiff{},
+	}
+
+	for _, v := range r.Chunks {
+		b, ok := before[v.Name]
+		delete(before, v.Name)
+
+		status := "changed"
+		if !ok {
+			status = "added"
+		} else if b == v.Size {
+			continue
+		}
+
+		diff.Chunks = append(diff.Chunks, ChunkDiff{Name: v.Name, Status: status, Before: b, After: v.Size, Delta: v.Size.sub(b)})
+	}
+
+	for name, b := range before {
+		diff.Chunks = append(diff.Chunks, ChunkDiff{Name: name, Status: "removed", Before: b, Delta: Size{}.sub(b)})
+	}
+
+	// Biggest (compressed) changes first
+	sort.Slice(diff.Chunks, func(i, j int) bool {
+		a, b := abs(diff.Chunks[i].Delta.Gzip), abs(diff.Chunks[j].Delta.Gzip)
+		if a != b {
+			return a > b
+		}
+		return diff.Chunks[i].Name < diff.Chunks[j].Name
+	})
+
+	r.Diff = diff
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Budget defines the maximum sizes of the whole frontend or of individual chunks. Limits that are 0 are not checked.
+type Budget struct {
+	Total  Size          `json:"total"`
+	Chunks []ChunkBudget `json:"chunks"`
+}
+
+type ChunkBudget struct {
+	// Pattern is matched against the chunk name (without content hash) using 'path.Match', like 'app.js' or '*.css'.
+	Pattern string `json:"pattern"`
+	Size
+}
+
+func ParseBudget(b []byte) (*Budget, error) {
+	budget := &Budget{}
+	if err := json.Unmarshal(b, budget); err != nil {
+		return nil, fmt.Errorf("error parsing budget: %w", err)
+	}
+
+	for _, v := range budget.Chunks {
+		if _, err := path.Match(v.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid budget pattern '%s': %w", v.Pattern, err)
+		}
+	}
+
+	return budget, nil
+}
+
+func exceeded(name string, size, limit Size) []string {
+	r := []string{}
+	check := func(kind string, size, limit int64) {
+		if limit > 0 && size > limit {
+			r = append(r, fmt.Sprintf("%s: %s size %s is over the budget of %s", name, kind, FormatBytes(size), FormatBytes(limit)))
+		}
+	}
+	check("raw", size.Raw, limit.Raw)
+	check("gzip", size.Gzip, limit.Gzip)
+	check("brotli", size.Brotli, limit.Brotli)
+
+	return r
+}
+
+// Check returns an error that lists every chunk (and the total) that is larger than the budget allows.
+func (b *Budget) Check(r *Report) error {
+	problems := exceeded("total", r.Total, b.Total)
+	for _, c := range r.Chunks {
+		for _, v := range b.Chunks {
+			if ok, _ := path.Match(v.Pattern, c.Name); ok {
+				problems = append(problems, exceeded(c.Name, c.Size, v.Size)...)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w:\n%s", ErrorBudgetExceeded, strings.Join(problems, "\n"))
+}
+
+// FormatBytes formats a size in bytes for humans, like '1.5 MiB'.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	if n < unit {
+		return fmt.Sprintf("%s%d B", sign, n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%s%.1f %ciB", sign, float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDelta(n int64) string {
+	if n > 0 {
+		return "+" + FormatBytes(n)
+	}
+	return FormatBytes(n)
+}
+
+// Markdown returns the report as a markdown document, with the diff if there is one.
+func (r *Report) Markdown() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# Frontend bundle size: %s\n\n", r.Version)
+	fmt.Fprintf(b, "| | Raw | Gzip | Brotli |\n|---|---:|---:|---:|\n")
+	fmt.Fprintf(b, "| **Total** | %s | %s | %s |\n", FormatBytes(r.Total.Raw), FormatBytes(r.Total.Gzip), FormatBytes(r.Total.Brotli))
+
+	if d := r.Diff; d != nil {
+		fmt.Fprintf(b, "\n## Compared to %s\n\n", d.PreviousVersion)
+		fmt.Fprintf(b, "| Chunk | Status | Raw | Gzip | Brotli |\n|---|---|---:|---:|---:|\n")
+		fmt.Fprintf(b, "| **Total** | | %s | %s | %s |\n", formatDelta(d.Total.Raw), formatDelta(d.Total.Gzip), formatDelta(d.Total.Brotli))
+		for _, v := range d.Chunks {
+			fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s |\n", v.Name, v.Status, formatDelta(v.Delta.Raw), formatDelta(v.Delta.Gzip), formatDelta(v.Delta.Brotli))
+		}
+	}
+
+	chunks := make([]Chunk, len(r.Chunks))
+	copy(chunks, r.Chunks)
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].Gzip > chunks[j].Gzip
+	})
+
+	fmt.Fprintf(b, "\n## Chunks\n\n| Chunk | Raw | Gzip | Brotli |\n|---|---:|---:|---:|\n")
+	for _, v := range chunks {
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s |\n", v.Name, FormatBytes(v.Raw), FormatBytes(v.Gzip), FormatBytes(v.Brotli))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package frontend_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-build/frontend"
+)
+
+const sizesOutput = "app.6b3c1d2e4f5a6b7c.js\t4000000\t1000000\t800000\n" +
+	"grafana.dark.0a1b2c3d4e5f.css\t300000\t40000\t30000\n" +
+	"4567.ffee0011aabb.js\t20000\t5000\t4000\n"
+
+func TestChunkName(t *testing.T) {
+	tests := map[string]string{
+		"app.6b3c1d2e4f5a6b7c.js":             "app.js",
+		"grafana.dark.0a1b2c3d4e5f.css":       "grafana.dark.css",
+		"runtime.js":                          "runtime.js",
+		"plugins/test.0123456789abcdef.js":    "plugins/test.js",
+		"app.6b3c1d2e4f5a6b7c.js.LICENSE.txt": "app.js.LICENSE.txt",
+	}
+
+	for input, expect := range tests {
+		if res := frontend.ChunkName(input); res != expect {
+			t.Fatalf("for '%s' got '%s', expected '%s'", input, res, expect)
+		}
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	r, err := frontend.ParseSizes("11.1.0", sizesOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Chunks) != 3 || r.Chunks[0].Name != "4567.js" || r.Chunks[1].Name != "app.js" {
+		t.Fatalf("unexpected chunks: %+v", r.Chunks)
+	}
+	if r.Total != (frontend.Size{Raw: 4320000, Gzip: 1045000, Brotli: 834000}) {
+		t.Fatalf("unexpected total: %+v", r.Total)
+	}
+
+	if _, err := frontend.ParseSizes("11.1.0", "app.js\t1\n"); err == nil {
+		t.Fatal("expected an error for malformed output")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	previous, err := frontend.ParseSizes("11.0.0", "app.0123456789ab.js\t3000000\t900000\t700000\n"+
+		"grafana.dark.0a1b2c3d4e5f.css\t300000\t40000\t30000\n"+
+		"old.0123456789ab.js\t1000\t500\t400\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := frontend.ParseSizes("11.1.0", sizesOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Compare(previous)
+	d := r.Diff
+	if d.PreviousVersion != "11.0.0" || d.Total.Gzip != 1045000-940500 {
+		t.Fatalf("unexpected diff total: %+v", d)
+	}
+
+	// The unchanged css file should not be in the diff, and the biggest change should be first.
+	if len(d.Chunks) != 3 {
+		t.Fatalf("expected 3 changed chunks, got %+v", d.Chunks)
+	}
+	if d.Chunks[0].Name != "app.js" || d.Chunks[0].Status != "changed" || d.Chunks[0].Delta.Gzip != 100000 {
+		t.Fatalf("unexpected first change: %+v", d.Chunks[0])
+	}
+	statuses := map[string]string{}
+	for _, v := range d.Chunks {
+		statuses[v.Name] = v.Status
+	}
+	if statuses["4567.js"] != "added" || statuses["old.js"] != "removed" {
+		t.Fatalf("unexpected statuses: %v", statuses)
+	}
+
+	if md := r.Markdown(); !strings.Contains(md, "## Compared to 11.0.0") || !strings.Contains(md, "| `app.js` | changed | +976.6 KiB |") {
+		t.Fatalf("unexpected markdown:\n%s", md)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	r, err := frontend.ParseSizes("11.1.0", sizesOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budget, err := frontend.ParseBudget([]byte(`{"total": {"gzip": 2000000}, "chunks": [{"pattern": "*.css", "gzip": 50000}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := budget.Check(r); err != nil {
+		t.Fatalf("expected the report to be within budget, got '%v'", err)
+	}
+
+	budget, err = frontend.ParseBudget([]byte(`{"total": {"brotli": 500000}, "chunks": [{"pattern": "app.js", "raw": 1000000}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = budget.Check(r)
+	if !errors.Is(err, frontend.ErrorBudgetExceeded) {
+		t.Fatalf("expected ErrorBudgetExceeded, got '%v'", err)
+	}
+	if !strings.Contains(err.Error(), "total: brotli size") || !strings.Contains(err.Error(), "app.js: raw size") {
+		t.Fatalf("expected the total and app.js to be over budget, got '%v'", err)
+	}
+
+	if _, err := frontend.ParseBudget([]byte(`{"chunks": [{"pattern": "[", "raw": 1}]}`)); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,185 @@
+package frontend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+var ErrorInvalidStorybook = errors.New("invalid storybook")
+
+// StorybookService serves the built storybook with nginx on port 80.
+func StorybookService(d *dagger.Client, dir *dagger.Directory) *dagger.Service {
+	return d.Container().From("nginx:1.27-alpine").
+		WithMountedDirectory("/usr/share/nginx/html", dir).
+		WithExposedPort(80).
+		AsService()
+}
+
+type storybookIndex struct {
+	Version int `json:"v"`
+	// Entries is used by 'index.json' (storybook 7+).
+	Entries map[string]struct {
+		Type string `json:"type"`
+	} `json:"entries"`
+	// Stories is used by 'stories.json' (storybook 6).
+	Stories map[string]json.RawMessage `json:"stories"`
+}
+
+// CountStories returns the number of stories in a storybook 'index.json' or 'stories.json'. Docs entries are not counted.
+func CountStories(b []byte) (int, error) {
+	idx := &storybookIndex{}
+	if err := json.Unmarshal(b, idx); err != nil {
+		return 0, fmt.Errorf("%w: error parsing stories index: %s", ErrorInvalidStorybook, err)
+	}
+
+	if idx.Entries == nil {
+		return len(idx.Stories), nil
+	}
+
+	n := 0
+	for _, v := range idx.Entries {
+		// Entries without a type are from older index versions, where every entry is a story.
+		if v.Type == "" || v.Type == "story" {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+var assetRegex = regexp.MustCompile(`(?i)\s(?:src|href)=["']([^"']+)["']`)
+
+// StorybookAssets returns the paths (relative to the storybook root) of every local asset that's referenced with 'src' or 'href' in 'html'.
+func StorybookAssets(html string) []string {
+	assets := map[string]bool{}
+	for _, m := range assetRegex.FindAllStringSubmatch(html, -1) {
+		ref := m[1]
+		if strings.Contains(ref, "://") || strings.HasPrefix(ref, "//") || strings.ContainsAny(ref, "{}%$") {
+			continue
+		}
+		if i := strings.IndexAny(ref, "?#"); i >= 0 {
+			ref = ref[:i]
+		}
+		if ref == "" || strings.Contains(ref, ":") {
+			// Anchors, 'data:', 'mailto:', and 'javascript:' references.
+			continue
+		}
+
+		ref = strings.TrimPrefix(path.Clean("/"+ref), "/")
+		if ref != "" {
+			assets[ref] = true
+		}
+	}
+
+	r := make([]string, 0, len(assets))
+	for k := range assets {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+
+	return r
+}
+
+// StatusScript prints the HTTP status code and path of every path, relative to 'base', separated by a space.
+func StatusScript(base string, paths []string) string {
+	lines := make([]string, len(paths))
+	for i, v := range paths {
+		lines[i] = fmt.Sprintf("printf '%%s %%s\\n' \"$(curl -s -o /dev/null -w '%%{http_code}' '%s/%s')\" '%s'", base, v, v)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// MissingAssets parses the output of StatusScript and returns every path that didn't respond with '200 OK'.
+func MissingAssets(out string) ([]string, error) {
+	missing := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		status, p, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("unexpected line in status output: '%s'", line)
+		}
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected status in status output: '%s'", line)
+		}
+		if code != 200 {
+			missing = append(missing, fmt.Sprintf("%s (%d)", p, code))
+		}
+	}
+
+	return missing, scanner.Err()
+}
+
+// VerifyStorybook serves the storybook in 'dir' and checks that:
+// * 'index.html' and 'iframe.html' load,
+// * 'index.json' (or 'stories.json' for older versions) lists at least 'minStories' stories,
+// * every asset that is referenced in 'index.html' and 'iframe.html' loads.
+func VerifyStorybook(ctx context.Context, d *dagger.Client, dir *dagger.Directory, minStories int) error {
+	const base = "http://storybook"
+	c := d.Container().From("alpine/curl").
+		WithServiceBinding("storybook", StorybookService(d, dir)).
+		WithEntrypoint([]string{})
+
+	get := func(p string) (string, error) {
+		return c.WithExec([]string{"curl", "-sSf", base + "/" + p}).Stdout(ctx)
+	}
+
+	assets := []string{}
+	for _, v := range []string{"index.html", "iframe.html"} {
+		html, err := get(v)
+		if err != nil {
+			return fmt.Errorf("%w: error loading '%s': %s", ErrorInvalidStorybook, v, err)
+		}
+		assets = append(assets, StorybookAssets(html)...)
+	}
+
+	index, err := get("index.json")
+	if err != nil {
+		index, err = get("stories.json")
+		if err != nil {
+			return fmt.Errorf("%w: neither 'index.json' nor 'stories.json' could be loaded: %s", ErrorInvalidStorybook, err)
+		}
+	}
+
+	n, err := CountStories([]byte(index))
+	if err != nil {
+		return err
+	}
+	if n < minStories {
+		return fmt.Errorf("%w: expected at least %d stories, found %d", ErrorInvalidStorybook, minStories, n)
+	}
+
+	if len(assets) == 0 {
+		return nil
+	}
+
+	out, err := c.WithExec([]string{"/bin/sh", "-c", StatusScript(base, assets)}).Stdout(ctx)
+	if err != nil {
+		return err
+	}
+
+	missing, err := MissingAssets(out)
+	if err != nil {
+		return err
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("%w: referenced assets could not be loaded: %s", ErrorInvalidStorybook, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
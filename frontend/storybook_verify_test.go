@@ -0,0 +1,68 @@
+package frontend_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/grafana-build/frontend"
+)
+
+func TestCountStories(t *testing.T) {
+	tests := map[string]int{
+		`{"v": 5, "entries": {"a--docs": {"type": "docs"}, "a--basic": {"type": "story"}, "b--basic": {"type": "story"}}}`: 2,
+		`{"v": 4, "entries": {"a--basic": {}, "b--basic": {}, "c--basic": {}}}`:                                            3,
+		`{"v": 3, "stories": {"a--basic": {"name": "Basic"}}}`:                                                             1,
+	}
+
+	for input, expect := range tests {
+		n, err := frontend.CountStories([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != expect {
+			t.Errorf("for '%s' got %d stories, expected %d", input, n, expect)
+		}
+	}
+
+	if _, err := frontend.CountStories([]byte("<html>")); !errors.Is(err, frontend.ErrorInvalidStorybook) {
+		t.Fatalf("expected ErrorInvalidStorybook, got '%v'", err)
+	}
+}
+
+func TestStorybookAssets(t *testing.T) {
+	html := `<!doctype html>
+<html>
+  <head>
+    <link rel="prefetch" href="./sb-addons/essentials/manager-bundle.js" as="script" />
+    <link href="https://fonts.googleapis.com/css" rel="stylesheet">
+    <link rel="icon" href="favicon.svg?v=1" />
+    <script src="./sb-manager/runtime.js" type="module"></script>
+    <script src='main.8d3e1b.iframe.bundle.js'></script>
+    <script src="//cdn.example.com/lib.js"></script>
+    <img src="data:image/png;base64,AAAA">
+    <a href="#root">skip</a>
+    <a href="mailto:hello@example.com">mail</a>
+    <script src="./sb-manager/runtime.js" type="module"></script>
+  </head>
+</html>`
+
+	expect := []string{"favicon.svg", "main.8d3e1b.iframe.bundle.js", "sb-addons/essentials/manager-bundle.js", "sb-manager/runtime.js"}
+	if assets := frontend.StorybookAssets(html); !reflect.DeepEqual(assets, expect) {
+		t.Fatalf("unexpected assets: %v", assets)
+	}
+}
+
+func TestMissingAssets(t *testing.T) {
+	missing, err := frontend.MissingAssets("200 favicon.svg\n404 sb-manager/runtime.js\n200 main.js\n000 sb-addons/a.js\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(missing, []string{"sb-manager/runtime.js (404)", "sb-addons/a.js (0)"}) {
+		t.Fatalf("unexpected missing assets: %v", missing)
+	}
+
+	if _, err := frontend.MissingAssets("not-a-status favicon.svg"); err == nil {
+		t.Fatal("expected an error for malformed output")
+	}
+}
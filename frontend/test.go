@@ -0,0 +1,148 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"dagger.io/dagger"
+)
+
+// TestReportsDir is where the JUnit reports, coverage, and the output of each suite are written in the test container.
+const TestReportsDir = "/src/test-reports"
+
+type TestSuite string
+
+const (
+	// TestSuiteJest runs the jest tests using the 'test:ci' script; 'yarn test' runs jest in watch mode.
+	TestSuiteJest TestSuite = "test"
+	// TestSuiteTypecheck runs 'yarn typecheck'.
+	TestSuiteTypecheck TestSuite = "typecheck"
+	// TestSuiteLint runs 'yarn lint'.
+	TestSuiteLint TestSuite = "lint"
+)
+
+var DefaultTestSuites = []TestSuite{TestSuiteJest, TestSuiteTypecheck, TestSuiteLint}
+
+var (
+	ErrorInvalidTestSuite = errors.New("invalid frontend test suite; expected 'test', 'typecheck', or 'lint'")
+	ErrorTestsFailed      = errors.New("frontend tests failed")
+)
+
+func ParseTestSuites(s []string) ([]TestSuite, error) {
+	if len(s) == 0 {
+		return DefaultTestSuites, nil
+	}
+
+	r := []TestSuite{}
+	for _, v := range s {
+		suite := TestSuite(v)
+		if !slices.Contains(DefaultTestSuites, suite) {
+			return nil, fmt.Errorf("%w: '%s'", ErrorInvalidTestSuite, v)
+		}
+		if !slices.Contains(r, suite) {
+			r = append(r, suite)
+		}
+	}
+
+	return r, nil
+}
+
+// TestOpts are options that change which frontend test suites are ran and how.
+type TestOpts struct {
+	Suites []TestSuite
+
+	// Shard and Shards split the jest tests into 'Shards' groups using 'jest --shard' and only run the group 'Shard' (1-indexed).
+	// The typecheck and lint suites can't be split, so they are only ran in the first shard.
+	// If Shards is 0 or 1 then all tests are ran.
+	Shard  int
+	Shards int
+}
+
+// RunSuites returns the suites that are ran in this shard.
+func (o *TestOpts) RunSuites() []TestSuite {
+	suites := o.Suites
+	if len(suites) == 0 {
+		suites = DefaultTestSuites
+	}
+	if o.Shards <= 1 || o.Shard == 1 {
+		return suites
+	}
+
+	r := []TestSuite{}
+	for _, v := range suites {
+		if v == TestSuiteJest {
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
+
+// ID returns a short string which is unique for each combination of options that changes which tests are ran.
+// It's used in the artifact filename so that different test runs are not confused with one another.
+func (o *TestOpts) ID() string {
+	parts := []string{}
+	for _, v := range o.RunSuites() {
+		parts = append(parts, string(v))
+	}
+	if o.Shards > 1 {
+		parts = append(parts, fmt.Sprintf("shard-%d-of-%d", o.Shard, o.Shards))
+	}
+
+	return strings.Join(parts, "-")
+}
+
+func (o *TestOpts) command(suite TestSuite) string {
+	switch suite {
+	case TestSuiteJest:
+		// '--reporters' replaces the reporters (and their options) from the jest config and 'test:ci', so that jest-junit only gets its
+		// options from the environment and always writes the report to TestReportsDir. jest can't set reporter options on the command line.
+		args := []string{
+			fmt.Sprintf("JEST_JUNIT_OUTPUT_DIR=%s", TestReportsDir),
+			"JEST_JUNIT_OUTPUT_NAME=junit-test.xml",
+			"yarn", "run", "test:ci",
+			"--reporters=default",
+			"--reporters=jest-junit",
+			"--coverage",
+			"--coverageReporters=lcov",
+			fmt.Sprintf("--coverageDirectory=%s", path.Join(TestReportsDir, "coverage")),
+		}
+		if o.Shards > 1 {
+			args = append(args, fmt.Sprintf("--shard=%d/%d", o.Shard, o.Shards))
+		}
+		return "env " + strings.Join(args, " ")
+	case TestSuiteTypecheck:
+		return "yarn run typecheck"
+	case TestSuiteLint:
+		return "yarn run lint"
+	}
+
+	return ""
+}
+
+// TestScript returns the shell script that runs each test suite. The output of each suite is written to '{suite}.log', and its exit code
+// to '{suite}.exit', in TestReportsDir.
+// A failing suite does not stop the other suites from running, and the script always exits successfully so that the results can be read
+// with 'TestResults'.
+func TestScript(opts *TestOpts) string {
+	lines := []string{
+		fmt.Sprintf("mkdir -p %s", TestReportsDir),
+	}
+	for _, v := range opts.RunSuites() {
+		log := path.Join(TestReportsDir, string(v)+".log")
+		exit := path.Join(TestReportsDir, string(v)+".exit")
+		lines = append(lines, fmt.Sprintf("%s > %s 2>&1; echo $? > %s", opts.command(v), log, exit))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Test runs the test suites in the provided builder (see 'Builder'). The test reports are in 'TestReportsDir' of the returned container.
+func Test(builder *dagger.Container, opts *TestOpts) *dagger.Container {
+	return builder.
+		WithEnvVariable("CI", "true").
+		WithExec([]string{"/bin/sh", "-c", TestScript(opts)})
+}
@@ -0,0 +1,109 @@
+package frontend_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-build/frontend"
+)
+
+func TestParseTestSuites(t *testing.T) {
+	suites, err := frontend.ParseTestSuites([]string{"lint", "test", "lint"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(suites, []frontend.TestSuite{frontend.TestSuiteLint, frontend.TestSuiteJest}) {
+		t.Fatalf("unexpected suites: %v", suites)
+	}
+
+	if _, err := frontend.ParseTestSuites([]string{"e2e"}); !errors.Is(err, frontend.ErrorInvalidTestSuite) {
+		t.Fatalf("expected ErrorInvalidTestSuite, got '%v'", err)
+	}
+}
+
+func TestTestOptsRunSuites(t *testing.T) {
+	first := &frontend.TestOpts{Shard: 1, Shards: 4}
+	second := &frontend.TestOpts{Shard: 2, Shards: 4}
+
+	if !reflect.DeepEqual(first.RunSuites(), frontend.DefaultTestSuites) {
+		t.Fatalf("expected every suite in the first shard, got %v", first.RunSuites())
+	}
+	if !reflect.DeepEqual(second.RunSuites(), []frontend.TestSuite{frontend.TestSuiteJest}) {
+		t.Fatalf("expected only jest in the second shard, got %v", second.RunSuites())
+	}
+	if first.ID() != "test-typecheck-lint-shard-1-of-4" || second.ID() != "test-shard-2-of-4" {
+		t.Fatalf("unexpected IDs '%s' and '%s'", first.ID(), second.ID())
+	}
+}
+
+func TestTestScript(t *testing.T) {
+	script := frontend.TestScript(&frontend.TestOpts{Shard: 2, Shards: 4})
+	expect := []string{
+		"yarn run test:ci --reporters=default --reporters=jest-junit --coverage --coverageReporters=lcov --coverageDirectory=/src/test-reports/coverage --shard=2/4 > /src/test-reports/test.log 2>&1; echo $? > /src/test-reports/test.exit",
+		"JEST_JUNIT_OUTPUT_NAME=junit-test.xml",
+	}
+	for _, v := range expect {
+		if !strings.Contains(script, v) {
+			t.Errorf("expected script to contain '%s', got:\n%s", v, script)
+		}
+	}
+	if strings.Contains(script, "typecheck") {
+		t.Errorf("typecheck should only run in the first shard, got:\n%s", script)
+	}
+}
+
+const jestJUnit = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="jest tests" tests="3" failures="1" errors="0" time="1.5">
+  <testsuite name="public/app/core/utils.test.ts" errors="0" failures="1" skipped="0" tests="2">
+    <testcase classname="utils formats dates" name="utils formats dates" time="0.1"></testcase>
+    <testcase classname="utils parses urls" name="utils parses urls" time="0.1">
+      <failure>Expected: true</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="public/app/core/other.test.ts" errors="0" failures="0" skipped="0" tests="1">
+    <testcase classname="other works" name="other works" time="0.1"></testcase>
+  </testsuite>
+</testsuites>`
+
+func TestParseJUnit(t *testing.T) {
+	r, err := frontend.ParseJUnit([]byte(jestJUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := r.FailedSuites()
+	if len(failed) != 1 || failed[0].Name != "public/app/core/utils.test.ts" || !reflect.DeepEqual(failed[0].Cases, []string{"utils parses urls"}) {
+		t.Fatalf("unexpected failed suites: %+v", failed)
+	}
+}
+
+func TestTypecheckJUnit(t *testing.T) {
+	out := "$ tsc --noEmit\n" +
+		"public/app/a.ts(10,5): error TS2322: Type 'string' is not assignable to type 'number'.\n" +
+		"public/app/b.tsx(1,1): error TS2304: Cannot find name 'foo'.\n" +
+		"public/app/a.ts(12,1): error TS2304: Cannot find name 'bar'.\n"
+
+	r := frontend.TypecheckJUnit(out, true)
+	if r.Tests != 2 || r.Failures != 2 {
+		t.Fatalf("expected 2 failing files, got %d / %d", r.Failures, r.Tests)
+	}
+	a := r.TestSuites[0].TestCases[0]
+	if a.Name != "public/app/a.ts" || !strings.Contains(a.Failure.Text, "public/app/a.ts:12:1: TS2304") {
+		t.Fatalf("unexpected test case: %+v", a)
+	}
+
+	r = frontend.TypecheckJUnit("$ tsc --noEmit\n", false)
+	if r.Tests != 1 || r.Failures != 0 {
+		t.Fatalf("expected 1 passing test, got %d / %d", r.Failures, r.Tests)
+	}
+
+	b, err := r.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `<testsuites name="typecheck" tests="1" failures="0" errors="0">`) {
+		t.Fatalf("unexpected XML:\n%s", b)
+	}
+}
@@ -0,0 +1,64 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"dagger.io/dagger"
+)
+
+const (
+	// VerdaccioImage is the local npm registry that is used to test publishing.
+	VerdaccioImage = "verdaccio/verdaccio:5"
+	VerdaccioPort  = 4873
+)
+
+// VerdaccioConfig allows any registered user to publish any package, and doesn't proxy to the npm registry.
+const VerdaccioConfig = `storage: /verdaccio/storage/data
+auth:
+  htpasswd:
+    file: /verdaccio/storage/htpasswd
+    max_users: 100
+uplinks: {}
+packages:
+  '**':
+    access: $all
+    publish: $authenticated
+    unpublish: $authenticated
+log: { type: stdout, format: pretty, level: warn }
+`
+
+// Verdaccio returns a local npm registry service. Bind it with the hostname 'verdaccio' and use the registry 'http://verdaccio:4873'.
+func Verdaccio(d *dagger.Client) *dagger.Service {
+	return d.Container().From(VerdaccioImage).
+		WithNewFile("/verdaccio/conf/config.yaml", VerdaccioConfig).
+		WithExposedPort(VerdaccioPort).
+		AsService()
+}
+
+// VerdaccioToken registers a user in the Verdaccio service and returns its token, which can be used to publish packages.
+func VerdaccioToken(ctx context.Context, d *dagger.Client, svc *dagger.Service, user string) (string, error) {
+	body := fmt.Sprintf(`{"name": "%[1]s", "password": "%[1]s"}`, user)
+	out, err := d.Container().From("alpine/curl").
+		WithServiceBinding("verdaccio", svc).
+		WithExec([]string{
+			"curl", "-sSf", "-X", "PUT",
+			"-H", "content-type: application/json",
+			"-d", body,
+			fmt.Sprintf("http://verdaccio:%d/-/user/org.couchdb.user:%s", VerdaccioPort, user),
+		}).
+		Stdout(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	res := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		return "", fmt.Errorf("error parsing verdaccio response '%s': %w", out, err)
+	}
+
+	return res.Token, nil
+}
@@ -1,10 +1,70 @@
 package frontend
 
-import "dagger.io/dagger"
+import (
+	"fmt"
+	"path"
 
-func YarnInstall(c *dagger.Client, src *dagger.Directory, version string, cache *dagger.CacheVolume, platform dagger.Platform) *dagger.Container {
-	return WithYarnCache(NodeContainer(c, NodeImage(version), platform), cache).
+	"dagger.io/dagger"
+)
+
+// YarnCacheExportDir is where the yarn cache is written when creating a yarn cache archive. It's a regular directory and not a cache
+// volume, so that it can be exported.
+const YarnCacheExportDir = "/yarn/export"
+
+func YarnInstall(c *dagger.Client, src *dagger.Directory, node *Node, cache *dagger.CacheVolume, platform dagger.Platform) *dagger.Container {
+	return WithYarnCache(NodeContainer(c, node, platform), cache).
 		WithMountedDirectory("/src", src).
 		WithWorkdir("/src").
 		WithExec([]string{"yarn", "install", "--immutable", "--inline-builds"})
 }
+
+// YarnSupportedArchitectures is the yarn 'supportedArchitectures' setting for every platform that Grafana is built for.
+// By default, yarn only fetches the optional dependencies (like esbuild or swc binaries) for the platform that it's running on.
+var YarnSupportedArchitectures = `{"os":["linux","darwin","win32"],"cpu":["x64","arm64","arm","ia32"],"libc":["glibc","musl"]}`
+
+// withSupportedArchitectures sets 'supportedArchitectures' (see 'YarnSupportedArchitectures') in the '.yarnrc.yml' in '/src'.
+// '/src' is a mounted directory, so the change is only made in this container.
+func withSupportedArchitectures(c *dagger.Container) *dagger.Container {
+	return c.WithExec([]string{"yarn", "config", "set", "supportedArchitectures", "--json", YarnSupportedArchitectures})
+}
+
+// YarnCacheArchive downloads every package in the 'yarn.lock' of 'src' using the 'node' container (see 'NodeContainer') and returns them as
+// a '.tar.gz' of the yarn cache folder.
+// The optional dependencies of every platform in 'YarnSupportedArchitectures' are fetched, and packages are only fetched and not built,
+// so the archive does not depend on the platform that it was created on.
+// The archive is reproducible: files are sorted and have no timestamps or owners.
+func YarnCacheArchive(node *dagger.Container, src *dagger.Directory) *dagger.File {
+	archive := path.Join("/yarn", "yarn-cache.tar.gz")
+	return withSupportedArchitectures(node.
+		WithEnvVariable("YARN_CACHE_FOLDER", YarnCacheExportDir).
+		WithEnvVariable("YARN_ENABLE_GLOBAL_CACHE", "false").
+		// The archive is created by downloading the packages, even if the other builds are seeded from an archive.
+		WithEnvVariable("YARN_ENABLE_NETWORK", "true").
+		WithMountedDirectory("/src", src).
+		WithWorkdir("/src")).
+		WithExec([]string{"yarn", "install", "--immutable", "--mode=skip-build"}).
+		WithExec([]string{"/bin/sh", "-c", fmt.Sprintf("tar --sort=name --mtime=@0 --owner=0 --group=0 --numeric-owner -C %s -cf - . | gzip -9 -n > %s", YarnCacheExportDir, archive)}).
+		File(archive)
+}
+
+// SeedYarnCache extracts a yarn cache archive (see 'YarnCacheArchive') into the yarn cache volume.
+func SeedYarnCache(c *dagger.Client, cache *dagger.CacheVolume, archive *dagger.File) *dagger.Container {
+	return c.Container().From("alpine:3.18.4").
+		WithMountedCache("/cache", cache).
+		WithMountedFile("/yarn-cache.tar.gz", archive).
+		WithExec([]string{"tar", "-xzf", "/yarn-cache.tar.gz", "-C", "/cache"})
+}
+
+// VerifyYarnCache runs 'yarn install' on 'src' in the 'node' container with the network disabled, using only the packages in the yarn cache archive.
+// It uses the same 'supportedArchitectures' as 'YarnCacheArchive', so the packages for the other platforms are needed and aren't removed from the cache.
+func VerifyYarnCache(node *dagger.Container, src *dagger.Directory, archive *dagger.File) *dagger.Container {
+	return withSupportedArchitectures(node.
+		WithMountedFile("/yarn/yarn-cache.tar.gz", archive).
+		WithExec([]string{"/bin/sh", "-c", fmt.Sprintf("mkdir -p %[1]s && tar -xzf /yarn/yarn-cache.tar.gz -C %[1]s", YarnCacheExportDir)}).
+		WithEnvVariable("YARN_CACHE_FOLDER", YarnCacheExportDir).
+		WithEnvVariable("YARN_ENABLE_GLOBAL_CACHE", "false").
+		WithEnvVariable("YARN_ENABLE_NETWORK", "false").
+		WithMountedDirectory("/src", src).
+		WithWorkdir("/src")).
+		WithExec([]string{"yarn", "install", "--immutable", "--immutable-cache", "--mode=skip-build"})
+}
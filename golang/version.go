@@ -0,0 +1,61 @@
+package golang
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+)
+
+var ErrorNoVersion = errors.New("no go version found")
+
+// VersionFromGoMod returns the Go toolchain version declared in the contents of a go.mod file.
+// The 'toolchain' directive is preferred over the 'go' directive, as the 'go' directive only declares the minimum language version.
+func VersionFromGoMod(gomod []byte) (string, error) {
+	var goDirective, toolchain string
+
+	scanner := bufio.NewScanner(bytes.NewReader(gomod))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "go":
+			goDirective = fields[1]
+		case "toolchain":
+			toolchain = strings.TrimPrefix(fields[1], "go")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	// 'toolchain default' means "use whatever the go directive says".
+	if toolchain != "" && toolchain != "default" {
+		return toolchain, nil
+	}
+
+	if goDirective != "" {
+		return goDirective, nil
+	}
+
+	return "", ErrorNoVersion
+}
+
+// VersionFromGoVersionFile returns the Go version from the contents of a '.go-version' file.
+func VersionFromGoVersionFile(contents []byte) (string, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(string(contents)), "go")
+	if v == "" {
+		return "", ErrorNoVersion
+	}
+
+	return v, nil
+}
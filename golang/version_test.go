@@ -0,0 +1,50 @@
+package golang_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-build/golang"
+)
+
+func TestVersionFromGoMod(t *testing.T) {
+	tests := map[string]string{
+		"module github.com/grafana/grafana\n\ngo 1.23.1\n":                                         "1.23.1",
+		"module github.com/grafana/grafana\n\ngo 1.23.1\n\ntoolchain go1.24.1\n":                   "1.24.1",
+		"module github.com/grafana/grafana\n\ngo 1.22.7 // comment\n":                              "1.22.7",
+		"module github.com/grafana/grafana\n\ngo 1.22.7\ntoolchain default\n":                      "1.22.7",
+		"module github.com/grafana/grafana\n\nrequire (\n\tgo.opentelemetry.io v1\n)\ngo 1.21.0\n": "1.21.0",
+	}
+
+	for input, expect := range tests {
+		res, err := golang.VersionFromGoMod([]byte(input))
+		if err != nil {
+			t.Fatalf("for '%s' got unexpected error: %s", input, err)
+		}
+		if res != expect {
+			t.Fatalf("for '%s' got '%s', expected '%s'", input, res, expect)
+		}
+	}
+
+	if _, err := golang.VersionFromGoMod([]byte("module example.com/test\n")); !errors.Is(err, golang.ErrorNoVersion) {
+		t.Fatalf("expected ErrorNoVersion when no go directive is present, got '%v'", err)
+	}
+}
+
+func TestVersionFromGoVersionFile(t *testing.T) {
+	tests := map[string]string{
+		"1.23.1\n": "1.23.1",
+		"go1.22.4": "1.22.4",
+		" 1.21.0 ": "1.21.0",
+	}
+
+	for input, expect := range tests {
+		res, err := golang.VersionFromGoVersionFile([]byte(input))
+		if err != nil {
+			t.Fatalf("for '%s' got unexpected error: %s", input, err)
+		}
+		if res != expect {
+			t.Fatalf("for '%s' got '%s', expected '%s'", input, res, expect)
+		}
+	}
+}
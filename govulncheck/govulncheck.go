@@ -0,0 +1,158 @@
+// Package govulncheck scans the built Grafana backend binaries for known vulnerabilities using govulncheck and a local (offline) copy of the Go
+// vulnerability database.
+package govulncheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/daggerutil"
+	"github.com/grafana/grafana-build/golang"
+)
+
+const (
+	// DefaultVersion is the version of govulncheck that is used if '--govulncheck-version' is not set.
+	DefaultVersion = "v1.1.3"
+	// DBPath is where the vulnerability database is mounted in the govulncheck container.
+	DBPath = "/vulndb"
+)
+
+type Policy string
+
+const (
+	// PolicyFail fails verification if any vulnerable function is called by a binary that isn't in the allowlist.
+	PolicyFail Policy = "fail"
+	// PolicyReport never fails verification; findings are only reported.
+	PolicyReport Policy = "report"
+)
+
+var (
+	ErrorInvalidPolicy = errors.New("invalid govulncheck policy; expected 'fail' or 'report'")
+	ErrorVulnerable    = errors.New("binaries are affected by vulnerabilities")
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicyFail, PolicyReport:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorInvalidPolicy, s)
+}
+
+// Opts define how the backend binaries are scanned and what to do with the findings.
+type Opts struct {
+	// Version is the version of govulncheck, like 'v1.1.3'. It is part of the report's name because newer versions can report different findings.
+	Version string
+	// Binary is a prebuilt govulncheck binary. If it's nil, govulncheck is built with Binary, which downloads it with 'go install'.
+	Binary    *dagger.File
+	DB        *dagger.Directory
+	Policy    Policy
+	Allowlist []string
+}
+
+// ParseAllowlist parses the contents of an allowlist file, which has one vulnerability ID (like 'GO-2024-2687' or a CVE / GHSA alias) per line.
+// Empty lines and everything after a '#' are ignored.
+func ParseAllowlist(contents string) []string {
+	ids := []string{}
+	for _, line := range strings.Split(contents, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			ids = append(ids, line)
+		}
+	}
+
+	return ids
+}
+
+// DB returns the vulnerability database at 'p' on the host. 'p' can be a directory in the vulndb format or a zip file of one (like
+// https://vuln.go.dev/vulndb.zip).
+func DB(d *dagger.Client, p string) (*dagger.Directory, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return daggerutil.HostDir(d, p)
+	}
+
+	return d.Container().From("alpine:3.18.4").
+		WithMountedFile("/src/vulndb.zip", d.Host().File(p)).
+		WithExec([]string{"unzip", "-q", "/src/vulndb.zip", "-d", DBPath}).
+		Directory(DBPath), nil
+}
+
+// Binary returns the govulncheck binary at 'version', built with the Go toolchain for 'goVersion'. It is built in its own container so that
+// it is cached like an image layer, and scanning the binaries doesn't download anything. Building it downloads govulncheck, so offline
+// builds use a prebuilt binary instead (see Opts.Binary).
+func Binary(d *dagger.Client, platform dagger.Platform, goVersion, version string) *dagger.File {
+	return golang.Container(d, platform, goVersion).
+		WithEnvVariable("CGO_ENABLED", "0").
+		WithEnvVariable("GOBIN", "/govulncheck").
+		WithExec([]string{"go", "install", "golang.org/x/vuln/cmd/govulncheck@" + version}).
+		File("/govulncheck/govulncheck")
+}
+
+// Container returns a container with govulncheck installed and the vulnerability database mounted at DBPath. govulncheck is the prebuilt
+// binary from the options if there is one, and is built with Binary if there isn't.
+func Container(d *dagger.Client, platform dagger.Platform, goVersion string, opts *Opts) *dagger.Container {
+	bin := opts.Binary
+	if bin == nil {
+		bin = Binary(d, platform, goVersion, opts.Version)
+	}
+
+	return golang.Container(d, platform, goVersion).
+		WithFile("/usr/local/bin/govulncheck", bin, dagger.ContainerWithFileOpts{Permissions: 0o755}).
+		WithMountedDirectory(DBPath, opts.DB)
+}
+
+// Scan runs govulncheck against every binary in 'bin' and returns the parsed report.
+func Scan(ctx context.Context, c *dagger.Container, bin *dagger.Directory) (*Report, error) {
+	entries, err := bin.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = c.WithMountedDirectory("/src/bin", bin)
+	report := &Report{Findings: []*Finding{}}
+	for _, v := range entries {
+		// With '-json', govulncheck exits successfully even if there are findings.
+		out, err := c.WithExec([]string{"govulncheck", "-mode=binary", "-json", "-db=file://" + DBPath, path.Join("/src/bin", v)}).Stdout(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error running govulncheck on '%s': %w", v, err)
+		}
+
+		if err := report.Add(v, []byte(out)); err != nil {
+			return nil, fmt.Errorf("error parsing govulncheck output for '%s': %w", v, err)
+		}
+	}
+
+	return report, nil
+}
+
+// Check returns an error if the report has findings that aren't allowed and the policy is PolicyFail.
+func Check(report *Report, policy Policy) error {
+	if policy == PolicyReport {
+		return nil
+	}
+
+	blocking := report.Blocking()
+	if len(blocking) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(blocking))
+	for i, v := range blocking {
+		ids[i] = v.ID
+	}
+
+	return fmt.Errorf("%w: %s", ErrorVulnerable, strings.Join(ids, ", "))
+}
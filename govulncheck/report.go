@@ -0,0 +1,142 @@
+package govulncheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sort"
+)
+
+// A Finding is a single vulnerability that affects one or more binaries.
+type Finding struct {
+	ID           string   `json:"id"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	Module       string   `json:"module"`
+	Version      string   `json:"version"`
+	FixedVersion string   `json:"fixed_version,omitempty"`
+	// Called is true when a vulnerable function is reachable in the binary, rather than only being in an imported package or module.
+	Called   bool     `json:"called"`
+	Binaries []string `json:"binaries"`
+	Allowed  bool     `json:"allowed"`
+}
+
+// Report is the result of scanning all of the binaries for a single backend build.
+type Report struct {
+	Findings []*Finding `json:"findings"`
+}
+
+// These are the parts of the govulncheck JSON stream (https://pkg.go.dev/golang.org/x/vuln/internal/govulncheck) that are used in the report.
+type message struct {
+	OSV *struct {
+		ID      string   `json:"id"`
+		Aliases []string `json:"aliases"`
+		Summary string   `json:"summary"`
+	} `json:"osv"`
+	Finding *struct {
+		OSV          string `json:"osv"`
+		FixedVersion string `json:"fixed_version"`
+		Trace        []struct {
+			Module   string `json:"module"`
+			Version  string `json:"version"`
+			Function string `json:"function"`
+		} `json:"trace"`
+	} `json:"finding"`
+}
+
+// Add adds the findings from the govulncheck JSON output for 'binary' to the report.
+func (r *Report) Add(binary string, stream []byte) error {
+	var (
+		dec  = json.NewDecoder(bytes.NewReader(stream))
+		osvs = map[string]*Finding{}
+		// Findings are emitted at the module, package, and function level; only keep the most precise one for each vulnerability.
+		found = map[string]*Finding{}
+	)
+
+	for {
+		var m message
+		if err := dec.Decode(&m); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		if m.OSV != nil {
+			osvs[m.OSV.ID] = &Finding{ID: m.OSV.ID, Aliases: m.OSV.Aliases, Summary: m.OSV.Summary}
+		}
+
+		if f := m.Finding; f != nil && len(f.Trace) != 0 {
+			called := f.Trace[0].Function != ""
+			if existing, ok := found[f.OSV]; ok && (existing.Called || !called) {
+				continue
+			}
+
+			found[f.OSV] = &Finding{
+				ID:           f.OSV,
+				Module:       f.Trace[0].Module,
+				Version:      f.Trace[0].Version,
+				FixedVersion: f.FixedVersion,
+				Called:       called,
+			}
+		}
+	}
+
+	for id, f := range found {
+		if osv, ok := osvs[id]; ok {
+			f.Aliases = osv.Aliases
+			f.Summary = osv.Summary
+		}
+		r.add(binary, f)
+	}
+
+	sort.Slice(r.Findings, func(i, j int) bool {
+		return r.Findings[i].ID < r.Findings[j].ID
+	})
+
+	return nil
+}
+
+func (r *Report) add(binary string, f *Finding) {
+	for _, v := range r.Findings {
+		if v.ID != f.ID {
+			continue
+		}
+		v.Called = v.Called || f.Called
+		if !slices.Contains(v.Binaries, binary) {
+			v.Binaries = append(v.Binaries, binary)
+		}
+		return
+	}
+
+	f.Binaries = []string{binary}
+	r.Findings = append(r.Findings, f)
+}
+
+// Allow marks findings whose ID or aliases are in 'ids' as allowed.
+func (r *Report) Allow(ids []string) {
+	for _, f := range r.Findings {
+		if slices.Contains(ids, f.ID) || slices.ContainsFunc(f.Aliases, func(a string) bool { return slices.Contains(ids, a) }) {
+			f.Allowed = true
+		}
+	}
+}
+
+// Blocking returns the findings that are called by a binary and are not allowed.
+func (r *Report) Blocking() []*Finding {
+	b := []*Finding{}
+	for _, f := range r.Findings {
+		if f.Called && !f.Allowed {
+			b = append(b, f)
+		}
+	}
+
+	return b
+}
+
+// JSON returns the report encoded as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
@@ -0,0 +1,84 @@
+package govulncheck_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-build/govulncheck"
+)
+
+const grafanaOutput = `{"config":{"protocol_version":"v1.0.0","scanner_name":"govulncheck","scanner_version":"v1.1.3","db":"file:///vulndb","scan_level":"symbol"}}
+{"progress":{"message":"Scanning your binary for known vulnerabilities..."}}
+{"osv":{"id":"GO-2024-2687","aliases":["CVE-2023-45288"],"summary":"HTTP/2 CONTINUATION flood in net/http"}}
+{"osv":{"id":"GO-2024-2611","aliases":["CVE-2024-24786"],"summary":"Infinite loop in JSON unmarshaling in google.golang.org/protobuf"}}
+{"finding":{"osv":"GO-2024-2687","fixed_version":"v0.23.0","trace":[{"module":"golang.org/x/net","version":"v0.22.0"}]}}
+{"finding":{"osv":"GO-2024-2687","fixed_version":"v0.23.0","trace":[{"module":"golang.org/x/net","version":"v0.22.0","package":"golang.org/x/net/http2","function":"Framer.ReadFrame","receiver":"*Framer"}]}}
+{"finding":{"osv":"GO-2024-2611","fixed_version":"v1.33.0","trace":[{"module":"google.golang.org/protobuf","version":"v1.32.0"}]}}
+`
+
+const cliOutput = `{"osv":{"id":"GO-2024-2687","aliases":["CVE-2023-45288"],"summary":"HTTP/2 CONTINUATION flood in net/http"}}
+{"finding":{"osv":"GO-2024-2687","fixed_version":"v0.23.0","trace":[{"module":"golang.org/x/net","version":"v0.22.0"}]}}
+`
+
+func testReport(t *testing.T) *govulncheck.Report {
+	t.Helper()
+	r := &govulncheck.Report{}
+	if err := r.Add("grafana", []byte(grafanaOutput)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add("grafana-cli", []byte(cliOutput)); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestReportAdd(t *testing.T) {
+	r := testReport(t)
+	if len(r.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(r.Findings))
+	}
+
+	protobuf, net := r.Findings[0], r.Findings[1]
+	if net.ID != "GO-2024-2687" || !net.Called || net.FixedVersion != "v0.23.0" || net.Summary == "" {
+		t.Fatalf("unexpected finding: %+v", net)
+	}
+	if len(net.Binaries) != 2 {
+		t.Fatalf("expected GO-2024-2687 to affect 2 binaries, got %v", net.Binaries)
+	}
+	if protobuf.ID != "GO-2024-2611" || protobuf.Called || protobuf.Module != "google.golang.org/protobuf" {
+		t.Fatalf("unexpected finding: %+v", protobuf)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	r := testReport(t)
+	if err := govulncheck.Check(r, govulncheck.PolicyFail); !errors.Is(err, govulncheck.ErrorVulnerable) {
+		t.Fatalf("expected ErrorVulnerable, got '%v'", err)
+	}
+	if err := govulncheck.Check(r, govulncheck.PolicyReport); err != nil {
+		t.Fatalf("expected no error with the report policy, got '%v'", err)
+	}
+
+	// Allowing a vulnerability by its CVE alias should also work
+	r.Allow([]string{"CVE-2023-45288"})
+	if err := govulncheck.Check(r, govulncheck.PolicyFail); err != nil {
+		t.Fatalf("expected no error after allowing the called vulnerability, got '%v'", err)
+	}
+}
+
+func TestParseAllowlist(t *testing.T) {
+	ids := govulncheck.ParseAllowlist("# Not reachable in Grafana\nGO-2024-2687 # x/net\n\n  CVE-2024-24786\n")
+	if len(ids) != 2 || ids[0] != "GO-2024-2687" || ids[1] != "CVE-2024-24786" {
+		t.Fatalf("unexpected allowlist: %v", ids)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if _, err := govulncheck.ParsePolicy("warn"); !errors.Is(err, govulncheck.ErrorInvalidPolicy) {
+		t.Fatalf("expected ErrorInvalidPolicy, got '%v'", err)
+	}
+	if p, err := govulncheck.ParsePolicy("report"); err != nil || p != govulncheck.PolicyReport {
+		t.Fatalf("expected the report policy, got '%s', '%v'", p, err)
+	}
+}
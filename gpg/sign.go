@@ -16,6 +16,12 @@ const RPMMacros = `
 	%{?_gpg_digest_algo:--digest-algo %{_gpg_digest_algo}} %{__plaintext_filename}
 `
 
+// GPGConf makes gpg sign without prompting for the passphrase, for tools like debsigs that run gpg without those options.
+const GPGConf = `batch
+pinentry-mode loopback
+passphrase-file /root/.rpmdb/passkeys/grafana.key
+`
+
 type GPGOpts struct {
 	GPGPrivateKey string
 	GPGPublicKey  string
@@ -31,7 +37,7 @@ func Signer(d *dagger.Client, pubkey, privkey, passphrase string) *dagger.Contai
 
 	return d.Container().From("debian:stable").
 		WithExec([]string{"apt-get", "update"}).
-		WithExec([]string{"apt-get", "install", "-yq", "rpm", "gnupg2", "file"}).
+		WithExec([]string{"apt-get", "install", "-yq", "rpm", "gnupg2", "file", "debsigs"}).
 		WithMountedSecret("/root/.rpmdb/privkeys/grafana.key", gpgPrivateKeySecret).
 		WithMountedSecret("/root/.rpmdb/pubkeys/grafana.key", gpgPublicKeySecret).
 		WithMountedSecret("/root/.rpmdb/passkeys/grafana.key", gpgPassphraseSecret).
@@ -54,12 +60,33 @@ func Signer(d *dagger.Client, pubkey, privkey, passphrase string) *dagger.Contai
 		WithNewFile("/root/.rpmmacros", RPMMacros, dagger.ContainerWithNewFileOpts{
 			Permissions: 0400,
 		}).
-		WithExec([]string{"gpg", "--batch", "--yes", "--no-tty", "--allow-secret-key-import", "--import", "/root/.rpmdb/privkeys/grafana.key"})
+		WithExec([]string{"gpg", "--batch", "--yes", "--no-tty", "--allow-secret-key-import", "--import", "/root/.rpmdb/privkeys/grafana.key"}).
+		WithNewFile("/root/.gnupg/gpg.conf", GPGConf, dagger.ContainerWithNewFileOpts{
+			Permissions: 0600,
+		})
 }
 
+// Sign signs the rpm 'file' with 'rpm --addsign'.
 func Sign(d *dagger.Client, file *dagger.File, opts GPGOpts) *dagger.File {
 	return Signer(d, opts.GPGPublicKey, opts.GPGPrivateKey, opts.GPGPassphrase).
 		WithMountedFile("/src/package.rpm", file).
 		WithExec([]string{"rpm", "--addsign", "/src/package.rpm"}).
 		File("/src/package.rpm")
 }
+
+// SignDeb adds an origin signature to the deb 'file' with debsigs. The signature is the '_gpgorigin' member of the deb.
+func SignDeb(d *dagger.Client, file *dagger.File, opts GPGOpts) *dagger.File {
+	return Signer(d, opts.GPGPublicKey, opts.GPGPrivateKey, opts.GPGPassphrase).
+		WithFile("/src/package.deb", file).
+		WithExec([]string{"debsigs", "--sign=origin", "/src/package.deb"}).
+		File("/src/package.deb")
+}
+
+// SignDetached returns a detached armored signature ('.asc') of 'file', for packages that can't be signed themselves like tar.gz, zip, and
+// msi files.
+func SignDetached(d *dagger.Client, file *dagger.File, opts GPGOpts) *dagger.File {
+	return Signer(d, opts.GPGPublicKey, opts.GPGPrivateKey, opts.GPGPassphrase).
+		WithFile("/src/package", file).
+		WithExec([]string{"gpg", "--armor", "--detach-sign", "--output", "/src/package.asc", "/src/package"}).
+		File("/src/package.asc")
+}
@@ -8,6 +8,15 @@ import (
 	"github.com/grafana/grafana-build/containers"
 )
 
+// Verifier returns a container with only the public key imported into gpg, so that signatures are checked the way a user would check them.
+func Verifier(d *dagger.Client, pubkey string) *dagger.Container {
+	return d.Container().From("debian:stable").
+		WithExec([]string{"apt-get", "update"}).
+		WithExec([]string{"apt-get", "install", "-yq", "gnupg2", "binutils"}).
+		WithMountedSecret("/root/grafana.key", d.SetSecret("gpg-public-key", pubkey)).
+		WithExec([]string{"gpg", "--batch", "--import", "/root/grafana.key"})
+}
+
 func VerifySignature(ctx context.Context, d *dagger.Client, file *dagger.File, pubKey, privKey, passphrase string) error {
 	container := Signer(d, pubKey, privKey, passphrase).
 		WithFile("/src/package.rpm", file).
@@ -18,3 +27,35 @@ func VerifySignature(ctx context.Context, d *dagger.Client, file *dagger.File, p
 	}
 	return nil
 }
+
+// VerifyDebSignature checks the debsigs origin signature of the deb 'file'. The signature is of the other members of the deb in order
+// ('debian-binary', 'control.tar.*', and 'data.tar.*'), which is the same as what debsig-verify checks without needing a policy.
+func VerifyDebSignature(ctx context.Context, d *dagger.Client, file *dagger.File, pubKey string) error {
+	container := Verifier(d, pubKey).
+		WithFile("/src/package.deb", file).
+		WithWorkdir("/src").
+		WithExec([]string{"/bin/sh", "-c", `set -e
+ar t package.deb | grep -qx _gpgorigin || { echo 'the deb has no origin signature'; exit 1; }
+for m in $(ar t package.deb | grep -v '^_gpg'); do ar p package.deb "$m"; done > signed
+ar p package.deb _gpgorigin > _gpgorigin
+gpg --batch --verify _gpgorigin signed
+`})
+
+	if _, err := containers.ExitError(ctx, container); err != nil {
+		return fmt.Errorf("failed to validate gpg signature for deb package: %w", err)
+	}
+	return nil
+}
+
+// VerifyDetachedSignature checks that 'signature' (from SignDetached) is a valid signature of 'file'.
+func VerifyDetachedSignature(ctx context.Context, d *dagger.Client, file, signature *dagger.File, pubKey string) error {
+	container := Verifier(d, pubKey).
+		WithFile("/src/package", file).
+		WithFile("/src/package.asc", signature).
+		WithExec([]string{"gpg", "--batch", "--verify", "/src/package.asc", "/src/package"})
+
+	if _, err := containers.ExitError(ctx, container); err != nil {
+		return fmt.Errorf("failed to validate detached gpg signature: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package lint
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/containers"
+)
+
+//go:embed profile/*
+var profile embed.FS
+
+const (
+	LintianImage = "debian:bookworm"
+	RPMLintImage = "fedora:40"
+
+	// LintianSuppressions, LintianOverrides, and RPMLintConfig are the checked-in suppression profile in 'profile'.
+	LintianSuppressions = "profile/lintian-suppressions"
+	LintianOverrides    = "profile/lintian-overrides"
+	RPMLintConfig       = "profile/grafana.rpmlintrc"
+)
+
+func profileFile(d *dagger.Client, name string) (*dagger.File, error) {
+	b, err := profile.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Directory().WithNewFile("profile", string(b)).File("profile"), nil
+}
+
+// LintianContainer returns a container with lintian installed.
+func LintianContainer(d *dagger.Client) *dagger.Container {
+	return d.Container().From(LintianImage).
+		WithExec([]string{"apt-get", "update"}).
+		WithExec([]string{"apt-get", "install", "-yq", "--no-install-recommends", "lintian"})
+}
+
+// RPMLintContainer returns a container with rpmlint installed.
+func RPMLintContainer(d *dagger.Client) *dagger.Container {
+	return d.Container().From(RPMLintImage).
+		WithExec([]string{"dnf", "install", "-y", "rpmlint"})
+}
+
+// run runs the linter 'cmd' and returns its output. Linters exit with a non-zero code when they find problems, so only the exit codes that
+// aren't in 'ok' are errors.
+func run(ctx context.Context, c *dagger.Container, cmd string, ok ...int) (string, error) {
+	codes := make([]string, len(ok))
+	for i, v := range ok {
+		codes[i] = fmt.Sprintf("[ $code -eq %d ]", v)
+	}
+
+	script := fmt.Sprintf("%s 2>&1; code=$?; %s || exit $code", cmd, strings.Join(codes, " || "))
+	c, err := containers.ExitError(ctx, c.WithExec([]string{"/bin/sh", "-c", script}))
+	if err != nil {
+		return "", err
+	}
+
+	return c.Stdout(ctx)
+}
+
+func version(ctx context.Context, c *dagger.Container, cmd string) (string, error) {
+	out, err := c.WithExec([]string{cmd, "--version"}).Stdout(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(out), "Lintian v")), nil
+}
+
+// Deb lints the deb 'file' with lintian in the container 'c' (from LintianContainer). 'name' is the name of the package in the report.
+// The findings that match the overrides in LintianOverrides are removed from the report.
+func Deb(ctx context.Context, d *dagger.Client, c *dagger.Container, file *dagger.File, name string) (*Report, error) {
+	suppressions, err := profileFile(d, LintianSuppressions)
+	if err != nil {
+		return nil, err
+	}
+
+	c = c.
+		WithMountedFile("/src/lintian-suppressions", suppressions).
+		WithMountedFile("/src/package.deb", file)
+
+	v, err := version(ctx, c, "lintian")
+	if err != nil {
+		return nil, err
+	}
+
+	// lintian exits with 1 if there are errors and 2 if it fails to run.
+	// It refuses to run as root without '--allow-root'.
+	out, err := run(ctx, c, "lintian --allow-root --no-tag-display-limit --display-info --suppress-tags-from-file /src/lintian-suppressions /src/package.deb", 0, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error running lintian: %w", err)
+	}
+
+	overrides, err := profile.ReadFile(LintianOverrides)
+	if err != nil {
+		return nil, err
+	}
+	o, err := ParseOverrides(string(overrides))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing '%s': %w", LintianOverrides, err)
+	}
+
+	r := ApplyOverrides(ParseLintian(name, out), o)
+	r.Version = v
+	return r, nil
+}
+
+// RPM lints the rpm 'file' with rpmlint in the container 'c' (from RPMLintContainer). 'name' is the name of the package in the report.
+func RPM(ctx context.Context, d *dagger.Client, c *dagger.Container, file *dagger.File, name string) (*Report, error) {
+	config, err := profileFile(d, RPMLintConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c = c.
+		WithMountedFile("/src/grafana.rpmlintrc", config).
+		WithMountedFile("/src/package.rpm", file)
+
+	v, err := version(ctx, c, "rpmlint")
+	if err != nil {
+		return nil, err
+	}
+
+	// rpmlint exits with 64 if there are errors and 66 if the errors are over the badness threshold.
+	out, err := run(ctx, c, "rpmlint --rpmlintrc /src/grafana.rpmlintrc /src/package.rpm", 0, 64, 66)
+	if err != nil {
+		return nil, fmt.Errorf("error running rpmlint: %w", err)
+	}
+
+	r := ParseRPMLint(name, out)
+	r.Version = v
+	return r, nil
+}
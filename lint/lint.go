@@ -0,0 +1,211 @@
+// Package lint checks built deb and rpm packages with lintian and rpmlint, using the suppression profile in 'profile'.
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Policy string
+
+const (
+	// PolicyFail fails verification if the linter reports an error.
+	PolicyFail Policy = "fail"
+	// PolicyReport never fails verification; findings are only reported.
+	PolicyReport Policy = "report"
+)
+
+var (
+	ErrorInvalidPolicy = errors.New("invalid package lint policy; expected 'fail' or 'report'")
+	ErrorLint          = errors.New("the package has lint errors")
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicyFail, PolicyReport:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("%w: '%s'", ErrorInvalidPolicy, s)
+}
+
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelInfo    Level = "info"
+)
+
+// levels maps the single letter levels of lintian and rpmlint to a Level. Lintian's pedantic and experimental findings are reported as
+// info.
+var levels = map[string]Level{
+	"E": LevelError,
+	"W": LevelWarning,
+	"I": LevelInfo,
+	"P": LevelInfo,
+	"X": LevelInfo,
+}
+
+// A Finding is a single problem reported by a linter.
+type Finding struct {
+	Level Level  `json:"level"`
+	Tag   string `json:"tag"`
+	// Package is the package name as reported by the linter, like 'grafana' or 'grafana.x86_64'.
+	Package string `json:"package"`
+	Info    string `json:"info,omitempty"`
+}
+
+// Report is the result of linting a single package.
+type Report struct {
+	// Linter is 'lintian' or 'rpmlint'.
+	Linter   string     `json:"linter"`
+	Version  string     `json:"version"`
+	Package  string     `json:"package"`
+	Findings []*Finding `json:"findings"`
+}
+
+// Count returns the number of findings at 'level'.
+func (r *Report) Count(level Level) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Level == level {
+			n++
+		}
+	}
+
+	return n
+}
+
+// JSON returns the report encoded as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+func (r *Report) sort() {
+	order := map[Level]int{LevelError: 0, LevelWarning: 1, LevelInfo: 2}
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if order[a.Level] != order[b.Level] {
+			return order[a.Level] < order[b.Level]
+		}
+		return a.Tag < b.Tag
+	})
+}
+
+var (
+	// Like 'E: grafana: tag-name some info' or 'W: grafana source: tag-name'.
+	lintianLine = regexp.MustCompile(`^([A-Z]): ([^:]+): (\S+)(?: (.*))?$`)
+	// Like 'grafana.x86_64: E: tag-name some info'.
+	rpmlintLine = regexp.MustCompile(`^(\S+): ([A-Z]): (\S+)(?: (.*))?$`)
+)
+
+func parse(r *Report, out string, re *regexp.Regexp, level, pkg int) *Report {
+	for _, line := range strings.Split(out, "\n") {
+		m := re.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		l, ok := levels[m[level]]
+		if !ok {
+			// Overridden findings and notes
+			continue
+		}
+		r.Findings = append(r.Findings, &Finding{
+			Level:   l,
+			Tag:     m[3],
+			Package: m[pkg],
+			Info:    strings.TrimSpace(m[4]),
+		})
+	}
+
+	r.sort()
+	return r
+}
+
+// ParseLintian parses the output of lintian for the package 'name'.
+func ParseLintian(name, out string) *Report {
+	return parse(&Report{Linter: "lintian", Package: name, Findings: []*Finding{}}, out, lintianLine, 1, 2)
+}
+
+// ParseRPMLint parses the output of rpmlint for the package 'name'.
+func ParseRPMLint(name, out string) *Report {
+	return parse(&Report{Linter: "rpmlint", Package: name, Findings: []*Finding{}}, out, rpmlintLine, 2, 1)
+}
+
+// An Override drops the findings for a tag whose info matches a regular expression, so that a tag can be ignored for some files without
+// suppressing it for the whole package.
+type Override struct {
+	Tag  string
+	Info *regexp.Regexp
+}
+
+// ParseOverrides parses a list of overrides, which has a tag and a regular expression on every line, separated by a space.
+// Empty lines and lines that start with a '#' are ignored.
+func ParseOverrides(contents string) ([]Override, error) {
+	overrides := []Override{}
+	for i, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		tag, expr, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a tag and a regular expression: '%s'", i+1, line)
+		}
+		re, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		overrides = append(overrides, Override{Tag: tag, Info: re})
+	}
+
+	return overrides, nil
+}
+
+// ApplyOverrides removes the findings in the report that match one of the overrides.
+func ApplyOverrides(r *Report, overrides []Override) *Report {
+	findings := []*Finding{}
+	for _, f := range r.Findings {
+		if !overridden(f, overrides) {
+			findings = append(findings, f)
+		}
+	}
+
+	r.Findings = findings
+	return r
+}
+
+func overridden(f *Finding, overrides []Override) bool {
+	for _, o := range overrides {
+		if o.Tag == f.Tag && o.Info.MatchString(f.Info) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check returns an error if the report has errors and the policy is PolicyFail.
+func Check(report *Report, policy Policy) error {
+	if policy == PolicyReport {
+		return nil
+	}
+
+	tags := []string{}
+	for _, f := range report.Findings {
+		if f.Level == LevelError {
+			tags = append(tags, f.Tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s: %s", ErrorLint, report.Package, strings.Join(tags, ", "))
+}
@@ -0,0 +1,116 @@
+package lint_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/grafana/grafana-build/lint"
+)
+
+const lintianOutput = `W: grafana: description-synopsis-starts-with-article
+E: grafana: bad-permissions-for-etc-cron.d-script [etc/cron.d/grafana 0755 != 0644]
+O: grafana: statically-linked-binary [usr/share/grafana/bin/grafana]
+I: grafana: spelling-error-in-description
+N: 1 hint overridden (1 error); 1 unused override
+E: grafana: maintainer-script-without-set-e postinst
+`
+
+const rpmlintOutput = `============================ rpmlint session starts ============================
+rpmlint: 2.5.0
+configuration:
+    /usr/lib/python3.12/site-packages/rpmlint/configdefaults.toml
+checks: 32, packages: 1
+
+grafana.x86_64: W: non-conffile-in-etc /etc/grafana/ldap.toml
+grafana.x86_64: E: non-standard-executable-perm /usr/sbin/grafana-cli 775
+grafana.x86_64: E: wrong-script-interpreter
+ 1 packages and 0 specfiles checked; 2 errors, 1 warnings, 0 badness; has taken 0.3 s
+`
+
+func TestParseLintian(t *testing.T) {
+	r := lint.ParseLintian("grafana_12.0.0_amd64.deb", lintianOutput)
+	if len(r.Findings) != 4 {
+		t.Fatalf("expected 4 findings, got %d: %+v", len(r.Findings), r.Findings)
+	}
+	if r.Count(lint.LevelError) != 2 || r.Count(lint.LevelWarning) != 1 || r.Count(lint.LevelInfo) != 1 {
+		t.Fatalf("unexpected levels: %+v", r.Findings)
+	}
+
+	// Errors are first
+	f := r.Findings[0]
+	if f.Level != lint.LevelError || f.Tag != "bad-permissions-for-etc-cron.d-script" || f.Package != "grafana" || f.Info != "[etc/cron.d/grafana 0755 != 0644]" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+}
+
+func TestParseRPMLint(t *testing.T) {
+	r := lint.ParseRPMLint("grafana-12.0.0_amd64.rpm", rpmlintOutput)
+	if len(r.Findings) != 3 || r.Count(lint.LevelError) != 2 {
+		t.Fatalf("unexpected findings: %+v", r.Findings)
+	}
+
+	f := r.Findings[0]
+	if f.Tag != "non-standard-executable-perm" || f.Package != "grafana.x86_64" || f.Info != "/usr/sbin/grafana-cli 775" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+	if f := r.Findings[1]; f.Tag != "wrong-script-interpreter" || f.Info != "" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	r := lint.ParseRPMLint("grafana.rpm", rpmlintOutput)
+	if err := lint.Check(r, lint.PolicyFail); !errors.Is(err, lint.ErrorLint) {
+		t.Fatalf("expected a lint error, got %v", err)
+	}
+	if err := lint.Check(r, lint.PolicyReport); err != nil {
+		t.Fatalf("expected no error with the 'report' policy, got %v", err)
+	}
+	if err := lint.Check(lint.ParseRPMLint("grafana.rpm", "grafana.x86_64: W: no-documentation\n"), lint.PolicyFail); err != nil {
+		t.Fatalf("expected no error for warnings, got %v", err)
+	}
+
+	if _, err := lint.ParsePolicy("ignore"); !errors.Is(err, lint.ErrorInvalidPolicy) {
+		t.Fatalf("expected an invalid policy, got %v", err)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	for _, v := range []string{lint.LintianSuppressions, lint.LintianOverrides, lint.RPMLintConfig} {
+		if _, err := fs.Stat(os.DirFS("."), v); err != nil {
+			t.Fatalf("expected the suppression profile '%s': %v", v, err)
+		}
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	out := `W: grafana: script-not-executable [usr/share/grafana/packaging/deb/init.d/grafana-server]
+W: grafana: script-not-executable [usr/sbin/grafana-server]
+E: grafana: malformed-contact Maintainer contact@grafana.com
+E: grafana: malformed-contact Uploaders contact@grafana.com
+`
+	b, err := os.ReadFile(lint.LintianOverrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := lint.ParseOverrides(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := lint.ApplyOverrides(lint.ParseLintian("grafana.deb", out), overrides)
+	if len(r.Findings) != 2 {
+		t.Fatalf("expected only the findings outside of the overridden paths and fields, got %+v", r.Findings)
+	}
+	for _, f := range r.Findings {
+		if f.Info != "Uploaders contact@grafana.com" && f.Info != "[usr/sbin/grafana-server]" {
+			t.Fatalf("unexpected finding: %+v", f)
+		}
+	}
+
+	if _, err := lint.ParseOverrides("script-not-executable"); err == nil {
+		t.Fatal("expected an error for an override without a regular expression")
+	}
+}
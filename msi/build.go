@@ -5,10 +5,11 @@ import (
 
 	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/containers"
+	"github.com/grafana/grafana-build/packages"
 )
 
-func Build(d *dagger.Client, builder *dagger.Container, targz *dagger.File, version string, enterprise bool) (*dagger.File, error) {
-	wxsFiles, err := WXSFiles(version, enterprise)
+func Build(d *dagger.Client, builder *dagger.Container, targz *dagger.File, version string, enterprise bool, metadata packages.Metadata) (*dagger.File, error) {
+	wxsFiles, err := WXSFiles(version, enterprise, metadata)
 	if err != nil {
 		return nil, fmt.Errorf("error generating wxs files: %w", err)
 	}
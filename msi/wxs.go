@@ -3,18 +3,27 @@ package msi
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"regexp"
 	"strings"
 	"text/template"
+
+	"github.com/grafana/grafana-build/packages"
 )
 
+// DefaultHelpLink is the help and about link of the installer if no homepage is set in the package metadata.
+const DefaultHelpLink = "https://www.grafana.com"
+
 type wxsCfg struct {
 	GrafanaVersion string
 	UpgradeCode    string
-	ProductName    string
-	Title          string
-	Manufacturer   string
-	License        string
+	// FeatureID is the ID of the product feature, which can't be changed by the metadata because it has to be a valid identifier.
+	FeatureID    string
+	ProductName  string
+	Title        string
+	Manufacturer string
+	Homepage     string
+	License      string
 }
 
 var semverRegex = regexp.MustCompile(`^(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
@@ -55,16 +64,20 @@ type WXSFile struct {
 	Contents string
 }
 
-func WXSFiles(version string, enterprise bool) ([]WXSFile, error) {
-	upgradeCode := "35c7d2a9-6e23-4645-b975-e8693a1cef10"
-	prodName := "GrafanaOSS"
-	title := "Grafana OSS"
+// WXSFiles returns the WiX sources of the installer. The upgrade code, product name, title, manufacturer, and links are from the package
+// 'metadata'; they are escaped for XML.
+func WXSFiles(version string, enterprise bool, metadata packages.Metadata) ([]WXSFile, error) {
+	helpLink := DefaultHelpLink
+	if metadata.Homepage != "" {
+		helpLink = metadata.Homepage
+	}
+
+	metadata = metadata.For(enterprise)
+	featureID := "GrafanaOSS"
 	license := "LICENSE.rtf"
 
 	if enterprise {
-		upgradeCode = "d534ec50-476b-4edc-a25e-fe854c949f4f"
-		prodName = "GrafanaEnterprise"
-		title = "Grafana Enterprise"
+		featureID = "GrafanaEnterprise"
 		license = "EE_LICENSE.rtf"
 	}
 
@@ -72,10 +85,12 @@ func WXSFiles(version string, enterprise bool) ([]WXSFile, error) {
 
 	cfg := wxsCfg{
 		GrafanaVersion: WxsVersion(ersion),
-		UpgradeCode:    upgradeCode,
-		ProductName:    prodName,
-		Title:          title,
-		Manufacturer:   "Grafana Labs",
+		UpgradeCode:    html.EscapeString(metadata.EditionUpgradeCode(enterprise)),
+		FeatureID:      featureID,
+		ProductName:    html.EscapeString(metadata.ProductName),
+		Title:          html.EscapeString(metadata.Title),
+		Manufacturer:   html.EscapeString(metadata.Vendor),
+		Homepage:       html.EscapeString(helpLink),
 		License:        license,
 	}
 
@@ -150,8 +165,8 @@ const prodTemplate = `<?xml version="1.0"?>
     <WixVariable Id="WixUIDialogBmp" Value="grafana_dialog_background.bmp" />
 
     <Property Id="ARPPRODUCTICON" Value="icon.ico" />
-    <Property Id="ARPHELPLINK" Value="https://www.grafana.com" />
-    <Property Id="ARPURLINFOABOUT" Value="https://www.grafana.com" />
+    <Property Id="ARPHELPLINK" Value="{{.Homepage}}" />
+    <Property Id="ARPURLINFOABOUT" Value="{{.Homepage}}" />
     <SetProperty Id="ARPINSTALLLOCATION" Value="[ApplicationFolder]"
       After="CostFinalize" />
 
@@ -165,7 +180,7 @@ const prodTemplate = `<?xml version="1.0"?>
     </Directory>
 
     <Feature Id="DefaultFeature" Title="Grafana" Display="expand" ConfigurableDirectory="INSTALLDIR">
-      <Feature Id="{{.ProductName }}" Title="{{ .Title }}" Level="1">
+      <Feature Id="{{.FeatureID }}" Title="{{ .Title }}" Level="1">
         <ComponentGroupRef Id="GrafanaX64" />
       </Feature>
       <Feature Id="GrafanaServiceFeature" Title="Run Grafana as a Service" Level="1">
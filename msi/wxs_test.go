@@ -1,9 +1,11 @@
 package msi_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/grafana/grafana-build/msi"
+	"github.com/grafana/grafana-build/packages"
 )
 
 func TestVersion(t *testing.T) {
@@ -20,3 +22,54 @@ func TestVersion(t *testing.T) {
 		}
 	}
 }
+
+// productWXS returns the contents of grafana-product.wxs for the metadata.
+func productWXS(t *testing.T, enterprise bool, metadata packages.Metadata) string {
+	t.Helper()
+	files, err := msi.WXSFiles("v12.0.0", enterprise, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		if f.Name == "grafana-product.wxs" {
+			return f.Contents
+		}
+	}
+
+	t.Fatal("expected grafana-product.wxs")
+	return ""
+}
+
+func TestWXSFiles(t *testing.T) {
+	t.Run("The metadata should be in the product wxs", func(t *testing.T) {
+		contents := productWXS(t, false, packages.Metadata{
+			Vendor:      "Example Corp",
+			Homepage:    "https://example.com",
+			UpgradeCode: "0f0a3a66-0c0e-4bc1-8a29-3b7d1a0a5d11",
+			ProductName: "ExampleGrafana",
+			Title:       "Example Grafana",
+		})
+		for _, v := range []string{`UpgradeCode="0f0a3a66-0c0e-4bc1-8a29-3b7d1a0a5d11"`, `Manufacturer="Example Corp"`, `Value="https://example.com"`, `Name="ExampleGrafana"`, `Title="Example Grafana"`, `Feature Id="GrafanaOSS"`} {
+			if !strings.Contains(contents, v) {
+				t.Fatalf("expected '%s' in the product wxs:\n%s", v, contents)
+			}
+		}
+	})
+
+	t.Run("The defaults should not change", func(t *testing.T) {
+		contents := productWXS(t, true, packages.Metadata{})
+		for _, v := range []string{`Manufacturer="Grafana Labs"`, `Name="GrafanaEnterprise"`, `Title="Grafana Enterprise"`, `<Property Id="ARPHELPLINK" Value="https://www.grafana.com" />`} {
+			if !strings.Contains(contents, v) {
+				t.Fatalf("expected '%s' in the product wxs:\n%s", v, contents)
+			}
+		}
+	})
+
+	t.Run("The metadata should be escaped", func(t *testing.T) {
+		contents := productWXS(t, false, packages.Metadata{Vendor: `Example "Corp" & <Co>`})
+		if v := `Manufacturer="Example &#34;Corp&#34; &amp; &lt;Co&gt;"`; !strings.Contains(contents, v) {
+			t.Fatalf("expected '%s' in the product wxs:\n%s", v, contents)
+		}
+	})
+}
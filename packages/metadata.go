@@ -0,0 +1,113 @@
+package packages
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	DefaultVendor      = "Grafana Labs"
+	DefaultMaintainer  = "contact@grafana.com"
+	DefaultHomepage    = "https://grafana.com"
+	DefaultLicense     = "AGPLv3"
+	DefaultDescription = "Grafana"
+
+	DefaultEnterpriseDescription = "Grafana Enterprise"
+
+	// DefaultProductName and DefaultTitle are the product name and the feature title of the Grafana installers.
+	DefaultProductName           = "GrafanaOSS"
+	DefaultTitle                 = "Grafana OSS"
+	DefaultEnterpriseProductName = "GrafanaEnterprise"
+	DefaultEnterpriseTitle       = "Grafana Enterprise"
+
+	// DefaultUpgradeCode and DefaultEnterpriseUpgradeCode are the MSI upgrade codes of the Grafana installers. Installers with the same
+	// upgrade code replace each other.
+	DefaultUpgradeCode           = "35c7d2a9-6e23-4645-b975-e8693a1cef10"
+	DefaultEnterpriseUpgradeCode = "d534ec50-476b-4edc-a25e-fe854c949f4f"
+)
+
+// Metadata is the metadata of the deb, rpm, apk, and msi packages and the labels of the docker images. Empty fields are set to the
+// defaults for the edition by For.
+type Metadata struct {
+	Vendor      string `json:"vendor,omitempty"`
+	Maintainer  string `json:"maintainer,omitempty"`
+	Homepage    string `json:"homepage,omitempty"`
+	Description string `json:"description,omitempty"`
+	// License is only set by default for Grafana OSS.
+	License string `json:"license,omitempty"`
+	// Depends are added to the dependencies of the deb, rpm, and apk packages, in the format 'name' or 'name <op> version'.
+	Depends []string `json:"depends,omitempty"`
+
+	UpgradeCode           string `json:"upgrade_code,omitempty"`
+	EnterpriseUpgradeCode string `json:"enterprise_upgrade_code,omitempty"`
+	// ProductName and Title are the name of the product and the title of its feature in the msi.
+	ProductName string `json:"product_name,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// ParseMetadata parses a JSON metadata file. Unknown fields are an error so that typos aren't silently ignored.
+func ParseMetadata(b []byte) (Metadata, error) {
+	m := Metadata{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return Metadata{}, fmt.Errorf("error parsing package metadata: %w", err)
+	}
+
+	return m, nil
+}
+
+// Merge returns 'm' with every field that is set in 'o' replaced. Dependencies are added.
+func (m Metadata) Merge(o Metadata) Metadata {
+	set := func(v *string, s string) {
+		if s != "" {
+			*v = s
+		}
+	}
+
+	set(&m.Vendor, o.Vendor)
+	set(&m.Maintainer, o.Maintainer)
+	set(&m.Homepage, o.Homepage)
+	set(&m.Description, o.Description)
+	set(&m.License, o.License)
+	set(&m.UpgradeCode, o.UpgradeCode)
+	set(&m.EnterpriseUpgradeCode, o.EnterpriseUpgradeCode)
+	set(&m.ProductName, o.ProductName)
+	set(&m.Title, o.Title)
+	m.Depends = append(append([]string{}, m.Depends...), o.Depends...)
+
+	return m
+}
+
+// For returns the metadata with the defaults of Grafana OSS or Grafana Enterprise in every field that is not set.
+func (m Metadata) For(enterprise bool) Metadata {
+	d := Metadata{
+		Vendor:                DefaultVendor,
+		Maintainer:            DefaultMaintainer,
+		Homepage:              DefaultHomepage,
+		Description:           DefaultDescription,
+		License:               DefaultLicense,
+		UpgradeCode:           DefaultUpgradeCode,
+		EnterpriseUpgradeCode: DefaultEnterpriseUpgradeCode,
+		ProductName:           DefaultProductName,
+		Title:                 DefaultTitle,
+	}
+	if enterprise {
+		d.Description = DefaultEnterpriseDescription
+		d.License = ""
+		d.ProductName = DefaultEnterpriseProductName
+		d.Title = DefaultEnterpriseTitle
+	}
+
+	return d.Merge(m)
+}
+
+// EditionUpgradeCode returns the MSI upgrade code of the edition.
+func (m Metadata) EditionUpgradeCode(enterprise bool) string {
+	if enterprise {
+		return m.EnterpriseUpgradeCode
+	}
+
+	return m.UpgradeCode
+}
@@ -0,0 +1,42 @@
+package packages_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grafana/grafana-build/packages"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("It should use the defaults for the edition", func(t *testing.T) {
+		m := packages.Metadata{}.For(true)
+		if m.Vendor != packages.DefaultVendor || m.Description != packages.DefaultEnterpriseDescription || m.License != "" {
+			t.Fatalf("unexpected enterprise defaults: %+v", m)
+		}
+		if m.EditionUpgradeCode(true) != packages.DefaultEnterpriseUpgradeCode {
+			t.Fatalf("expected the enterprise upgrade code, got '%s'", m.EditionUpgradeCode(true))
+		}
+		if m := (packages.Metadata{}).For(false); m.License != packages.DefaultLicense || m.Description != packages.DefaultDescription {
+			t.Fatalf("unexpected OSS defaults: %+v", m)
+		}
+	})
+	t.Run("It should override the file with the flags and add the dependencies", func(t *testing.T) {
+		file, err := packages.ParseMetadata([]byte(`{"vendor": "Example Corp", "homepage": "https://example.com", "depends": ["tzdata"]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := file.Merge(packages.Metadata{Vendor: "Example Inc", Depends: []string{"ca-certificates"}}).For(false)
+		if m.Vendor != "Example Inc" || m.Homepage != "https://example.com" || m.Maintainer != packages.DefaultMaintainer {
+			t.Fatalf("unexpected metadata: %+v", m)
+		}
+		if expected := []string{"tzdata", "ca-certificates"}; !reflect.DeepEqual(m.Depends, expected) {
+			t.Fatalf("expected %v, got %v", expected, m.Depends)
+		}
+	})
+	t.Run("It should return an error for unknown fields", func(t *testing.T) {
+		if _, err := packages.ParseMetadata([]byte(`{"vendr": "Example Corp"}`)); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -58,6 +58,12 @@ type ArtifactHandler interface {
 	VerifyDirectory(context.Context, *dagger.Client, *dagger.Directory) error
 }
 
+// A DependencyFileVerifier is an ArtifactHandler that needs the files of its dependencies to verify its own file, like a signature that is
+// verified against the signed package. VerifyFileWithStore is called instead of VerifyFile for these handlers.
+type DependencyFileVerifier interface {
+	VerifyFileWithStore(ctx context.Context, client *dagger.Client, store ArtifactStore, file *dagger.File) error
+}
+
 type Artifact struct {
 	// ArtifactString is the artifact string provided by the user.
 	// If the artifact is being initialized as a dependency where an artifact string is not provided,
@@ -100,6 +100,24 @@ func (a *ArtifactHandlerLogger) VerifyFile(ctx context.Context, client *dagger.C
 	return nil
 }
 
+// VerifyFileWithStore verifies the file with the files of the dependencies from the store if the handler is a DependencyFileVerifier, and
+// with VerifyFile if it isn't.
+func (a *ArtifactHandlerLogger) VerifyFileWithStore(ctx context.Context, client *dagger.Client, store ArtifactStore, file *dagger.File) error {
+	h, ok := a.Handler.(DependencyFileVerifier)
+	if !ok {
+		return a.VerifyFile(ctx, client, file)
+	}
+
+	a.log.InfoContext(ctx, "verifying file...")
+	if err := h.VerifyFileWithStore(ctx, client, store, file); err != nil {
+		a.log.InfoContext(ctx, "error verifying file", "error", err)
+		return err
+	}
+	a.log.InfoContext(ctx, "done verifying file")
+
+	return nil
+}
+
 func (a *ArtifactHandlerLogger) VerifyDirectory(ctx context.Context, client *dagger.Client, dir *dagger.Directory) error {
 	a.log.InfoContext(ctx, "verifying directory...")
 	if err := a.Handler.VerifyDirectory(ctx, client, dir); err != nil {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
 
 	"dagger.io/dagger"
 	"github.com/grafana/grafana-build/containers"
@@ -18,6 +20,28 @@ func PublishNPM(ctx context.Context, d *dagger.Client, args PipelineArgs) error
 		sm = semaphore.NewWeighted(args.ConcurrencyOpts.Parallel)
 	)
 
+	ifPublished, err := frontend.ParseNPMPublishPolicy(args.NpmIfPublished)
+	if err != nil {
+		return err
+	}
+
+	opts := &frontend.NPMPublishOpts{
+		Registry:    args.NpmRegistry,
+		Token:       args.NpmToken,
+		Tags:        args.NpmTags,
+		DryRun:      args.NpmDryRun,
+		Provenance:  args.NpmProvenance,
+		IfPublished: ifPublished,
+		Node: &frontend.Node{
+			Version:       args.NpmNodeVersion,
+			SourceVersion: args.NpmNodeVersion,
+			Image:         args.NpmNodeImage,
+		},
+	}
+	if opts.Provenance {
+		opts.ProvenanceEnv = frontend.ProvenanceEnv(os.Environ())
+	}
+
 	packages, err := containers.GetPackages(ctx, d, args.PackageInputOpts, args.GCPOpts)
 	if err != nil {
 		return err
@@ -38,13 +62,13 @@ func PublishNPM(ctx context.Context, d *dagger.Client, args PipelineArgs) error
 		}
 
 		for _, path := range entries {
-			wg.Go(PublishNPMFunc(ctx, sm, d, artifacts.File(path), path, args.NpmToken, args.NpmRegistry, args.NpmTags))
+			wg.Go(PublishNPMFunc(ctx, sm, d, artifacts.File(path), path, opts))
 		}
 	}
 	return wg.Wait()
 }
 
-func PublishNPMFunc(ctx context.Context, sm *semaphore.Weighted, d *dagger.Client, pkg *dagger.File, path, token, registry string, tags []string) func() error {
+func PublishNPMFunc(ctx context.Context, sm *semaphore.Weighted, d *dagger.Client, pkg *dagger.File, path string, opts *frontend.NPMPublishOpts) func() error {
 	return func() error {
 		log.Printf("[%s] Attempting to publish package", path)
 		log.Printf("[%s] Acquiring semaphore", path)
@@ -55,7 +79,7 @@ func PublishNPMFunc(ctx context.Context, sm *semaphore.Weighted, d *dagger.Clien
 		log.Printf("[%s] Acquired semaphore", path)
 
 		log.Printf("[%s] Publishing package", path)
-		out, err := frontend.PublishNPM(ctx, d, pkg, token, registry, tags)
+		out, err := frontend.PublishNPM(ctx, d, slog.Default().With("path", path), pkg, opts)
 		if err != nil {
 			return fmt.Errorf("[%s] error: %w", path, err)
 		}
@@ -78,9 +78,14 @@ type PipelineArgs struct {
 	ProImageOpts *containers.ProImageOpts
 
 	// NPMOpts will be populated if NPMFlags are enabled on the current sub-command.
-	NpmToken    string
-	NpmRegistry string
-	NpmTags     []string
+	NpmToken       string
+	NpmRegistry    string
+	NpmTags        []string
+	NpmDryRun      bool
+	NpmProvenance  bool
+	NpmIfPublished string
+	NpmNodeVersion string
+	NpmNodeImage   string
 
 	// GCOMOpts will be populated if GCOMFlags are enabled on the current sub-command.
 	GCOMOpts *gcom.GCOMOpts
@@ -119,6 +124,11 @@ func PipelineArgsFromContext(ctx context.Context, c cliutil.CLIContext) (Pipelin
 		NpmToken:         c.String("token"),
 		NpmRegistry:      c.String("registry"),
 		NpmTags:          c.StringSlice("tag"),
+		NpmDryRun:        c.Bool("dry-run"),
+		NpmProvenance:    c.Bool("provenance"),
+		NpmIfPublished:   c.String("if-published"),
+		NpmNodeVersion:   c.String("node-version"),
+		NpmNodeImage:     c.String("node-image"),
 	}, nil
 }
 
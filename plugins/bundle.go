@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/containers"
+)
+
+// Open reads and validates the plugin zip file 'name'. If 'sums' is not nil, then it must have the sha256 sum of the zip file.
+func Open(name string, sums map[string]string) (*Plugin, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if sums != nil {
+		sum, ok := sums[filepath.Base(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s' is not in the checksum file", ErrorInvalidChecksum, filepath.Base(name))
+		}
+		if err := CheckChecksum(f, filepath.Base(name), sum); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := ReadZip(f, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(name), err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(name), err)
+	}
+
+	return p, nil
+}
+
+// Unpack extracts the plugin folder from the zip file of the plugin 'p'.
+func Unpack(d *dagger.Client, zip *dagger.File, p *Plugin) *dagger.Directory {
+	return d.Container().From("alpine:3.20").
+		WithMountedFile("/plugin.zip", zip).
+		WithExec([]string{"unzip", "-q", "/plugin.zip", "-d", "/plugin"}).
+		Directory(path.Join("/plugin", p.Root))
+}
+
+// VerifySignatures verifies the signature of the 'MANIFEST.txt' of every plugin in 'dir' with the armored public key 'key'.
+func VerifySignatures(ctx context.Context, d *dagger.Client, dir *dagger.Directory, key *dagger.File) error {
+	c := d.Container().From("alpine:3.20").
+		WithExec([]string{"apk", "add", "--no-cache", "gnupg"}).
+		WithMountedFile("/plugins.key", key).
+		WithMountedDirectory("/plugins", dir).
+		WithExec([]string{"gpg", "--batch", "--import", "/plugins.key"}).
+		WithExec([]string{"/bin/sh", "-c", fmt.Sprintf(`set -e; for m in /plugins/*/%s; do echo "$m"; gpg --batch --verify "$m"; done`, ManifestFile)})
+
+	if _, err := containers.ExitError(ctx, c); err != nil {
+		return fmt.Errorf("%w: %s", ErrorInvalidSignature, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+// Package plugins reads and validates Grafana plugin zip files, so that they can be bundled with Grafana in 'plugins-bundled'.
+package plugins
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	JSONFile     = "plugin.json"
+	ManifestFile = "MANIFEST.txt"
+)
+
+var (
+	ErrorInvalidPlugin    = errors.New("invalid plugin")
+	ErrorInvalidSignature = errors.New("invalid plugin signature")
+	ErrorInvalidChecksum  = errors.New("invalid checksum")
+)
+
+// Types are the plugin types that Grafana loads.
+var Types = []string{"app", "datasource", "panel", "renderer", "secretsmanager"}
+
+// JSON has the fields of a 'plugin.json' that are validated.
+type JSON struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+}
+
+// ParseJSON parses a 'plugin.json' and checks that it has an ID, a known type, and a version.
+func ParseJSON(b []byte) (*JSON, error) {
+	p := &JSON{}
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("%w: error parsing %s: %s", ErrorInvalidPlugin, JSONFile, err)
+	}
+
+	if p.ID == "" {
+		return nil, fmt.Errorf("%w: %s has no 'id'", ErrorInvalidPlugin, JSONFile)
+	}
+	if !slices.Contains(Types, p.Type) {
+		return nil, fmt.Errorf("%w: %s: unknown type '%s'", ErrorInvalidPlugin, p.ID, p.Type)
+	}
+	if p.Info.Version == "" {
+		return nil, fmt.Errorf("%w: %s: %s has no 'info.version'", ErrorInvalidPlugin, p.ID, JSONFile)
+	}
+
+	return p, nil
+}
+
+// Manifest is the signed manifest in a plugin's 'MANIFEST.txt'.
+type Manifest struct {
+	ManifestVersion string `json:"manifestVersion"`
+	SignatureType   string `json:"signatureType"`
+	SignedByOrg     string `json:"signedByOrg"`
+	Plugin          string `json:"plugin"`
+	Version         string `json:"version"`
+	// Files are the sha256 sums of every file in the plugin, by path relative to the plugin directory.
+	Files map[string]string `json:"files"`
+}
+
+const (
+	signedMessageHeader = "-----BEGIN PGP SIGNED MESSAGE-----"
+	signatureHeader     = "-----BEGIN PGP SIGNATURE-----"
+)
+
+// ParseManifest parses the JSON in a 'MANIFEST.txt', which is a PGP cleartext signed message. The signature itself is not verified; see
+// VerifySignatures.
+func ParseManifest(b []byte) (*Manifest, error) {
+	s := strings.ReplaceAll(string(b), "\r\n", "\n")
+	_, body, ok := strings.Cut(s, signedMessageHeader+"\n")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a signed message", ErrorInvalidSignature, ManifestFile)
+	}
+	body, _, ok = strings.Cut(body, signatureHeader)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no signature", ErrorInvalidSignature, ManifestFile)
+	}
+
+	// The armor headers (like 'Hash: SHA512') end with an empty line.
+	if _, text, ok := strings.Cut(body, "\n\n"); ok {
+		body = text
+	}
+
+	lines := strings.Split(body, "\n")
+	for i, v := range lines {
+		// Lines that start with a dash are escaped with '- '.
+		lines[i] = strings.TrimPrefix(v, "- ")
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), m); err != nil {
+		return nil, fmt.Errorf("%w: error parsing %s: %s", ErrorInvalidSignature, ManifestFile, err)
+	}
+
+	return m, nil
+}
+
+// A Plugin is a plugin that was read from a zip file.
+type Plugin struct {
+	// Root is the folder in the zip file that has the plugin, like 'grafana-clock-panel'.
+	Root     string
+	JSON     *JSON
+	Manifest *Manifest
+	// Files are the sha256 sums of every file in the plugin except the manifest, by path relative to Root.
+	Files map[string]string
+}
+
+// ReadZip reads a plugin zip file. Every file in the zip must be in the same folder, which has the 'plugin.json' and the 'MANIFEST.txt'.
+func ReadZip(r io.ReaderAt, size int64) (*Plugin, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidPlugin, err)
+	}
+
+	var (
+		p        = &Plugin{Files: map[string]string{}}
+		pj       []byte
+		manifest []byte
+	)
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return nil, fmt.Errorf("%w: '%s' is not a regular file", ErrorInvalidPlugin, f.Name)
+		}
+
+		name := path.Clean(strings.TrimPrefix(f.Name, "./"))
+		root, rel, ok := strings.Cut(name, "/")
+		if !ok || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return nil, fmt.Errorf("%w: '%s' is not in the plugin folder", ErrorInvalidPlugin, f.Name)
+		}
+		if p.Root == "" {
+			p.Root = root
+		}
+		if root != p.Root {
+			return nil, fmt.Errorf("%w: the zip file has more than one folder ('%s' and '%s')", ErrorInvalidPlugin, p.Root, root)
+		}
+
+		b, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		switch rel {
+		case JSONFile:
+			pj = b
+		case ManifestFile:
+			manifest = b
+			continue
+		}
+		p.Files[rel] = fmt.Sprintf("%x", sha256.Sum256(b))
+	}
+
+	if pj == nil {
+		return nil, fmt.Errorf("%w: %s not found", ErrorInvalidPlugin, JSONFile)
+	}
+	if p.JSON, err = ParseJSON(pj); err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: %s: the plugin is not signed; %s not found", ErrorInvalidSignature, p.JSON.ID, ManifestFile)
+	}
+	if p.Manifest, err = ParseManifest(manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.JSON.ID, err)
+	}
+
+	return p, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// Validate checks that the manifest is for this plugin and version, and that it lists every file in the plugin with its sha256 sum, the
+// same way that Grafana does before it loads a plugin.
+func (p *Plugin) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s: %s", ErrorInvalidSignature, p.JSON.ID, fmt.Sprintf(format, args...))
+	}
+
+	if p.Manifest.Plugin != p.JSON.ID {
+		return invalid("the manifest is for plugin '%s'", p.Manifest.Plugin)
+	}
+	if p.Manifest.Version != p.JSON.Info.Version {
+		return invalid("the manifest is for version '%s', but the plugin is version '%s'", p.Manifest.Version, p.JSON.Info.Version)
+	}
+
+	names := make([]string, 0, len(p.Manifest.Files))
+	for k := range p.Manifest.Files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sum, ok := p.Files[name]
+		if !ok {
+			return invalid("'%s' is in the manifest but not in the plugin", name)
+		}
+		if sum != p.Manifest.Files[name] {
+			return invalid("'%s' was modified", name)
+		}
+	}
+
+	for name := range p.Files {
+		if _, ok := p.Manifest.Files[name]; !ok {
+			return invalid("'%s' is not in the manifest", name)
+		}
+	}
+
+	return nil
+}
+
+// ParseChecksums parses a checksum file in the format of 'sha256sum' ('<sha256>  <file>' on every line), returning the sums by file name.
+// Only the base name of every file is used.
+func ParseChecksums(s string) (map[string]string, error) {
+	sums := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("%w: unexpected line '%s'", ErrorInvalidChecksum, line)
+		}
+
+		// 'sha256sum --binary' marks file names with a '*'.
+		name := path.Base(strings.TrimPrefix(fields[1], "*"))
+		sums[name] = strings.ToLower(fields[0])
+	}
+
+	return sums, nil
+}
+
+// CheckChecksum returns an error if the sha256 sum of 'r' is not 'sum'.
+func CheckChecksum(r io.Reader, name, sum string) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+
+	if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != strings.ToLower(sum) {
+		return fmt.Errorf("%w: '%s' has sha256 '%s', expected '%s'", ErrorInvalidChecksum, name, actual, sum)
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package plugins_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-build/plugins"
+)
+
+const pluginJSON = `{"id": "grafana-clock-panel", "type": "panel", "name": "Clock", "info": {"version": "2.1.5"}}`
+
+func manifest(t *testing.T, id, version string, files map[string]string) string {
+	t.Helper()
+	sums := map[string]string{}
+	for k, v := range files {
+		sums[k] = fmt.Sprintf("%x", sha256.Sum256([]byte(v)))
+	}
+
+	b, err := json.MarshalIndent(map[string]any{
+		"manifestVersion": "2.0.0",
+		"signatureType":   "grafana",
+		"signedByOrg":     "grafana",
+		"plugin":          id,
+		"version":         version,
+		"files":           sums,
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return "-----BEGIN PGP SIGNED MESSAGE-----\nHash: SHA512\n\n" + string(b) + "\n-----BEGIN PGP SIGNATURE-----\n\nwsBcBAEBCAAQBQJk\n-----END PGP SIGNATURE-----\n"
+}
+
+func pluginZip(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for k, v := range files {
+		f, err := w.Create(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+// signedPlugin returns the files of a plugin zip, with a manifest that lists 'signed' (which defaults to 'files').
+func signedPlugin(t *testing.T, files, signed map[string]string) map[string]string {
+	t.Helper()
+	if signed == nil {
+		signed = files
+	}
+
+	r := map[string]string{"grafana-clock-panel/MANIFEST.txt": manifest(t, "grafana-clock-panel", "2.1.5", signed)}
+	for k, v := range files {
+		r["grafana-clock-panel/"+k] = v
+	}
+
+	return r
+}
+
+func readPlugin(t *testing.T, files map[string]string) (*plugins.Plugin, error) {
+	t.Helper()
+	r := pluginZip(t, files)
+	return plugins.ReadZip(r, r.Size())
+}
+
+func TestReadZip(t *testing.T) {
+	files := map[string]string{
+		"plugin.json":    pluginJSON,
+		"module.js":      "console.log('clock')",
+		"img/clock.svg":  "<svg/>",
+		"CHANGELOG.md":   "# Changelog",
+		"dist/README.md": "# Clock",
+	}
+
+	t.Run("A signed plugin should be valid", func(t *testing.T) {
+		p, err := readPlugin(t, signedPlugin(t, files, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Root != "grafana-clock-panel" || p.JSON.ID != "grafana-clock-panel" || p.JSON.Info.Version != "2.1.5" {
+			t.Fatalf("unexpected plugin: %+v", p)
+		}
+		if p.Manifest.SignatureType != "grafana" {
+			t.Fatalf("expected signature type 'grafana', got '%s'", p.Manifest.SignatureType)
+		}
+		if err := p.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Modified files should be invalid", func(t *testing.T) {
+		modified := signedPlugin(t, files, nil)
+		modified["grafana-clock-panel/module.js"] = "console.log('modified')"
+
+		p, err := readPlugin(t, modified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Validate(); !errors.Is(err, plugins.ErrorInvalidSignature) || !strings.Contains(err.Error(), "module.js") {
+			t.Fatalf("expected an invalid signature for 'module.js', got %v", err)
+		}
+	})
+
+	t.Run("Files that are not in the manifest should be invalid", func(t *testing.T) {
+		extra := signedPlugin(t, files, nil)
+		extra["grafana-clock-panel/extra.js"] = "console.log('extra')"
+
+		p, err := readPlugin(t, extra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Validate(); !errors.Is(err, plugins.ErrorInvalidSignature) || !strings.Contains(err.Error(), "extra.js") {
+			t.Fatalf("expected an invalid signature for 'extra.js', got %v", err)
+		}
+	})
+
+	t.Run("A manifest for another version should be invalid", func(t *testing.T) {
+		other := signedPlugin(t, files, nil)
+		other["grafana-clock-panel/plugin.json"] = strings.Replace(pluginJSON, "2.1.5", "2.1.6", 1)
+
+		p, err := readPlugin(t, other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Validate(); !errors.Is(err, plugins.ErrorInvalidSignature) {
+			t.Fatalf("expected an invalid signature, got %v", err)
+		}
+	})
+
+	t.Run("Unsigned plugins should be invalid", func(t *testing.T) {
+		unsigned := signedPlugin(t, files, nil)
+		delete(unsigned, "grafana-clock-panel/MANIFEST.txt")
+
+		if _, err := readPlugin(t, unsigned); !errors.Is(err, plugins.ErrorInvalidSignature) {
+			t.Fatalf("expected an invalid signature, got %v", err)
+		}
+	})
+
+	t.Run("Zip files with more than one folder should be invalid", func(t *testing.T) {
+		folders := signedPlugin(t, files, nil)
+		folders["other/plugin.json"] = pluginJSON
+
+		if _, err := readPlugin(t, folders); !errors.Is(err, plugins.ErrorInvalidPlugin) {
+			t.Fatalf("expected an invalid plugin, got %v", err)
+		}
+	})
+
+	t.Run("Zip files without plugin.json should be invalid", func(t *testing.T) {
+		if _, err := readPlugin(t, signedPlugin(t, map[string]string{"module.js": ""}, nil)); !errors.Is(err, plugins.ErrorInvalidPlugin) {
+			t.Fatalf("expected an invalid plugin, got %v", err)
+		}
+	})
+}
+
+func TestParseJSON(t *testing.T) {
+	for _, v := range []string{
+		`{"type": "panel", "info": {"version": "1.0.0"}}`,
+		`{"id": "a", "type": "widget", "info": {"version": "1.0.0"}}`,
+		`{"id": "a", "type": "panel"}`,
+		`{`,
+	} {
+		if _, err := plugins.ParseJSON([]byte(v)); !errors.Is(err, plugins.ErrorInvalidPlugin) {
+			t.Fatalf("expected '%s' to be invalid, got %v", v, err)
+		}
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	t.Run("Dash-escaped lines should be unescaped", func(t *testing.T) {
+		m, err := plugins.ParseManifest([]byte("-----BEGIN PGP SIGNED MESSAGE-----\r\nHash: SHA512\r\n\r\n{\r\n\"plugin\": \"a\",\r\n- \"version\": \"1.0.0\"\r\n}\r\n-----BEGIN PGP SIGNATURE-----\r\n-----END PGP SIGNATURE-----\r\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Plugin != "a" || m.Version != "1.0.0" {
+			t.Fatalf("unexpected manifest: %+v", m)
+		}
+	})
+
+	t.Run("Manifests without a signature should be invalid", func(t *testing.T) {
+		if _, err := plugins.ParseManifest([]byte(`{"plugin": "a"}`)); !errors.Is(err, plugins.ErrorInvalidSignature) {
+			t.Fatalf("expected an invalid signature, got %v", err)
+		}
+	})
+}
+
+func TestChecksums(t *testing.T) {
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte("plugin")))
+	sums, err := plugins.ParseChecksums(fmt.Sprintf("# plugins\n%s  plugins/a.zip\n%s *b.zip\n\n", sum, strings.ToUpper(sum)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sums["a.zip"] != sum || sums["b.zip"] != sum {
+		t.Fatalf("unexpected checksums: %v", sums)
+	}
+
+	if err := plugins.CheckChecksum(strings.NewReader("plugin"), "a.zip", sums["a.zip"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := plugins.CheckChecksum(strings.NewReader("modified"), "a.zip", sums["a.zip"]); !errors.Is(err, plugins.ErrorInvalidChecksum) {
+		t.Fatalf("expected an invalid checksum, got %v", err)
+	}
+
+	if _, err := plugins.ParseChecksums("abc  a.zip"); !errors.Is(err, plugins.ErrorInvalidChecksum) {
+		t.Fatalf("expected an invalid checksum file, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Package repos creates apt and yum repositories from built packages.
+package repos
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/containers"
+	"github.com/grafana/grafana-build/gpg"
+)
+
+const (
+	AptImage = "debian:stable"
+
+	DefaultAptSuite     = "stable"
+	DefaultAptComponent = "main"
+	DefaultOrigin       = "Grafana"
+)
+
+// AptOpts are the options for creating an apt repository.
+type AptOpts struct {
+	Suite     string
+	Component string
+	// Origin is used for the 'Origin' and 'Label' fields of the Release file.
+	Origin string
+	// Sign signs the Release file ('InRelease' and 'Release.gpg') with the GPG keys. The armored public key is added to the repository as
+	// 'gpg.key'.
+	Sign bool
+	GPG  gpg.GPGOpts
+}
+
+// aptScript adds the debs in /src/debs to the pool of the repository in /repo and then writes the indexes and Release file of the suite.
+// Every deb in the pool is indexed, so debs from an existing repository are kept. Other suites and components are not changed.
+const aptScript = `set -e
+cd /repo
+pool="pool/$COMPONENT"
+for f in /src/debs/*.deb; do
+  name=$(dpkg-deb -f "$f" Package)
+  version=$(dpkg-deb -f "$f" Version)
+  arch=$(dpkg-deb -f "$f" Architecture)
+  dir="$pool/$(printf %s "$name" | cut -c1)/$name"
+  mkdir -p "$dir"
+  cp "$f" "$dir/${name}_${version#*:}_${arch}.deb"
+done
+
+archs=$(find "$pool" -name '*.deb' -exec dpkg-deb -f {} Architecture \; | grep -vx all | sort -u)
+dists="dists/$SUITE"
+rm -rf "$dists/$COMPONENT" "$dists/Release" "$dists/InRelease" "$dists/Release.gpg"
+for arch in $archs; do
+  dir="$dists/$COMPONENT/binary-$arch"
+  mkdir -p "$dir"
+  apt-ftparchive --arch "$arch" packages "$pool" > "$dir/Packages"
+  gzip -9nkf "$dir/Packages"
+done
+
+components=$(find "$dists" -mindepth 1 -maxdepth 1 -type d -exec basename {} \; | sort | tr '\n' ' ')
+archs=$(find "$dists" -mindepth 2 -maxdepth 2 -type d -name 'binary-*' | sed 's/.*binary-//' | sort -u | tr '\n' ' ')
+apt-ftparchive \
+  -o APT::FTPArchive::Release::Origin="$ORIGIN" \
+  -o APT::FTPArchive::Release::Label="$ORIGIN" \
+  -o APT::FTPArchive::Release::Suite="$SUITE" \
+  -o APT::FTPArchive::Release::Codename="$SUITE" \
+  -o APT::FTPArchive::Release::Architectures="$archs" \
+  -o APT::FTPArchive::Release::Components="$components" \
+  release "$dists" > /tmp/Release
+mv /tmp/Release "$dists/Release"
+`
+
+const aptSignScript = `set -e
+cd /repo
+gpg --clearsign --output "dists/$SUITE/InRelease" "dists/$SUITE/Release"
+gpg --armor --detach-sign --output "dists/$SUITE/Release.gpg" "dists/$SUITE/Release"
+cp /tmp/grafana.key gpg.key
+`
+
+// Apt returns an apt repository with the 'debs' in 'pool/' and the indexes in 'dists/<suite>/<component>/binary-<arch>/'. If 'existing' is
+// not nil, the debs are added to that repository instead of a new one.
+func Apt(d *dagger.Client, debs []*dagger.File, existing *dagger.Directory, opts AptOpts) *dagger.Directory {
+	var c *dagger.Container
+	if opts.Sign {
+		c = gpg.Signer(d, opts.GPG.GPGPublicKey, opts.GPG.GPGPrivateKey, opts.GPG.GPGPassphrase)
+	} else {
+		c = d.Container().From(AptImage)
+	}
+
+	if existing == nil {
+		existing = d.Directory()
+	}
+
+	src := d.Directory()
+	for i, v := range debs {
+		src = src.WithFile(fmt.Sprintf("%d.deb", i), v)
+	}
+
+	c = c.WithExec([]string{"apt-get", "update"}).
+		WithExec([]string{"apt-get", "install", "-yq", "apt-utils"}).
+		WithDirectory("/repo", existing).
+		WithDirectory("/src/debs", src).
+		WithEnvVariable("SUITE", opts.Suite).
+		WithEnvVariable("COMPONENT", opts.Component).
+		WithEnvVariable("ORIGIN", opts.Origin).
+		WithExec([]string{"/bin/sh", "-c", aptScript})
+
+	if opts.Sign {
+		c = c.WithExec([]string{"/bin/sh", "-c", aptSignScript})
+	}
+
+	return c.Directory("/repo")
+}
+
+// aptVerifyScript adds the repository as an apt source and checks that every deb in the pool is available.
+const aptVerifyScript = `set -e
+for dir in /repo/dists/$SUITE/$COMPONENT/binary-*; do
+  dpkg --add-architecture "${dir##*binary-}"
+done
+echo "deb [$OPTIONS] file:/repo $SUITE $COMPONENT" > /etc/apt/sources.list.d/repo.list
+apt-get update -o Dir::Etc::sourcelist=/etc/apt/sources.list.d/repo.list -o Dir::Etc::sourceparts=- -o APT::Get::List-Cleanup=0
+for f in $(find "/repo/pool/$COMPONENT" -name '*.deb'); do
+  name=$(dpkg-deb -f "$f" Package)
+  version=$(dpkg-deb -f "$f" Version)
+  arch=$(dpkg-deb -f "$f" Architecture)
+  if [ "$arch" != all ]; then
+    name="$name:$arch"
+  fi
+  apt-cache show "$name=$version" > /dev/null || { echo "$name=$version is in the pool but not in the repository"; exit 1; }
+done
+`
+
+// VerifyApt adds the apt repository 'dir' as an apt source and checks that apt can read it and that every deb in the pool is in the indexes.
+// If the repository is signed, apt checks the signature of the Release file with only the public key.
+func VerifyApt(ctx context.Context, d *dagger.Client, dir *dagger.Directory, opts AptOpts) error {
+	c := d.Container().From(AptImage).
+		WithDirectory("/repo", dir).
+		WithEnvVariable("SUITE", opts.Suite).
+		WithEnvVariable("COMPONENT", opts.Component)
+
+	if opts.Sign {
+		c = gpg.Verifier(d, opts.GPG.GPGPublicKey).
+			WithDirectory("/repo", dir).
+			WithEnvVariable("SUITE", opts.Suite).
+			WithEnvVariable("COMPONENT", opts.Component).
+			WithExec([]string{"/bin/sh", "-c", "gpg --dearmor < /repo/gpg.key > /usr/share/keyrings/repo.gpg"}).
+			WithEnvVariable("OPTIONS", "signed-by=/usr/share/keyrings/repo.gpg")
+	} else {
+		c = c.WithEnvVariable("OPTIONS", "trusted=yes")
+	}
+
+	if _, err := containers.ExitError(ctx, c.WithExec([]string{"/bin/sh", "-c", aptVerifyScript})); err != nil {
+		return fmt.Errorf("failed to verify the apt repository in '%s': %w", path.Join("dists", opts.Suite), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"dagger.io/dagger"
+	"github.com/grafana/grafana-build/containers"
+	"github.com/grafana/grafana-build/gpg"
+	"golang.org/x/sync/errgroup"
+)
+
+const YumImage = "debian:stable"
+
+// YumVerifyImages are the distributions that the packages are installed on from the yum repository when verifying it.
+var YumVerifyImages = []string{
+	"rockylinux:9",
+	"fedora:latest",
+}
+
+// YumOpts are the options for creating a yum repository.
+type YumOpts struct {
+	// Sign signs 'repodata/repomd.xml' ('repomd.xml.asc') with the GPG keys. The armored public key is added to the repository as 'gpg.key'.
+	Sign bool
+	GPG  gpg.GPGOpts
+}
+
+// yumScript adds the rpms in /src/rpms to 'Packages/' in the repository in /repo and then updates 'repodata/'. With '--update',
+// createrepo_c reuses the metadata of the packages that are already in the repository and only reads the new ones.
+const yumScript = `set -e
+mkdir -p /repo/Packages
+for f in /src/rpms/*.rpm; do
+  cp "$f" "/repo/Packages/$(rpm -qp --qf '%{NAME}-%{VERSION}-%{RELEASE}.%{ARCH}' "$f").rpm"
+done
+rm -f /repo/repodata/repomd.xml.asc
+createrepo_c --update /repo
+`
+
+const yumSignScript = `set -e
+cd /repo
+gpg --armor --detach-sign --output repodata/repomd.xml.asc repodata/repomd.xml
+cp /tmp/grafana.key gpg.key
+`
+
+// Yum returns a yum repository with the 'rpms' in 'Packages/' and the metadata in 'repodata/'. If 'existing' is not nil, the rpms are added
+// to that repository instead of a new one.
+func Yum(d *dagger.Client, rpms []*dagger.File, existing *dagger.Directory, opts YumOpts) *dagger.Directory {
+	var c *dagger.Container
+	if opts.Sign {
+		c = gpg.Signer(d, opts.GPG.GPGPublicKey, opts.GPG.GPGPrivateKey, opts.GPG.GPGPassphrase)
+	} else {
+		c = d.Container().From(YumImage)
+	}
+
+	if existing == nil {
+		existing = d.Directory()
+	}
+
+	src := d.Directory()
+	for i, v := range rpms {
+		src = src.WithFile(fmt.Sprintf("%d.rpm", i), v)
+	}
+
+	c = c.WithExec([]string{"apt-get", "update"}).
+		WithExec([]string{"apt-get", "install", "-yq", "rpm", "createrepo-c"}).
+		WithDirectory("/repo", existing).
+		WithDirectory("/src/rpms", src).
+		WithExec([]string{"/bin/sh", "-c", yumScript})
+
+	if opts.Sign {
+		c = c.WithExec([]string{"/bin/sh", "-c", yumSignScript})
+	}
+
+	return c.Directory("/repo")
+}
+
+// RepoService serves the repository 'dir' with nginx on port 80.
+func RepoService(d *dagger.Client, dir *dagger.Directory) *dagger.Service {
+	return d.Container().From("nginx:1.27-alpine").
+		WithMountedDirectory("/usr/share/nginx/html", dir).
+		WithExposedPort(80).
+		AsService()
+}
+
+// yumVerifyScript adds the repository served at 'http://repo' as a dnf repository. Every rpm in the repository for the architecture of the
+// container is installed from it and removed again, and the rpms for other architectures must be in the metadata.
+const yumVerifyScript = `set -e
+cat > /etc/yum.repos.d/verify.repo <<EOF
+[verify]
+name=verify
+baseurl=http://repo
+enabled=1
+gpgcheck=$GPGCHECK
+repo_gpgcheck=$GPGCHECK
+gpgkey=http://repo/gpg.key
+EOF
+
+host=$(uname -m)
+for f in $(find /repo/Packages -name '*.rpm'); do
+  name=$(rpm -qp --nosignature --qf '%{NAME}' "$f")
+  arch=$(rpm -qp --nosignature --qf '%{ARCH}' "$f")
+  nevra=$(rpm -qp --nosignature --qf '%{NAME}-%{VERSION}-%{RELEASE}.%{ARCH}' "$f")
+  if [ "$arch" = "$host" ] || [ "$arch" = noarch ]; then
+    dnf install -y "$nevra"
+    dnf remove -y "$name"
+  elif [ -z "$(dnf repoquery -q --repo verify --forcearch "$arch" "$nevra")" ]; then
+    echo "$nevra is in Packages but not in the repository"
+    exit 1
+  fi
+done
+`
+
+// VerifyYum serves the yum repository 'dir' over HTTP and installs every rpm in it with dnf in each of the YumVerifyImages. If the
+// repository is signed, dnf checks the signatures of the metadata and the rpms with the public key in the repository.
+func VerifyYum(ctx context.Context, d *dagger.Client, dir *dagger.Directory, opts YumOpts) error {
+	gpgcheck := "0"
+	if opts.Sign {
+		gpgcheck = "1"
+	}
+
+	svc := RepoService(d, dir)
+	wg, ctx := errgroup.WithContext(ctx)
+	for _, image := range YumVerifyImages {
+		image := image
+		wg.Go(func() error {
+			c := d.Container().From(image).
+				WithServiceBinding("repo", svc).
+				WithMountedDirectory("/repo", dir).
+				WithEnvVariable("GPGCHECK", gpgcheck).
+				WithExec([]string{"/bin/sh", "-c", yumVerifyScript})
+
+			if _, err := containers.ExitError(ctx, c); err != nil {
+				return fmt.Errorf("failed to verify the yum repository in %s: %w", image, err)
+			}
+			return nil
+		})
+	}
+
+	return wg.Wait()
+}
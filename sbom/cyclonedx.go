@@ -0,0 +1,77 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const CycloneDXVersion = "1.5"
+
+type cdxDocument struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// CycloneDX returns the document encoded as CycloneDX 1.5 JSON.
+func CycloneDX(doc *Document) ([]byte, error) {
+	h := documentHash(doc)
+	// The serial number must be a RFC 4122 UUID; derive a version 4 (random) formatted UUID from the document hash so that it's stable.
+	h[6] = (h[6] & 0x0f) | 0x40
+	h[8] = (h[8] & 0x3f) | 0x80
+	serial := fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+
+	d := cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  CycloneDXVersion,
+		SerialNumber: serial,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: doc.Created.Format(time.RFC3339),
+			Tools: cdxTools{
+				Components: []cdxComponent{{Type: "application", Name: Creator}},
+			},
+			Component: cdxComponent{
+				Type:    "application",
+				BOMRef:  doc.Name,
+				Name:    doc.Product,
+				Version: doc.Version,
+			},
+		},
+		Components: make([]cdxComponent, len(doc.Packages)),
+	}
+
+	for i, p := range doc.Packages {
+		d.Components[i] = cdxComponent{
+			Type:    "library",
+			BOMRef:  p.PURL(),
+			Name:    p.Name,
+			Version: p.Version,
+			PURL:    p.PURL(),
+		}
+	}
+
+	return json.MarshalIndent(d, "", "  ")
+}
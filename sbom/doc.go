@@ -0,0 +1,3 @@
+// Package sbom generates software bills of materials (SBOMs) for Grafana in the SPDX and CycloneDX formats.
+// Components are gathered from the Go build info embedded in the backend binaries ('go version -m') and from the frontend's yarn lockfile.
+package sbom
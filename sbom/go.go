@@ -0,0 +1,35 @@
+package sbom
+
+import (
+	"bufio"
+	"strings"
+)
+
+// GoPackages parses the output of 'go version -m' for one or more binaries and returns the modules that were compiled into them.
+// Replaced modules ('=>' lines) are reported using the replacement module instead of the original.
+func GoPackages(output string) ([]Package, error) {
+	pkgs := []Package{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+
+		switch fields[0] {
+		case "dep":
+			pkgs = append(pkgs, Package{Type: PackageTypeGo, Name: fields[1], Version: fields[2]})
+		case "=>":
+			// The replacement directly follows the dependency it replaces.
+			// Local replacements (paths) don't have a version and are left as-is.
+			if len(pkgs) == 0 || strings.HasPrefix(fields[1], ".") || strings.HasPrefix(fields[1], "/") {
+				continue
+			}
+			pkgs[len(pkgs)-1] = Package{Type: PackageTypeGo, Name: fields[1], Version: fields[2]}
+		}
+	}
+
+	return pkgs, scanner.Err()
+}
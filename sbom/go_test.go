@@ -0,0 +1,47 @@
+package sbom_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-build/sbom"
+)
+
+const goVersionOutput = `/src/bin/grafana: go1.23.1
+	path	github.com/grafana/grafana/pkg/cmd/grafana
+	mod	github.com/grafana/grafana	(devel)	
+	dep	cloud.google.com/go/storage	v1.43.0	h1:abc=
+	dep	github.com/prometheus/client_golang	v1.20.2	h1:def=
+	dep	github.com/grafana/grafana/pkg/apimachinery	v0.0.0-20240821155123-6891eb1d35da
+	=>	./pkg/apimachinery	(devel)	
+	dep	github.com/crewjam/saml	v0.4.14
+	=>	github.com/grafana/saml	v0.4.15-0.20240523142256-cc370b98af7c	h1:ghi=
+	build	-compiler=gc
+	build	GOOS=linux
+/src/bin/grafana-cli: go1.23.1
+	path	github.com/grafana/grafana/pkg/cmd/grafana-cli
+	dep	cloud.google.com/go/storage	v1.43.0	h1:abc=
+`
+
+func TestGoPackages(t *testing.T) {
+	pkgs, err := sbom.GoPackages(goVersionOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []sbom.Package{
+		{Type: sbom.PackageTypeGo, Name: "cloud.google.com/go/storage", Version: "v1.43.0"},
+		{Type: sbom.PackageTypeGo, Name: "github.com/prometheus/client_golang", Version: "v1.20.2"},
+		{Type: sbom.PackageTypeGo, Name: "github.com/grafana/grafana/pkg/apimachinery", Version: "v0.0.0-20240821155123-6891eb1d35da"},
+		{Type: sbom.PackageTypeGo, Name: "github.com/grafana/saml", Version: "v0.4.15-0.20240523142256-cc370b98af7c"},
+		{Type: sbom.PackageTypeGo, Name: "cloud.google.com/go/storage", Version: "v1.43.0"},
+	}
+
+	if len(pkgs) != len(expect) {
+		t.Fatalf("expected %d packages, got %d: %v", len(expect), len(pkgs), pkgs)
+	}
+	for i := range expect {
+		if pkgs[i] != expect[i] {
+			t.Fatalf("package %d: expected '%v', got '%v'", i, expect[i], pkgs[i])
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PackageType string
+
+const (
+	PackageTypeGo  PackageType = "golang"
+	PackageTypeNPM PackageType = "npm"
+)
+
+// A Package is a single component (Go module or npm package) that is included in the build.
+type Package struct {
+	Type    PackageType
+	Name    string
+	Version string
+}
+
+// PURL returns the package URL (https://github.com/package-url/purl-spec) of the package.
+func (p Package) PURL() string {
+	name := p.Name
+	if p.Type == PackageTypeNPM && strings.HasPrefix(name, "@") {
+		// Scoped npm packages use the scope as the namespace, and the '@' must be percent-encoded.
+		name = "%40" + strings.TrimPrefix(name, "@")
+	}
+
+	return fmt.Sprintf("pkg:%s/%s@%s", p.Type, name, url.PathEscape(p.Version))
+}
+
+// Document holds everything that is needed to create an SBOM in any format.
+type Document struct {
+	// Name is the name of the described artifact, like 'grafana_11.0.0_123_linux_amd64'.
+	Name    string
+	Product string
+	Version string
+	Created time.Time
+
+	Packages []Package
+}
+
+// NewDocument creates a Document with the packages de-duplicated and sorted so that the output is stable.
+func NewDocument(name, product, version string, created time.Time, pkgs ...[]Package) *Document {
+	seen := map[Package]bool{}
+	list := []Package{}
+	for _, p := range pkgs {
+		for _, v := range p {
+			if seen[v] {
+				continue
+			}
+			seen[v] = true
+			list = append(list, v)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Type != list[j].Type {
+			return list[i].Type < list[j].Type
+		}
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].Version < list[j].Version
+	})
+
+	return &Document{
+		Name:     name,
+		Product:  product,
+		Version:  strings.TrimPrefix(version, "v"),
+		Created:  created.UTC(),
+		Packages: list,
+	}
+}
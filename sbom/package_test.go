@@ -0,0 +1,122 @@
+package sbom_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-build/sbom"
+)
+
+func testDocument() *sbom.Document {
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	return sbom.NewDocument("grafana_11.1.0_123_linux_amd64", "grafana", "v11.1.0", created,
+		[]sbom.Package{
+			{Type: sbom.PackageTypeNPM, Name: "lodash", Version: "4.17.21"},
+			{Type: sbom.PackageTypeGo, Name: "github.com/prometheus/client_golang", Version: "v1.20.2"},
+		},
+		[]sbom.Package{
+			{Type: sbom.PackageTypeNPM, Name: "@babel/core", Version: "7.24.7"},
+			{Type: sbom.PackageTypeGo, Name: "github.com/prometheus/client_golang", Version: "v1.20.2"},
+		},
+	)
+}
+
+func TestPURL(t *testing.T) {
+	tests := map[sbom.Package]string{
+		{Type: sbom.PackageTypeGo, Name: "github.com/grafana/saml", Version: "v0.4.15"}: "pkg:golang/github.com/grafana/saml@v0.4.15",
+		{Type: sbom.PackageTypeNPM, Name: "lodash", Version: "4.17.21"}:                 "pkg:npm/lodash@4.17.21",
+		{Type: sbom.PackageTypeNPM, Name: "@babel/core", Version: "7.24.7"}:             "pkg:npm/%40babel/core@7.24.7",
+	}
+
+	for pkg, expect := range tests {
+		if res := pkg.PURL(); res != expect {
+			t.Fatalf("for '%v' got '%s', expected '%s'", pkg, res, expect)
+		}
+	}
+}
+
+func TestNewDocument(t *testing.T) {
+	doc := testDocument()
+	if doc.Version != "11.1.0" {
+		t.Fatalf("expected version '11.1.0', got '%s'", doc.Version)
+	}
+
+	expect := []string{"github.com/prometheus/client_golang", "@babel/core", "lodash"}
+	if len(doc.Packages) != len(expect) {
+		t.Fatalf("expected %d de-duplicated packages, got %d: %v", len(expect), len(doc.Packages), doc.Packages)
+	}
+	for i, name := range expect {
+		if doc.Packages[i].Name != name {
+			t.Fatalf("package %d: expected '%s', got '%s'", i, name, doc.Packages[i].Name)
+		}
+	}
+}
+
+func TestSPDX(t *testing.T) {
+	b, err := sbom.SPDX(testDocument())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Packages    []struct {
+			SPDXID string `json:"SPDXID"`
+		} `json:"packages"`
+		Relationships []struct {
+			Type string `json:"relationshipType"`
+		} `json:"relationships"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.SPDXVersion != sbom.SPDXVersion {
+		t.Fatalf("expected spdxVersion '%s', got '%s'", sbom.SPDXVersion, doc.SPDXVersion)
+	}
+	// 3 packages plus the described product
+	if len(doc.Packages) != 4 {
+		t.Fatalf("expected 4 packages, got %d", len(doc.Packages))
+	}
+	if len(doc.Relationships) != 4 || doc.Relationships[0].Type != "DESCRIBES" {
+		t.Fatalf("expected 1 DESCRIBES and 3 DEPENDS_ON relationships, got %v", doc.Relationships)
+	}
+
+	// The document should be stable for the same input.
+	b2, err := sbom.SPDX(testDocument())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(b2) {
+		t.Fatal("expected identical SPDX output for identical documents")
+	}
+}
+
+func TestCycloneDX(t *testing.T) {
+	b, err := sbom.CycloneDX(testDocument())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		BOMFormat    string `json:"bomFormat"`
+		SerialNumber string `json:"serialNumber"`
+		Components   []struct {
+			PURL string `json:"purl"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.BOMFormat != "CycloneDX" {
+		t.Fatalf("expected bomFormat 'CycloneDX', got '%s'", doc.BOMFormat)
+	}
+	if len(doc.SerialNumber) != len("urn:uuid:")+36 {
+		t.Fatalf("expected serialNumber to be a UUID URN, got '%s'", doc.SerialNumber)
+	}
+	if len(doc.Components) != 3 || doc.Components[1].PURL != "pkg:npm/%40babel/core@7.24.7" {
+		t.Fatalf("unexpected components: %v", doc.Components)
+	}
+}
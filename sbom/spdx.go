@@ -0,0 +1,128 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	SPDXVersion = "SPDX-2.3"
+	// SPDXNamespace is the prefix of the 'documentNamespace' field, which must be a unique URI for each document.
+	SPDXNamespace = "https://grafana.com/spdxdocs"
+	// Creator is used in both SPDX and CycloneDX documents to describe what tool created them.
+	Creator = "grafana-build"
+)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+var spdxIDInvalid = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+func spdxID(p Package) string {
+	return "SPDXRef-Package-" + spdxIDInvalid.ReplaceAllString(fmt.Sprintf("%s-%s-%s", p.Type, p.Name, p.Version), "-")
+}
+
+// SPDX returns the document encoded as SPDX 2.3 JSON.
+func SPDX(doc *Document) ([]byte, error) {
+	const noAssertion = "NOASSERTION"
+
+	root := spdxPackage{
+		Name:             doc.Product,
+		SPDXID:           "SPDXRef-Package-" + spdxIDInvalid.ReplaceAllString(doc.Product, "-"),
+		VersionInfo:      doc.Version,
+		DownloadLocation: noAssertion,
+		LicenseConcluded: noAssertion,
+		LicenseDeclared:  noAssertion,
+		CopyrightText:    noAssertion,
+	}
+
+	s := spdxDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              doc.Name,
+		DocumentNamespace: fmt.Sprintf("%s/%s-%x", SPDXNamespace, doc.Name, documentHash(doc)[:8]),
+		CreationInfo: spdxCreationInfo{
+			Created:  doc.Created.Format(time.RFC3339),
+			Creators: []string{"Tool: " + Creator, "Organization: Grafana Labs"},
+		},
+		Packages: []spdxPackage{root},
+		Relationships: []spdxRelationship{
+			{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: root.SPDXID},
+		},
+	}
+
+	for _, p := range doc.Packages {
+		id := spdxID(p)
+		s.Packages = append(s.Packages, spdxPackage{
+			Name:             p.Name,
+			SPDXID:           id,
+			VersionInfo:      p.Version,
+			DownloadLocation: noAssertion,
+			LicenseConcluded: noAssertion,
+			LicenseDeclared:  noAssertion,
+			CopyrightText:    noAssertion,
+			ExternalRefs: []spdxExternalRef{
+				{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: p.PURL()},
+			},
+		})
+		s.Relationships = append(s.Relationships, spdxRelationship{
+			SPDXElementID:      root.SPDXID,
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// documentHash is a hash of the contents of the document, used to derive unique (but stable) identifiers for a document.
+func documentHash(doc *Document) []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n", doc.Name, doc.Product, doc.Version, doc.Created.Format(time.RFC3339))
+	for _, p := range doc.Packages {
+		fmt.Fprintln(h, p.PURL())
+	}
+
+	return h.Sum(nil)
+}
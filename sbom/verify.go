@@ -0,0 +1,85 @@
+package sbom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrorInvalidDocument = errors.New("invalid SBOM document")
+	ErrorEmptyDocument   = errors.New("SBOM document has no packages")
+)
+
+// VerifySPDX checks that 'b' is an SPDX 2 JSON document that describes a product and lists at least one package that the product
+// depends on. Every relationship must refer to a package in the document.
+func VerifySPDX(b []byte) error {
+	var doc spdxDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("%w: error parsing SPDX JSON: %s", ErrorInvalidDocument, err)
+	}
+
+	if !strings.HasPrefix(doc.SPDXVersion, "SPDX-2.") {
+		return fmt.Errorf("%w: unexpected spdxVersion '%s'", ErrorInvalidDocument, doc.SPDXVersion)
+	}
+	if doc.SPDXID != "SPDXRef-DOCUMENT" || doc.Name == "" || doc.DocumentNamespace == "" {
+		return fmt.Errorf("%w: SPDXID, name, or documentNamespace is missing", ErrorInvalidDocument)
+	}
+
+	ids := map[string]bool{doc.SPDXID: true}
+	for i, p := range doc.Packages {
+		if p.Name == "" || p.SPDXID == "" {
+			return fmt.Errorf("%w: package %d has no name or SPDXID", ErrorInvalidDocument, i)
+		}
+		ids[p.SPDXID] = true
+	}
+
+	describes, dependencies := 0, 0
+	for _, r := range doc.Relationships {
+		if !ids[r.SPDXElementID] || !ids[r.RelatedSPDXElement] {
+			return fmt.Errorf("%w: relationship '%s %s %s' refers to a package that isn't in the document", ErrorInvalidDocument, r.SPDXElementID, r.RelationshipType, r.RelatedSPDXElement)
+		}
+		switch r.RelationshipType {
+		case "DESCRIBES":
+			describes++
+		case "DEPENDS_ON":
+			dependencies++
+		}
+	}
+
+	if describes == 0 {
+		return fmt.Errorf("%w: the document doesn't describe a package", ErrorInvalidDocument)
+	}
+	if dependencies == 0 {
+		return ErrorEmptyDocument
+	}
+
+	return nil
+}
+
+// VerifyCycloneDX checks that 'b' is a CycloneDX JSON document for a product with at least one component.
+func VerifyCycloneDX(b []byte) error {
+	var doc cdxDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("%w: error parsing CycloneDX JSON: %s", ErrorInvalidDocument, err)
+	}
+
+	if doc.BOMFormat != "CycloneDX" || doc.SpecVersion == "" {
+		return fmt.Errorf("%w: unexpected bomFormat '%s' or specVersion '%s'", ErrorInvalidDocument, doc.BOMFormat, doc.SpecVersion)
+	}
+	if doc.Metadata.Component.Name == "" {
+		return fmt.Errorf("%w: the metadata has no component", ErrorInvalidDocument)
+	}
+
+	if len(doc.Components) == 0 {
+		return ErrorEmptyDocument
+	}
+	for i, c := range doc.Components {
+		if c.Name == "" || c.Type == "" {
+			return fmt.Errorf("%w: component %d has no name or type", ErrorInvalidDocument, i)
+		}
+	}
+
+	return nil
+}
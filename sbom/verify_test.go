@@ -0,0 +1,69 @@
+package sbom_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-build/sbom"
+)
+
+func TestVerifySPDX(t *testing.T) {
+	t.Run("A generated document should be valid", func(t *testing.T) {
+		b, err := sbom.SPDX(testDocument())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sbom.VerifySPDX(b); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("A document without packages should be empty", func(t *testing.T) {
+		b, err := sbom.SPDX(sbom.NewDocument("grafana", "grafana", "v11.1.0", time.Now(), nil, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sbom.VerifySPDX(b); !errors.Is(err, sbom.ErrorEmptyDocument) {
+			t.Fatalf("expected ErrorEmptyDocument, got %v", err)
+		}
+	})
+
+	t.Run("Invalid documents should return an error", func(t *testing.T) {
+		for _, v := range []string{"", "{", `{"bomFormat":"CycloneDX"}`, `{"spdxVersion":"SPDX-2.3"}`} {
+			if err := sbom.VerifySPDX([]byte(v)); !errors.Is(err, sbom.ErrorInvalidDocument) {
+				t.Fatalf("expected ErrorInvalidDocument for '%s', got %v", v, err)
+			}
+		}
+	})
+}
+
+func TestVerifyCycloneDX(t *testing.T) {
+	t.Run("A generated document should be valid", func(t *testing.T) {
+		b, err := sbom.CycloneDX(testDocument())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sbom.VerifyCycloneDX(b); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("A document without components should be empty", func(t *testing.T) {
+		b, err := sbom.CycloneDX(sbom.NewDocument("grafana", "grafana", "v11.1.0", time.Now(), nil, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sbom.VerifyCycloneDX(b); !errors.Is(err, sbom.ErrorEmptyDocument) {
+			t.Fatalf("expected ErrorEmptyDocument, got %v", err)
+		}
+	})
+
+	t.Run("Invalid documents should return an error", func(t *testing.T) {
+		for _, v := range []string{"", "{", `{"spdxVersion":"SPDX-2.3"}`, `{"bomFormat":"CycloneDX","specVersion":"1.5"}`} {
+			if err := sbom.VerifyCycloneDX([]byte(v)); !errors.Is(err, sbom.ErrorInvalidDocument) {
+				t.Fatalf("expected ErrorInvalidDocument for '%s', got %v", v, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package sbom
+
+import (
+	"bufio"
+	"strings"
+)
+
+// YarnPackages parses a yarn lockfile and returns the npm packages that it resolves.
+// Both the Yarn 1 format and the Yarn 2+ ("berry") format are supported. Workspace packages, links, and patches of a package that is already
+// listed are ignored.
+func YarnPackages(lockfile string) ([]Package, error) {
+	var (
+		pkgs    = []Package{}
+		name    string
+		version string
+		skip    bool
+	)
+
+	flush := func() {
+		if name != "" && version != "" && !skip {
+			pkgs = append(pkgs, Package{Type: PackageTypeNPM, Name: name, Version: version})
+		}
+		name, version, skip = "", "", false
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(lockfile))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Entries start at column 0, like '"@babel/core@npm:^7.0.0, @babel/core@npm:^7.1.0":' or 'lodash@^4.17.21:'
+		if !strings.HasPrefix(line, " ") {
+			flush()
+			name = yarnEntryName(line)
+			continue
+		}
+
+		key, value := yarnField(line)
+		switch key {
+		case "version":
+			version = value
+		case "resolution":
+			if strings.Contains(value, "@workspace:") || strings.Contains(value, "@link:") || strings.Contains(value, "@portal:") {
+				skip = true
+			}
+		case "linkType":
+			if value == "soft" {
+				skip = true
+			}
+		}
+	}
+	flush()
+
+	return pkgs, scanner.Err()
+}
+
+// yarnEntryName returns the package name from the first descriptor of a lockfile entry.
+func yarnEntryName(line string) string {
+	line = strings.TrimSuffix(strings.TrimSpace(line), ":")
+	descriptor := strings.Trim(strings.TrimSpace(strings.Split(line, ",")[0]), `"`)
+	if descriptor == "" || descriptor == "__metadata" {
+		return ""
+	}
+
+	// The name ends at the first '@' that is not the start of a scope.
+	i := strings.Index(descriptor[1:], "@")
+	if i < 0 {
+		return descriptor
+	}
+
+	return descriptor[:i+1]
+}
+
+// yarnField parses a field in a yarn lockfile entry. Berry uses 'key: value' and Yarn 1 uses 'key "value"'.
+func yarnField(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	sep := strings.IndexAny(line, ": ")
+	if sep < 0 {
+		return line, ""
+	}
+
+	key := line[:sep]
+	value := strings.TrimSpace(strings.TrimPrefix(line[sep:], ":"))
+	return key, strings.Trim(value, `"`)
+}
@@ -0,0 +1,81 @@
+package sbom_test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-build/sbom"
+)
+
+const berryLockfile = `# This file is generated by running "yarn install" inside your project.
+# Manual changes might be lost - proceed with caution!
+
+__metadata:
+  version: 8
+  cacheKey: 10
+
+"@babel/core@npm:^7.0.0, @babel/core@npm:^7.1.0":
+  version: 7.24.7
+  resolution: "@babel/core@npm:7.24.7"
+  checksum: 10/abc
+  languageName: node
+  linkType: hard
+
+"@grafana/data@workspace:*, @grafana/data@workspace:packages/grafana-data":
+  version: 0.0.0-use.local
+  resolution: "@grafana/data@workspace:packages/grafana-data"
+  languageName: unknown
+  linkType: soft
+
+"lodash@npm:4.17.21, lodash@npm:^4.17.21":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+  languageName: node
+  linkType: hard
+`
+
+const v1Lockfile = `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+  version "7.24.7"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.24.7.tgz"
+  dependencies:
+    lodash "^4.17.21"
+
+lodash@^4.17.21:
+  version "4.17.21"
+  resolved "https://registry.yarnpkg.com/lodash/-/lodash-4.17.21.tgz"
+`
+
+func TestYarnPackages(t *testing.T) {
+	expect := []sbom.Package{
+		{Type: sbom.PackageTypeNPM, Name: "@babel/core", Version: "7.24.7"},
+		{Type: sbom.PackageTypeNPM, Name: "lodash", Version: "4.17.21"},
+	}
+
+	for name, lockfile := range map[string]string{"berry": berryLockfile, "v1": v1Lockfile} {
+		pkgs, err := sbom.YarnPackages(lockfile)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if len(pkgs) != len(expect) {
+			t.Fatalf("%s: expected %d packages, got %d: %v", name, len(expect), len(pkgs), pkgs)
+		}
+		for i := range expect {
+			if pkgs[i] != expect[i] {
+				t.Fatalf("%s: package %d: expected '%v', got '%v'", name, i, expect[i], pkgs[i])
+			}
+		}
+	}
+}
+
+func TestYarnPackagesEmptyEntry(t *testing.T) {
+	pkgs, err := sbom.YarnPackages(":\n  version: 1.0.0\n\"\":\n  version: 1.0.0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 0 {
+		t.Fatalf("expected no packages for entries without a name, got %v", pkgs)
+	}
+}
@@ -34,6 +34,9 @@ type Opts struct {
 	// to dagger directories.
 	Directories []MappedDirectory
 	Files       []MappedFile
+
+	// Overlay is copied over the root after the directories and files are added, so it can replace any of them and add new files.
+	Overlay *dagger.Directory
 }
 
 func Build(packager *dagger.Container, opts *Opts) *dagger.File {
@@ -55,6 +58,15 @@ func Build(packager *dagger.Container, opts *Opts) *dagger.File {
 		paths = append(paths, path)
 	}
 
+	if opts.Overlay != nil {
+		// The overlay can add new folders at the top of the root, so the whole root is archived instead of the mapped paths.
+		// 'cp -a' keeps the permissions, symlinks, and timestamps of the overlay's files.
+		packager = packager.
+			WithMountedDirectory("/overlay", opts.Overlay).
+			WithExec([]string{"cp", "-a", "/overlay/.", root})
+		paths = []string{root}
+	}
+
 	packager = packager.WithExec(append([]string{"tar", "-czf", "/package.tar.gz"}, paths...))
 
 	return packager.File("/package.tar.gz")